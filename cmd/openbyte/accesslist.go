@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/accesslist"
+)
+
+const accessListPollInterval = 5 * time.Second
+
+// watchAccessList reloads the access list when its file changes or when the
+// process receives SIGHUP. Failed reloads keep the previous rules.
+func watchAccessList(list *accesslist.List, interval time.Duration, stop <-chan struct{}, hup <-chan os.Signal) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-hup:
+			if err := list.Reload(); err != nil {
+				slog.Error("access list reload failed; keeping previous rules", "path", list.Path(), "error", err)
+			}
+		case <-ticker.C:
+			if err := list.ReloadIfChanged(); err != nil {
+				slog.Error("access list reload failed; keeping previous rules", "path", list.Path(), "error", err)
+			}
+		}
+	}
+}
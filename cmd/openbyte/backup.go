@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+// runScheduledBackups snapshots the results database every interval until
+// stop closes. Failures are logged and retried at the next tick.
+func runScheduledBackups(store *results.Store, dir string, keep int, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			snap, err := store.Backup(context.Background(), dir, keep)
+			if err != nil {
+				slog.Error("scheduled results backup failed", "dir", dir, "error", err)
+				continue
+			}
+			slog.Info("scheduled results backup written", "name", snap.Name, "size_bytes", snap.SizeBytes)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/geoip"
+)
+
+const geoIPPollInterval = 5 * time.Second
+
+// watchGeoIP reloads the GeoIP databases when a file in DATA_DIR is added,
+// removed, or replaced. Failed reloads keep the previous databases.
+func watchGeoIP(db *geoip.DB, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := db.ReloadIfChanged(); err != nil {
+				slog.Error("GeoIP reload failed; keeping previous databases", "dir", db.Dir(), "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/apikeys"
+	"github.com/saveenergy/openbyte/internal/config"
+)
+
+const keysUsage = `Usage:
+  openbyte keys create --name NAME [--admin] [--max-concurrent N] [--start-rate N] [--daily-quota N] [--max-duration 60s]
+  openbyte keys list
+  openbyte keys revoke ID
+
+Keys are stored hashed in DATA_DIR/apikeys.db. Clients send
+"Authorization: Bearer <key>". Zero limits use server defaults (concurrency,
+duration) or disable the limit (start rate per minute, daily transfer quota).
+--admin keys may also call the /api/v1/admin endpoints.`
+
+// runKeysCommand manages API keys offline or next to a running server; the
+// server picks up changes within the key cache TTL.
+func runKeysCommand(args []string, stdout io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintln(stdout, keysUsage)
+		if len(args) == 0 {
+			return exitFailure
+		}
+		return exitSuccess
+	}
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		slog.Error("Failed to load config", "error", err)
+		return exitFailure
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+		slog.Error("Failed to create data directory", "error", err)
+		return exitFailure
+	}
+	store, err := apikeys.Open(filepath.Join(cfg.DataDir, apikeys.FileName))
+	if err != nil {
+		slog.Error("Failed to open API key store", "error", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "create":
+		err = createAPIKey(ctx, store, args[1:], stdout)
+	case "list":
+		err = listAPIKeys(ctx, store, stdout)
+	case "revoke":
+		if len(args) != 2 {
+			err = errors.New("revoke requires exactly one key ID")
+			break
+		}
+		if err = store.Revoke(ctx, args[1]); err == nil {
+			fmt.Fprintf(stdout, "revoked %s\n", args[1])
+		}
+	default:
+		err = fmt.Errorf("unknown keys command %q", args[0])
+	}
+	if err != nil {
+		slog.Error("keys command failed", "error", err)
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+func createAPIKey(ctx context.Context, store *apikeys.Store, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("openbyte keys create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	name := fs.String("name", "", "Descriptive key name")
+	admin := fs.Bool("admin", false, "Allow the admin endpoints")
+	var policy apikeys.Policy
+	fs.IntVar(&policy.MaxConcurrent, "max-concurrent", 0, "Concurrent transfers per direction")
+	fs.IntVar(&policy.StartRate, "start-rate", 0, "Transfer starts per minute")
+	fs.IntVar(&policy.DailyQuota, "daily-quota", 0, "Transfer starts per UTC day")
+	fs.DurationVar(&policy.MaxDuration, "max-duration", 0, "Maximum transfer duration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if *name == "" {
+		return errors.New("--name is required")
+	}
+	key, token, err := store.Create(ctx, *name, policy, *admin)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "id:  %s\nkey: %s\n\nStore the key now; it cannot be shown again.\n", key.ID, token)
+	return nil
+}
+
+func listAPIKeys(ctx context.Context, store *apikeys.Store, stdout io.Writer) error {
+	keys, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSCOPE\tCONCURRENT\tSTART/MIN\tDAILY QUOTA\tMAX DURATION\tCREATED\tSTATUS")
+	for _, key := range keys {
+		status := "active"
+		if key.Revoked() {
+			status = "revoked " + key.RevokedAt.Format(time.RFC3339)
+		}
+		scope := "client"
+		if key.Admin {
+			scope = "admin"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			key.ID, key.Name, scope,
+			limitString(key.Policy.MaxConcurrent, "default"),
+			limitString(key.Policy.StartRate, "unlimited"),
+			limitString(key.Policy.DailyQuota, "unlimited"),
+			durationString(key.Policy.MaxDuration),
+			key.CreatedAt.Format(time.RFC3339),
+			status,
+		)
+	}
+	return tw.Flush()
+}
+
+func limitString(value int, zero string) string {
+	if value == 0 {
+		return zero
+	}
+	return fmt.Sprint(value)
+}
+
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return "default"
+	}
+	return d.String()
+}
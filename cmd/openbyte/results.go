@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const resultsUsage = `Usage:
+  openbyte results reencrypt
+  openbyte results restore SNAPSHOT
+  openbyte results replica-check [DIR]
+  openbyte results replica-restore [DIR]
+  openbyte results export [--format ndjson|csv] [--from TIME] [--to TIME] [--output FILE]
+  openbyte results import [--format ndjson|csv] FILE
+
+reencrypt rewrites the personal fields of every stored result under the
+first FIELD_ENCRYPTION_KEYS key, encrypting plaintext rows and rows sealed
+with older keys. Run it after prepending a new key; drop the old key once it
+reports completion. It is safe to run next to a live server.
+
+restore checks SNAPSHOT, a file written by a scheduled or admin-triggered
+backup, and replaces DATA_DIR/results.db with it. The replaced database is
+kept as results.db.pre-restore. Stop the server first.
+
+replica-check rebuilds the replica in DIR (default REPLICA_DIR) and compares
+it with DATA_DIR/results.db, listing results the replica lacks or holds
+differently. It is safe to run next to a live server.
+
+replica-restore rebuilds DATA_DIR/results.db from the replica in DIR, for
+example on a new host after the old one failed. The replaced database is
+kept as results.db.pre-restore. Stop the server first.
+
+export writes live results created in [--from, --to) to FILE or standard
+output, newest first. Times are RFC 3339 or YYYY-MM-DD (UTC); the format
+defaults to csv for a .csv FILE and ndjson otherwise. Exports hold client
+addresses as stored; protect them accordingly.
+
+import stores results from an export (FILE or - for standard input) under
+their original IDs and timestamps, applying this server's retention and IP
+policy. Results whose ID already exists are skipped and listed. With the
+ndjson backend, stop the server first.`
+
+// runResultsCommand maintains DATA_DIR/results.db with the server's
+// configuration.
+func runResultsCommand(args []string, stdout io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintln(stdout, resultsUsage)
+		if len(args) == 0 {
+			return exitFailure
+		}
+		return exitSuccess
+	}
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		slog.Error("Failed to load config", "error", err)
+		return exitFailure
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return exitFailure
+	}
+
+	var err error
+	switch args[0] {
+	case "reencrypt":
+		err = reencryptResults(context.Background(), cfg, args[1:], stdout)
+	case "restore":
+		err = restoreResults(context.Background(), cfg, args[1:], stdout)
+	case "replica-check":
+		err = checkReplica(context.Background(), cfg, args[1:], stdout)
+	case "replica-restore":
+		err = restoreReplica(context.Background(), cfg, args[1:], stdout)
+	case "export":
+		err = exportResults(context.Background(), cfg, args[1:], stdout)
+	case "import":
+		err = importResults(context.Background(), cfg, args[1:], stdout)
+	default:
+		err = fmt.Errorf("unknown results command %q", args[0])
+	}
+	if err != nil {
+		slog.Error("results command failed", "error", err)
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+func reencryptResults(ctx context.Context, cfg *config.Config, args []string, stdout io.Writer) error {
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected argument %q", args[0])
+	}
+	if len(cfg.FieldEncryptionKeys) == 0 {
+		return fmt.Errorf("FIELD_ENCRYPTION_KEYS or FIELD_ENCRYPTION_KEY_FILE is required")
+	}
+	store, err := openResultsStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	reencrypter, ok := store.(interface {
+		Reencrypt(context.Context) (int, error)
+	})
+	if !ok {
+		return fmt.Errorf("results backend %s cannot re-encrypt", cfg.ResultsBackend)
+	}
+	n, err := reencrypter.Reencrypt(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "re-encrypted %d results with key %s\n", n, cfg.FieldEncryptionKeys[0].ID)
+	return nil
+}
+
+func restoreResults(ctx context.Context, cfg *config.Config, args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("restore needs exactly one snapshot path")
+	}
+	dbPath, err := sqliteResultsPath(cfg)
+	if err != nil {
+		return err
+	}
+	n, err := results.RestoreSnapshot(ctx, args[0], dbPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "restored %d results from %s into %s\n", n, args[0], dbPath)
+	return nil
+}
+
+func checkReplica(ctx context.Context, cfg *config.Config, args []string, stdout io.Writer) error {
+	dir, err := replicaDirArg(cfg, args)
+	if err != nil {
+		return err
+	}
+	dbPath, err := sqliteResultsPath(cfg)
+	if err != nil {
+		return err
+	}
+	report, err := results.CheckReplica(ctx, dir, dbPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "replica holds %d results; %d missing, %d differing, %d awaiting cleanup\n",
+		report.Results, len(report.Missing), len(report.Mismatched), report.Pending)
+	for _, id := range report.Missing {
+		fmt.Fprintf(stdout, "missing %s\n", id)
+	}
+	for _, id := range report.Mismatched {
+		fmt.Fprintf(stdout, "differs %s\n", id)
+	}
+	if !report.Consistent() {
+		return fmt.Errorf("replica in %s is inconsistent", dir)
+	}
+	return nil
+}
+
+func restoreReplica(ctx context.Context, cfg *config.Config, args []string, stdout io.Writer) error {
+	dir, err := replicaDirArg(cfg, args)
+	if err != nil {
+		return err
+	}
+	dbPath, err := sqliteResultsPath(cfg)
+	if err != nil {
+		return err
+	}
+	n, err := results.RestoreReplica(ctx, dir, dbPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "restored %d results from replica %s into %s\n", n, dir, dbPath)
+	return nil
+}
+
+func replicaDirArg(cfg *config.Config, args []string) (string, error) {
+	switch {
+	case len(args) > 1:
+		return "", fmt.Errorf("unexpected argument %q", args[1])
+	case len(args) == 1:
+		return args[0], nil
+	case cfg.ReplicaDir != "":
+		return cfg.ReplicaDir, nil
+	default:
+		return "", fmt.Errorf("REPLICA_DIR or a replica directory argument is required")
+	}
+}
+
+// sqliteResultsPath is the database the snapshot and replica commands
+// maintain.
+func sqliteResultsPath(cfg *config.Config) (string, error) {
+	if cfg.ResultsBackend != config.ResultsBackendSQLite {
+		return "", fmt.Errorf("results backend %s has no database to restore", cfg.ResultsBackend)
+	}
+	return filepath.Join(cfg.DataDir, "results.db"), nil
+}
+
+func exportResults(ctx context.Context, cfg *config.Config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("openbyte results export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	formatName := fs.String("format", "", "ndjson or csv")
+	from := fs.String("from", "", "Earliest creation time (inclusive)")
+	to := fs.String("to", "", "Latest creation time (exclusive)")
+	output := fs.String("output", "", "File to write instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	format, err := transferFormat(*formatName, *output)
+	if err != nil {
+		return err
+	}
+	var q results.Query
+	if q.From, err = parseExportTime("--from", *from); err != nil {
+		return err
+	}
+	if q.To, err = parseExportTime("--to", *to); err != nil {
+		return err
+	}
+	store, err := openTransferStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *output == "" {
+		n, err := results.Export(ctx, store, stdout, format, q)
+		if err == nil {
+			slog.Info("results exported", "count", n, "format", format)
+		}
+		return err
+	}
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	n, err := results.Export(ctx, store, f, format, q)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "exported %d results to %s\n", n, *output)
+	return nil
+}
+
+func importResults(ctx context.Context, cfg *config.Config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("openbyte results import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	formatName := fs.String("format", "", "ndjson or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("import needs exactly one file, or - for standard input")
+	}
+	path := fs.Arg(0)
+	format, err := transferFormat(*formatName, path)
+	if err != nil {
+		return err
+	}
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	store, err := openTransferStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	report, err := results.Import(ctx, store, in, format)
+	fmt.Fprintf(stdout, "imported %d results; skipped %d existing IDs and %d expired results\n",
+		report.Imported, len(report.Collisions), report.Expired)
+	for _, id := range report.Collisions {
+		fmt.Fprintf(stdout, "exists %s\n", id)
+	}
+	return err
+}
+
+// openTransferStore opens the configured backend for export or import. The
+// memory backend holds nothing outside the server process.
+func openTransferStore(cfg *config.Config) (results.Storage, error) {
+	if cfg.ResultsBackend == config.ResultsBackendMemory {
+		return nil, fmt.Errorf("results backend %s keeps no results outside the server", cfg.ResultsBackend)
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+		return nil, err
+	}
+	return openResultsStore(cfg)
+}
+
+// transferFormat returns the named format, or the one a .csv path implies.
+func transferFormat(name, path string) (results.ExportFormat, error) {
+	if name != "" {
+		return results.ParseExportFormat(name)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return results.FormatCSV, nil
+	}
+	return results.FormatNDJSON, nil
+}
+
+func parseExportTime(flagName, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q: want RFC 3339 or YYYY-MM-DD", flagName, value)
+}
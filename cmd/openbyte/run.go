@@ -26,6 +26,12 @@ const (
 )
 
 func run(args []string, version string) int {
+	if len(args) > 0 && args[0] == "keys" {
+		return runKeysCommand(args[1:], os.Stdout)
+	}
+	if len(args) > 0 && args[0] == "results" {
+		return runResultsCommand(args[1:], os.Stdout)
+	}
 	versionFlag, err := parseServerArgs(args)
 	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
@@ -48,7 +54,10 @@ func run(args []string, version string) int {
 		return exitFailure
 	}
 
-	muxRouter, resultsStore, err := setupRuntimeResources(cfg)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	resources, err := setupRuntimeResources(cfg, hup)
 	if err != nil {
 		return exitFailure
 	}
@@ -56,7 +65,7 @@ func run(args []string, version string) int {
 
 	srv := &http.Server{
 		Addr:              cfg.BindAddress + ":" + cfg.Port,
-		Handler:           muxRouter,
+		Handler:           resources.handler,
 		ReadHeaderTimeout: serverReadHeaderTimeout,
 		IdleTimeout:       serverIdleTimeout,
 		HTTP2:             speedtestHTTP2Config(cfg),
@@ -70,7 +79,7 @@ func run(args []string, version string) int {
 	exitCode := waitForShutdown(quit, srvErrCh)
 	shutdownHTTPServer(srv, 30*time.Second)
 
-	resultsStore.Close()
+	resources.Close()
 	shutdownPprofServer(pprofServer, 5*time.Second)
 	slog.Info("Server stopped")
 	return exitCode
@@ -81,6 +90,8 @@ func parseServerArgs(args []string) (bool, error) {
 	fs.SetOutput(io.Discard)
 	fs.Usage = func() {
 		fmt.Fprintln(os.Stdout, "Usage: openbyte [--version]")
+		fmt.Fprintln(os.Stdout, "       openbyte keys <create|list|revoke> ...")
+		fmt.Fprintln(os.Stdout, "       openbyte results <reencrypt|restore|replica-check|replica-restore|export|import>")
 		fmt.Fprintln(os.Stdout, "\nServer configuration is environment-only; see README.md for variables.")
 	}
 	version := fs.Bool("version", false, "Print version")
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"errors"
 	"flag"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
 )
 
 func TestParseServerArgs(t *testing.T) {
@@ -98,3 +106,209 @@ func TestConfigureHTTPProtocolsCanDisableHTTP2(t *testing.T) {
 		t.Fatal("HTTP/2 should be disabled")
 	}
 }
+
+func TestKeysCommandCreateListRevoke(t *testing.T) {
+	t.Setenv("DATA_DIR", t.TempDir())
+
+	var out bytes.Buffer
+	if code := runKeysCommand([]string{"create", "--name", "probe", "--daily-quota", "50"}, &out); code != exitSuccess {
+		t.Fatalf("create exit code = %d, output %q", code, out.String())
+	}
+	var id string
+	for line := range strings.SplitSeq(out.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, "id:  "); ok {
+			id = value
+		}
+	}
+	if id == "" || !strings.Contains(out.String(), "key: obk_"+id+"_") {
+		t.Fatalf("create output = %q, want ID and key", out.String())
+	}
+
+	out.Reset()
+	if code := runKeysCommand([]string{"list"}, &out); code != exitSuccess {
+		t.Fatalf("list exit code = %d", code)
+	}
+	if !strings.Contains(out.String(), id) || !strings.Contains(out.String(), "active") {
+		t.Fatalf("list output = %q, want active key %s", out.String(), id)
+	}
+
+	out.Reset()
+	if code := runKeysCommand([]string{"revoke", id}, &out); code != exitSuccess {
+		t.Fatalf("revoke exit code = %d", code)
+	}
+	if code := runKeysCommand([]string{"revoke", id}, &out); code != exitFailure {
+		t.Fatal("revoking an already revoked key should fail")
+	}
+	if code := runKeysCommand([]string{"create"}, &out); code != exitFailure {
+		t.Fatal("create without --name should fail")
+	}
+}
+
+func TestResultsCommandReencrypt(t *testing.T) {
+	t.Setenv("DATA_DIR", t.TempDir())
+
+	var out bytes.Buffer
+	if code := runResultsCommand([]string{"reencrypt"}, &out); code != exitFailure {
+		t.Fatal("reencrypt without keys should fail")
+	}
+	t.Setenv("FIELD_ENCRYPTION_KEYS", "k1:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	if code := runResultsCommand([]string{"reencrypt"}, &out); code != exitSuccess {
+		t.Fatalf("reencrypt exit code = %d", code)
+	}
+	if !strings.Contains(out.String(), "re-encrypted 0 results with key k1") {
+		t.Fatalf("reencrypt output = %q", out.String())
+	}
+	if code := runResultsCommand([]string{"unknown"}, &out); code != exitFailure {
+		t.Fatal("unknown results command should fail")
+	}
+}
+
+func TestResultsCommandRestore(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("DATA_DIR", dataDir)
+	ctx := context.Background()
+	dbPath := filepath.Join(dataDir, "results.db")
+	store, err := results.New(dbPath, 10)
+	if err != nil {
+		t.Fatalf("open results: %v", err)
+	}
+	id, err := store.Save(ctx, results.Result{DownloadMbps: 5})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	snap, err := store.Backup(ctx, filepath.Join(dataDir, "backups"), 1)
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	store.Close()
+
+	var out bytes.Buffer
+	if code := runResultsCommand([]string{"restore"}, &out); code != exitFailure {
+		t.Fatal("restore without a snapshot should fail")
+	}
+	garbage := filepath.Join(dataDir, "garbage.db")
+	if err := os.WriteFile(garbage, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if code := runResultsCommand([]string{"restore", garbage}, &out); code != exitFailure {
+		t.Fatal("restore of a damaged snapshot should fail")
+	}
+	if _, err := os.Stat(dbPath + ".pre-restore"); !os.IsNotExist(err) {
+		t.Fatalf("damaged snapshot touched the live database: %v", err)
+	}
+	if code := runResultsCommand([]string{"restore", snap.Path}, &out); code != exitSuccess {
+		t.Fatalf("restore exit code = %d", code)
+	}
+	if !strings.Contains(out.String(), "restored 1 results") {
+		t.Fatalf("restore output = %q", out.String())
+	}
+	restored, err := results.New(dbPath, 10)
+	if err != nil {
+		t.Fatalf("reopen results: %v", err)
+	}
+	defer restored.Close()
+	if got, err := restored.Get(ctx, id); err != nil || got == nil {
+		t.Fatalf("restored result = %+v, %v", got, err)
+	}
+}
+
+func TestResultsCommandReplica(t *testing.T) {
+	dataDir := t.TempDir()
+	replicaDir := filepath.Join(t.TempDir(), "replica")
+	t.Setenv("DATA_DIR", dataDir)
+	ctx := context.Background()
+	dbPath := filepath.Join(dataDir, "results.db")
+	store, err := results.New(dbPath, 10)
+	if err != nil {
+		t.Fatalf("open results: %v", err)
+	}
+	if err := store.Replicate(ctx, replicaDir, time.Hour); err != nil {
+		t.Fatalf("replicate: %v", err)
+	}
+	id, err := store.Save(ctx, results.Result{DownloadMbps: 5})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	store.Close()
+
+	var out bytes.Buffer
+	if code := runResultsCommand([]string{"replica-check"}, &out); code != exitFailure {
+		t.Fatal("replica-check without REPLICA_DIR should fail")
+	}
+	t.Setenv("REPLICA_DIR", replicaDir)
+	if code := runResultsCommand([]string{"replica-check"}, &out); code != exitSuccess {
+		t.Fatalf("replica-check exit code = %d; output %q", code, out.String())
+	}
+	if !strings.Contains(out.String(), "replica holds 1 results; 0 missing, 0 differing") {
+		t.Fatalf("replica-check output = %q", out.String())
+	}
+
+	// The host is lost; only the replica survives.
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+	if code := runResultsCommand([]string{"replica-restore"}, &out); code != exitSuccess {
+		t.Fatalf("replica-restore exit code = %d", code)
+	}
+	restored, err := results.New(dbPath, 10)
+	if err != nil {
+		t.Fatalf("reopen results: %v", err)
+	}
+	defer restored.Close()
+	if got, err := restored.Get(ctx, id); err != nil || got == nil {
+		t.Fatalf("restored result = %+v, %v", got, err)
+	}
+}
+
+func TestResultsCommandExportImport(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	store, err := results.New(filepath.Join(srcDir, "results.db"), 10)
+	if err != nil {
+		t.Fatalf("open results: %v", err)
+	}
+	id, err := store.Save(ctx, results.Result{DownloadMbps: 42, ServerName: "Berlin"})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	store.Close()
+
+	exportPath := filepath.Join(t.TempDir(), "results.csv")
+	t.Setenv("DATA_DIR", srcDir)
+	var out bytes.Buffer
+	if code := runResultsCommand([]string{"export", "--from", "yesterday"}, &out); code != exitFailure {
+		t.Fatal("export with a bad --from should fail")
+	}
+	if code := runResultsCommand([]string{"export", "--from", "2000-01-01", "--output", exportPath}, &out); code != exitSuccess {
+		t.Fatalf("export exit code = %d", code)
+	}
+	if !strings.Contains(out.String(), "exported 1 results") {
+		t.Fatalf("export output = %q", out.String())
+	}
+	data, err := os.ReadFile(exportPath)
+	if err != nil || !strings.HasPrefix(string(data), "id,created_at") || !strings.Contains(string(data), id) {
+		t.Fatalf("export file = %q, %v", data, err)
+	}
+
+	dstDir := t.TempDir()
+	t.Setenv("DATA_DIR", dstDir)
+	t.Setenv("RESULTS_BACKEND", "ndjson")
+	out.Reset()
+	if code := runResultsCommand([]string{"import", exportPath}, &out); code != exitSuccess {
+		t.Fatalf("import exit code = %d", code)
+	}
+	if code := runResultsCommand([]string{"import", exportPath}, &out); code != exitSuccess {
+		t.Fatalf("re-import exit code = %d", code)
+	}
+	if got := out.String(); !strings.Contains(got, "imported 1 results; skipped 0 existing IDs") ||
+		!strings.Contains(got, "imported 0 results; skipped 1 existing IDs") || !strings.Contains(got, "exists "+id) {
+		t.Fatalf("import output = %q", got)
+	}
+
+	t.Setenv("RESULTS_BACKEND", "memory")
+	if code := runResultsCommand([]string{"import", exportPath}, &out); code != exitFailure {
+		t.Fatal("import into the memory backend should fail")
+	}
+}
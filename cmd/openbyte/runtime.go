@@ -5,32 +5,154 @@ import (
 	"crypto/tls"
 	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/saveenergy/openbyte/internal/accesslist"
 	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/apikeys"
 	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/conninfo"
+	"github.com/saveenergy/openbyte/internal/geoip"
+	"github.com/saveenergy/openbyte/internal/proxyproto"
 	"github.com/saveenergy/openbyte/internal/results"
 	"github.com/saveenergy/openbyte/internal/tlsutil"
 )
 
-func setupRuntimeResources(cfg *config.Config) (http.Handler, *results.Store, error) {
+// runtimeResources are the long-lived dependencies behind the HTTP handler.
+type runtimeResources struct {
+	handler    http.Handler
+	results    results.Storage
+	apiKeys    *apikeys.Store
+	accessList *accesslist.List
+	geoIP      *geoip.DB
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// openResultsStore opens the configured results backend in DATA_DIR with
+// the configured retention, IP policy, and field encryption.
+func openResultsStore(cfg *config.Config) (results.Storage, error) {
+	opts := []results.Option{
+		results.WithRetention(cfg.ResultRetention()),
+		results.WithIPPolicy(results.IPPolicy{
+			Mode:           results.IPMode(cfg.IPStorageMode),
+			HashKey:        cfg.IPHashKey,
+			AnonymizeAfter: cfg.IPAnonymizeAfter(),
+		}),
+	}
+	if len(cfg.FieldEncryptionKeys) > 0 {
+		keys := make([]results.FieldKey, 0, len(cfg.FieldEncryptionKeys))
+		for _, key := range cfg.FieldEncryptionKeys {
+			keys = append(keys, results.FieldKey{ID: key.ID, Key: key.Key})
+		}
+		fields, err := results.NewFieldEncryption(keys)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, results.WithFieldEncryption(fields))
+	}
+	switch cfg.ResultsBackend {
+	case config.ResultsBackendMemory:
+		return results.NewMemory(cfg.MaxStoredResults, opts...), nil
+	case config.ResultsBackendNDJSON:
+		return results.OpenNDJSON(filepath.Join(cfg.DataDir, "results.ndjson"), cfg.MaxStoredResults, opts...)
+	default:
+		return results.New(filepath.Join(cfg.DataDir, "results.db"), cfg.MaxStoredResults, opts...)
+	}
+}
+
+func setupRuntimeResources(cfg *config.Config, hup <-chan os.Signal) (*runtimeResources, error) {
 	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
 		slog.Error("Failed to create data directory", "error", err)
-		return nil, nil, err
+		return nil, err
+	}
+	accessList, err := accesslist.Load(filepath.Join(cfg.DataDir, accesslist.FileName))
+	if err != nil {
+		slog.Error("Failed to load access list", "error", err)
+		return nil, err
 	}
-	resultsStore, err := results.New(cfg.DataDir+"/results.db", cfg.MaxStoredResults)
+	geoIP, err := geoip.Open(cfg.DataDir)
 	if err != nil {
+		slog.Error("Failed to load GeoIP databases", "error", err)
+		return nil, err
+	}
+	keyStore, err := apikeys.Open(filepath.Join(cfg.DataDir, apikeys.FileName))
+	if err != nil {
+		slog.Error("Failed to open API key store", "error", err)
+		return nil, err
+	}
+	resultsStore, err := openResultsStore(cfg)
+	if err != nil {
+		keyStore.Close()
 		slog.Error("Failed to open results store", "error", err)
-		return nil, nil, err
+		return nil, err
+	}
+	if store, ok := resultsStore.(*results.Store); ok && cfg.ReplicaDir != "" {
+		if err := store.Replicate(context.Background(), cfg.ReplicaDir, cfg.ReplicaSnapshotInterval); err != nil {
+			resultsStore.Close()
+			keyStore.Close()
+			slog.Error("Failed to start results replication", "dir", cfg.ReplicaDir, "error", err)
+			return nil, err
+		}
+		slog.Info("Results replication enabled",
+			"dir", cfg.ReplicaDir,
+			"snapshot_interval", cfg.ReplicaSnapshotInterval)
 	}
 	slog.Info("Results store opened",
-		"path", cfg.DataDir+"/results.db",
-		"max_results", cfg.MaxStoredResults)
+		"backend", cfg.ResultsBackend,
+		"data_dir", cfg.DataDir,
+		"max_results", cfg.MaxStoredResults,
+		"retention_days", cfg.ResultRetentionDays,
+		"ip_storage", cfg.IPStorageMode,
+		"field_encryption", len(cfg.FieldEncryptionKeys) > 0)
 
 	router := api.NewRouter(cfg, resultsStore)
-	return router.SetupRoutes(), resultsStore, nil
+	router.SetAccessList(accessList)
+	router.SetAPIKeys(keyStore)
+	router.SetGeoIP(geoIP)
+	res := &runtimeResources{
+		handler:    router.SetupRoutes(),
+		results:    resultsStore,
+		apiKeys:    keyStore,
+		accessList: accessList,
+		geoIP:      geoIP,
+		stop:       make(chan struct{}),
+	}
+	res.wg.Add(1)
+	go func() {
+		defer res.wg.Done()
+		watchAccessList(accessList, accessListPollInterval, res.stop, hup)
+	}()
+	res.wg.Add(1)
+	go func() {
+		defer res.wg.Done()
+		watchGeoIP(geoIP, geoIPPollInterval, res.stop)
+	}()
+	if store, ok := resultsStore.(*results.Store); ok && cfg.BackupInterval > 0 {
+		slog.Info("Scheduled results backups enabled",
+			"dir", cfg.ResultBackupDir(),
+			"interval", cfg.BackupInterval,
+			"keep", cfg.BackupKeep)
+		res.wg.Add(1)
+		go func() {
+			defer res.wg.Done()
+			runScheduledBackups(store, cfg.ResultBackupDir(), cfg.BackupKeep, cfg.BackupInterval, res.stop)
+		}()
+	}
+	return res, nil
+}
+
+// Close stops background watchers and backups and closes the results store.
+func (res *runtimeResources) Close() {
+	close(res.stop)
+	res.wg.Wait()
+	res.results.Close()
+	res.apiKeys.Close()
 }
 
 func startHTTPServer(cfg *config.Config, srv *http.Server, srvErrCh chan<- error) {
@@ -44,17 +166,65 @@ func startHTTPServer(cfg *config.Config, srv *http.Server, srvErrCh chan<- error
 
 func serveHTTPOrTLS(cfg *config.Config, srv *http.Server) error {
 	slog.Info("Server starting", "address", cfg.BindAddress+":"+cfg.Port)
-	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
-		return srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
-	}
-	if cfg.TLSAutoGen {
+	useTLS := true
+	switch {
+	case cfg.TLSCertFile != "" && cfg.TLSKeyFile != "":
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		ensureTLSConfig(srv).Certificates = []tls.Certificate{cert}
+	case cfg.TLSAutoGen:
 		if err := configureAutogeneratedTLS(srv); err != nil {
 			return err
 		}
 		slog.Info("TLS auto-generation enabled; serving HTTPS with an ephemeral self-signed certificate")
-		return srv.ListenAndServeTLS("", "")
+	default:
+		useTLS = false
+	}
+	ln, err := listen(cfg, srv.Addr)
+	if err != nil {
+		return err
+	}
+	if !useTLS {
+		srv.ConnContext = conninfo.ConnContext
+		return srv.Serve(ln)
+	}
+	// Handshaking in the listener lets /api/v1/connection report how long
+	// each client's TLS handshake took.
+	tlsListener := conninfo.NewTLSListener(ln, tlsServerConfig(srv).Clone(), serverReadHeaderTimeout)
+	srv.ConnContext = tlsListener.ConnContext
+	return srv.Serve(tlsListener)
+}
+
+// listen opens the server socket. With PROXY protocol enabled, connections
+// from trusted proxies report the client address their header names.
+func listen(cfg *config.Config, addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil || !cfg.ProxyProtocol {
+		return ln, err
+	}
+	trusted, err := cfg.TrustedProxyNetworks()
+	if err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	slog.Info("PROXY protocol enabled", "trusted_proxy_cidrs", cfg.TrustedProxyCIDRs)
+	return proxyproto.NewListener(ln, trusted, serverReadHeaderTimeout), nil
+}
+
+// tlsServerConfig sets the ALPN protocols ServeTLS would advertise on
+// srv.TLSConfig, which also tells Serve to enable HTTP/2.
+func tlsServerConfig(srv *http.Server) *tls.Config {
+	config := ensureTLSConfig(srv)
+	if len(config.NextProtos) == 0 {
+		if srv.Protocols == nil || srv.Protocols.HTTP2() {
+			config.NextProtos = []string{"h2", "http/1.1"}
+		} else {
+			config.NextProtos = []string{"http/1.1"}
+		}
 	}
-	return srv.ListenAndServe()
+	return config
 }
 
 func configureAutogeneratedTLS(srv *http.Server) error {
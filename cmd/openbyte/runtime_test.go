@@ -2,7 +2,11 @@ package main
 
 import (
 	"net/http"
+	"slices"
 	"testing"
+
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/proxyproto"
 )
 
 func TestConfigureAutogeneratedTLSInstallsCertificate(t *testing.T) {
@@ -20,3 +24,41 @@ func TestConfigureAutogeneratedTLSInstallsCertificate(t *testing.T) {
 		t.Fatal("generated certificate chain is empty")
 	}
 }
+
+func TestTLSServerConfigAdvertisesEnabledProtocols(t *testing.T) {
+	srv := &http.Server{}
+	if got := tlsServerConfig(srv).NextProtos; !slices.Equal(got, []string{"h2", "http/1.1"}) {
+		t.Fatalf("default NextProtos = %v, want h2 and http/1.1", got)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.HTTP2Enabled = false
+	srv = &http.Server{}
+	configureHTTPProtocols(cfg, srv)
+	if got := tlsServerConfig(srv).NextProtos; !slices.Equal(got, []string{"http/1.1"}) {
+		t.Fatalf("HTTP/1-only NextProtos = %v, want http/1.1", got)
+	}
+}
+
+func TestListenWrapsProxyProtocolWhenEnabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	ln, err := listen(cfg, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if _, ok := ln.(*proxyproto.Listener); ok {
+		t.Fatal("PROXY protocol listener installed without PROXY_PROTOCOL")
+	}
+	_ = ln.Close()
+
+	cfg.ProxyProtocol = true
+	cfg.TrustedProxyCIDRs = []string{"127.0.0.0/8"}
+	ln, err = listen(cfg, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen with PROXY protocol: %v", err)
+	}
+	defer ln.Close()
+	if _, ok := ln.(*proxyproto.Listener); !ok {
+		t.Fatalf("listener = %T, want *proxyproto.Listener", ln)
+	}
+}
@@ -0,0 +1,212 @@
+// Package accesslist loads operator-maintained CIDR allow and deny lists.
+//
+// The file holds one rule per line, either "allow <cidr-or-ip>" or
+// "deny <cidr-or-ip>". Blank lines and text after '#' are ignored. A missing
+// file is an empty list so deployments opt in by creating it.
+package accesslist
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the access list file looked up inside DATA_DIR.
+const FileName = "access.list"
+
+// Maximum accepted file size; larger files are rejected on load.
+const maxFileBytes = 1 << 20
+
+// Decision classifies a client address against the loaded rules.
+type Decision int
+
+const (
+	// Neutral addresses match no rule and follow the normal limits.
+	Neutral Decision = iota
+	// Allowed addresses bypass rate and concurrency limits.
+	Allowed
+	// Denied addresses are rejected on every route.
+	Denied
+)
+
+type rules struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// List is a hot-reloadable set of allow and deny networks. Deny rules take
+// precedence so an allowlisted range can still exclude a narrower block.
+type List struct {
+	path string
+
+	mu      sync.RWMutex
+	rules   rules
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// Load reads path and returns a list that can be reloaded later. A missing
+// file yields an empty list; a malformed file is an error.
+func Load(path string) (*List, error) {
+	l := &List{path: path}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path returns the file backing the list.
+func (l *List) Path() string {
+	return l.path
+}
+
+// Reload re-reads the file unconditionally. On error the previous rules stay
+// active so a typo cannot silently drop a deny rule; the failed file version
+// is remembered so polling does not retry it until it changes again.
+func (l *List) Reload() error {
+	info, err := os.Stat(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		l.replace(rules{}, time.Time{}, 0, false)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat access list: %w", err)
+	}
+	if info.Size() > maxFileBytes {
+		l.remember(info)
+		return fmt.Errorf("access list %s exceeds %d bytes", l.path, maxFileBytes)
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return fmt.Errorf("read access list: %w", err)
+	}
+	parsed, err := parse(data)
+	if err != nil {
+		l.remember(info)
+		return fmt.Errorf("parse access list %s: %w", l.path, err)
+	}
+	l.replace(parsed, info.ModTime(), info.Size(), true)
+	slog.Info("access list loaded", "path", l.path, "allow", len(parsed.allow), "deny", len(parsed.deny))
+	return nil
+}
+
+// ReloadIfChanged reloads when the file appeared, disappeared, or its size or
+// modification time changed since the last load attempt.
+func (l *List) ReloadIfChanged() error {
+	info, err := os.Stat(l.path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("stat access list: %w", err)
+	}
+	l.mu.RLock()
+	unchanged := exists == l.exists &&
+		(!exists || (info.ModTime().Equal(l.modTime) && info.Size() == l.size))
+	l.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	return l.Reload()
+}
+
+func (l *List) remember(info fs.FileInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.modTime = info.ModTime()
+	l.size = info.Size()
+	l.exists = true
+}
+
+func (l *List) replace(r rules, modTime time.Time, size int64, exists bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rules = r
+	l.modTime = modTime
+	l.size = size
+	l.exists = exists
+}
+
+// Check classifies ip. A nil list or nil address is always Neutral.
+func (l *List) Check(ip net.IP) Decision {
+	if l == nil || ip == nil {
+		return Neutral
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if containsIP(l.rules.deny, ip) {
+		return Denied
+	}
+	if containsIP(l.rules.allow, ip) {
+		return Allowed
+	}
+	return Neutral
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parse(data []byte) (rules, error) {
+	var parsed rules
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return rules{}, fmt.Errorf("line %d: want \"allow|deny <cidr>\"", lineNo)
+		}
+		network, err := parseNetwork(fields[1])
+		if err != nil {
+			return rules{}, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		switch strings.ToLower(fields[0]) {
+		case "allow":
+			parsed.allow = append(parsed.allow, network)
+		case "deny":
+			parsed.deny = append(parsed.deny, network)
+		default:
+			return rules{}, fmt.Errorf("line %d: unknown action %q", lineNo, fields[0])
+		}
+	}
+	return parsed, scanner.Err()
+}
+
+// parseNetwork accepts CIDR notation or a bare address (treated as /32 or /128).
+func parseNetwork(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q", value)
+	}
+	bits := 128
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+		bits = 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/saveenergy/openbyte/internal/config"
@@ -12,55 +13,108 @@ import (
 type ClientIPResolver struct {
 	trustProxyHeaders bool
 	trustedProxyNets  []*net.IPNet
+	// headers are the proxy headers consulted, in precedence order.
+	headers []string
 }
 
 func NewClientIPResolver(cfg *config.Config) *ClientIPResolver {
 	if cfg == nil {
 		return &ClientIPResolver{}
 	}
-	trustedNetworks, invalidCIDRs := parseTrustedProxyCIDRs(cfg.TrustedProxyCIDRs)
+	trustedNetworks, err := cfg.TrustedProxyNetworks()
 	trustProxyHeaders := cfg.TrustProxyHeaders
-	if trustProxyHeaders && len(invalidCIDRs) > 0 {
+	if err != nil {
 		// Fail closed on proxy trust when CIDR config is invalid.
-		slog.Error("disabling trusted proxy headers due to invalid trusted CIDRs",
-			"invalid_cidrs", strings.Join(invalidCIDRs, ","))
+		slog.Error("disabling trusted proxy headers due to invalid trusted CIDRs", "error", err)
 		trustProxyHeaders = false
 	}
+	headers := cfg.ClientIPHeaders
+	if len(headers) == 0 {
+		headers = config.DefaultClientIPHeaders()
+	}
 	return &ClientIPResolver{
 		trustProxyHeaders: trustProxyHeaders,
 		trustedProxyNets:  trustedNetworks,
+		headers:           headers,
 	}
 }
 
 func (r *ClientIPResolver) FromRequest(req *http.Request) string {
+	ip, _, _ := r.resolve(req)
+	return ipString(ip)
+}
+
+// resolve returns the client address, the text it was parsed from, and
+// whether a trusted proxy's header supplied it.
+func (r *ClientIPResolver) resolve(req *http.Request) (net.IP, string, bool) {
 	remoteIP := parseRemoteIP(req.RemoteAddr)
 	if !r.trustProxyHeaders || !r.isTrustedProxy(remoteIP) {
-		return ipString(remoteIP)
+		return remoteIP, req.RemoteAddr, false
 	}
 
-	xff := req.Header.Get("X-Forwarded-For")
-	if clientIP := r.rightmostUntrustedIP(xff); clientIP != nil {
-		return ipString(clientIP)
+	for _, header := range r.headers {
+		clientIP, raw, present := r.fromHeader(req, header)
+		if !present {
+			continue
+		}
+		// The first header present decides. A proxy may pass later ones
+		// through unstripped, so falling back to them would let clients
+		// spoof (trusted XFF + fake X-Real-IP). Without a usable entry, use
+		// the direct connection.
+		if clientIP == nil {
+			return remoteIP, req.RemoteAddr, false
+		}
+		return clientIP, raw, true
 	}
-	// When XFF contains only trusted hops, do not fall back to X-Real-IP:
-	// a proxy may not strip it, allowing an attacker to spoof (trusted XFF + fake X-Real-IP).
-	// Fall back to the direct connection (remoteAddr).
-	if xff != "" {
-		return ipString(remoteIP)
+	return remoteIP, req.RemoteAddr, false
+}
+
+// fromHeader returns the client address one proxy header names and whether
+// the header is present at all.
+func (r *ClientIPResolver) fromHeader(req *http.Request, header string) (net.IP, string, bool) {
+	switch header {
+	case config.ClientIPHeaderXForwardedFor:
+		xff := req.Header.Get("X-Forwarded-For")
+		ip, raw := r.rightmostUntrustedIP(xff)
+		return ip, raw, xff != ""
+	case config.ClientIPHeaderForwarded:
+		forwarded := strings.Join(req.Header.Values("Forwarded"), ",")
+		ip, raw := r.rightmostUntrustedForwarded(forwarded)
+		return ip, raw, strings.TrimSpace(forwarded) != ""
+	case config.ClientIPHeaderXRealIP:
+		realIP := req.Header.Get("X-Real-IP")
+		return parseHeaderIP(realIP), realIP, realIP != ""
+	}
+	return nil, "", false
+}
+
+// ForwardedHTTPS reports whether a trusted proxy says the client connected
+// over HTTPS via X-Forwarded-Proto, or via the proto parameter of Forwarded
+// when that header is enabled. With several proxies only the value the
+// nearest one appended counts.
+func (r *ClientIPResolver) ForwardedHTTPS(req *http.Request) bool {
+	if !r.trustProxyHeaders || !r.isTrustedProxy(parseRemoteIP(req.RemoteAddr)) {
+		return false
 	}
-	if clientIP := parseHeaderIP(req.Header.Get("X-Real-IP")); clientIP != nil {
-		return ipString(clientIP)
+	proto := req.Header.Get("X-Forwarded-Proto")
+	if i := strings.LastIndexByte(proto, ','); i >= 0 {
+		proto = proto[i+1:]
 	}
-
-	return ipString(remoteIP)
+	if proto == "" && slices.Contains(r.headers, config.ClientIPHeaderForwarded) {
+		if elements, ok := parseForwarded(strings.Join(req.Header.Values("Forwarded"), ",")); ok && len(elements) > 0 {
+			proto = elements[len(elements)-1].proto
+		}
+	}
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
 }
 
 // rightmostUntrustedIP walks X-Forwarded-For entries from right to left,
 // skipping trusted proxy IPs. The first non-trusted entry is the real client.
-// This prevents spoofing via attacker-prepended XFF values.
-func (r *ClientIPResolver) rightmostUntrustedIP(xff string) net.IP {
+// This prevents spoofing via attacker-prepended XFF values. The entry's
+// text is returned with the address.
+func (r *ClientIPResolver) rightmostUntrustedIP(xff string) (net.IP, string) {
 	if xff == "" {
-		return nil
+		return nil, ""
 	}
 	// Parse from the right without strings.Split (avoids a slice alloc per request).
 	remainder := xff
@@ -76,11 +130,11 @@ func (r *ClientIPResolver) rightmostUntrustedIP(xff string) net.IP {
 		}
 		if ip := parseHeaderIP(part); ip != nil {
 			if !r.isTrustedProxy(ip) {
-				return ip
+				return ip, part
 			}
 		}
 	}
-	return nil
+	return nil, ""
 }
 
 func (r *ClientIPResolver) isTrustedProxy(ip net.IP) bool {
@@ -95,27 +149,6 @@ func (r *ClientIPResolver) isTrustedProxy(ip net.IP) bool {
 	return false
 }
 
-func parseTrustedProxyCIDRs(cidrs []string) ([]*net.IPNet, []string) {
-	networks := make([]*net.IPNet, 0, len(cidrs))
-	invalid := make([]string, 0)
-	for _, entry := range cidrs {
-		trimmed := strings.TrimSpace(entry)
-		if trimmed == "" {
-			continue
-		}
-		_, network, err := net.ParseCIDR(trimmed)
-		if err != nil {
-			slog.Warn("invalid trusted proxy CIDR", "cidr", trimmed, "error", err)
-			invalid = append(invalid, trimmed)
-			continue
-		}
-		if network != nil {
-			networks = append(networks, network)
-		}
-	}
-	return networks, invalid
-}
-
 func parseRemoteIP(remoteAddr string) net.IP {
 	if remoteAddr == "" {
 		return nil
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Address scopes reported in the ping bootstrap.
+const (
+	addressScopePublic    = "public"
+	addressScopePrivate   = "private"
+	addressScopeCGNAT     = "cgnat"
+	addressScopeLoopback  = "loopback"
+	addressScopeLinkLocal = "link_local"
+	addressScopeOther     = "other"
+)
+
+// Transition mechanisms that carry an IPv4 address inside an IPv6 one.
+const (
+	addressTransitionIPv4Mapped = "ipv4_mapped"
+	addressTransitionNAT64      = "nat64"
+	addressTransitionTeredo     = "teredo"
+	addressTransition6to4       = "6to4"
+)
+
+var (
+	cgnatPrefix  = netip.MustParsePrefix("100.64.0.0/10")
+	nat64Prefix  = netip.MustParsePrefix("64:ff9b::/96")
+	teredoPrefix = netip.MustParsePrefix("2001::/32")
+	sixToFour    = netip.MustParsePrefix("2002::/16")
+)
+
+// clientAddress classifies the resolved client address so the UI can
+// explain results measured through carrier-grade NAT, a private network, or
+// an IPv6 transition mechanism.
+type clientAddress struct {
+	Version int `json:"version"`
+	// Scope describes the embedded IPv4 address for IPv4-mapped and NAT64
+	// addresses, since that is the client's real address.
+	Scope      string `json:"scope"`
+	Transition string `json:"transition,omitempty"`
+	// EmbeddedIPv4 is the IPv4 address carried by a transition address; for
+	// Teredo and 6to4 it is the client's public IPv4 address.
+	EmbeddedIPv4 string `json:"embedded_ipv4,omitempty"`
+	// ViaProxy reports that a trusted proxy's forwarding header supplied the
+	// address rather than the connection itself.
+	ViaProxy bool `json:"via_proxy"`
+}
+
+// classify describes the client address of req, or returns nil when it
+// cannot be parsed.
+func (r *ClientIPResolver) classify(req *http.Request) *clientAddress {
+	ip, raw, viaProxy := r.resolve(req)
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return nil
+	}
+	// net.IP cannot tell 192.0.2.1 from ::ffff:192.0.2.1; the text can.
+	if addr.Is4In6() && !rawIs4In6(raw) {
+		addr = addr.Unmap()
+	}
+	class := &clientAddress{ViaProxy: viaProxy}
+	if addr.Is4() {
+		class.Version = 4
+		class.Scope = addressScope(addr)
+		return class
+	}
+
+	class.Version = 6
+	embedded, transition := embeddedIPv4(addr)
+	class.Transition = transition
+	if embedded.IsValid() {
+		class.EmbeddedIPv4 = embedded.String()
+	}
+	switch transition {
+	case addressTransitionIPv4Mapped, addressTransitionNAT64:
+		class.Scope = addressScope(embedded)
+	default:
+		class.Scope = addressScope(addr)
+	}
+	return class
+}
+
+// embeddedIPv4 extracts the IPv4 address carried by an IPv6 transition
+// address.
+func embeddedIPv4(addr netip.Addr) (netip.Addr, string) {
+	b := addr.As16()
+	switch {
+	case addr.Is4In6():
+		return addr.Unmap(), addressTransitionIPv4Mapped
+	case nat64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16])), addressTransitionNAT64
+	case teredoPrefix.Contains(addr):
+		// RFC 4380 stores the client's external address with every bit
+		// inverted.
+		return netip.AddrFrom4([4]byte{^b[12], ^b[13], ^b[14], ^b[15]}), addressTransitionTeredo
+	case sixToFour.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6])), addressTransition6to4
+	}
+	return netip.Addr{}, ""
+}
+
+func addressScope(addr netip.Addr) string {
+	switch {
+	case addr.IsLoopback():
+		return addressScopeLoopback
+	case cgnatPrefix.Contains(addr):
+		return addressScopeCGNAT
+	case addr.IsPrivate():
+		return addressScopePrivate
+	case addr.IsLinkLocalUnicast():
+		return addressScopeLinkLocal
+	case addr.IsGlobalUnicast():
+		return addressScopePublic
+	}
+	return addressScopeOther
+}
+
+// rawIs4In6 reports whether an address as written in RemoteAddr or a
+// forwarding header is an IPv4-mapped IPv6 address.
+func rawIs4In6(raw string) bool {
+	clean := strings.TrimSpace(raw)
+	if host, _, err := net.SplitHostPort(clean); err == nil {
+		clean = host
+	}
+	clean = strings.TrimSuffix(strings.TrimPrefix(clean, "["), "]")
+	addr, err := netip.ParseAddr(clean)
+	return err == nil && addr.Is4In6()
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net"
+	"strings"
+)
+
+// forwardedElement is one proxy hop of an RFC 7239 Forwarded header.
+type forwardedElement struct {
+	// node is the for= value with quotes removed, such as 192.0.2.60,
+	// [2001:db8::17]:4711, unknown, or an obfuscated _identifier. It is
+	// empty when the hop did not disclose the client.
+	node  string
+	proto string
+}
+
+// rightmostUntrustedForwarded walks Forwarded elements from right to left,
+// skipping trusted proxy addresses. A hop that hides its client (unknown,
+// an obfuscated identifier, or no for=) ends the walk without a result:
+// everything to its left was written by a party no trusted proxy vouches
+// for. A malformed header yields no result either.
+func (r *ClientIPResolver) rightmostUntrustedForwarded(value string) (net.IP, string) {
+	elements, ok := parseForwarded(value)
+	if !ok {
+		return nil, ""
+	}
+	for i := len(elements) - 1; i >= 0; i-- {
+		node := elements[i].node
+		ip := parseHeaderIP(node)
+		if ip == nil {
+			return nil, ""
+		}
+		if !r.isTrustedProxy(ip) {
+			return ip, node
+		}
+	}
+	return nil, ""
+}
+
+// parseForwarded splits a Forwarded header into its elements. It reports
+// false for unterminated quotes, pairs without "=", or a parameter repeated
+// within one element.
+func parseForwarded(value string) ([]forwardedElement, bool) {
+	parts, ok := splitOutsideQuotes(value, ',')
+	if !ok {
+		return nil, false
+	}
+	elements := make([]forwardedElement, 0, len(parts))
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		pairs, _ := splitOutsideQuotes(part, ';')
+		var element forwardedElement
+		var seenFor, seenProto bool
+		for _, pair := range pairs {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			name, raw, found := strings.Cut(pair, "=")
+			if !found {
+				return nil, false
+			}
+			value, ok := forwardedValue(raw)
+			if !ok {
+				return nil, false
+			}
+			switch {
+			case strings.EqualFold(name, "for"):
+				if seenFor {
+					return nil, false
+				}
+				seenFor, element.node = true, value
+			case strings.EqualFold(name, "proto"):
+				if seenProto {
+					return nil, false
+				}
+				seenProto, element.proto = true, value
+			}
+		}
+		elements = append(elements, element)
+	}
+	return elements, true
+}
+
+// forwardedValue decodes a token or quoted-string.
+func forwardedValue(raw string) (string, bool) {
+	if !strings.HasPrefix(raw, `"`) {
+		return raw, !strings.ContainsAny(raw, `"\ `)
+	}
+	if len(raw) < 2 || !strings.HasSuffix(raw, `"`) {
+		return "", false
+	}
+	var b strings.Builder
+	inner := raw[1 : len(raw)-1]
+	for i := 0; i < len(inner); i++ {
+		switch c := inner[i]; c {
+		case '\\':
+			i++
+			if i == len(inner) {
+				return "", false
+			}
+			b.WriteByte(inner[i])
+		case '"':
+			return "", false
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), true
+}
+
+// splitOutsideQuotes splits s at sep where it is not inside a
+// quoted-string. It reports false for an unterminated quote.
+func splitOutsideQuotes(s string, sep byte) ([]string, bool) {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, false
+	}
+	return append(parts, s[start:]), true
+}
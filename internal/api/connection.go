@@ -0,0 +1,83 @@
+package api
+
+import (
+	"crypto/tls"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/conninfo"
+)
+
+// connectionResponse describes the connection a request arrived on. Behind
+// a reverse proxy these are facts about the proxy's connection to the
+// server, which ViaProxy flags.
+type connectionResponse struct {
+	Protocol string `json:"protocol"`
+	// ClientPort is omitted when a trusted proxy supplied the client
+	// address, since the connection's port is then the proxy's.
+	ClientPort int            `json:"client_port,omitempty"`
+	ViaProxy   bool           `json:"via_proxy"`
+	TLS        *connectionTLS `json:"tls,omitempty"`
+	Socket     *socketStats   `json:"socket,omitempty"`
+}
+
+type connectionTLS struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	ALPN        string `json:"alpn,omitempty"`
+	ServerName  string `json:"server_name,omitempty"`
+	Resumed     bool   `json:"resumed"`
+	// HandshakeMs is measured from accepting the connection until the
+	// handshake completed; it is omitted when the server did not time it.
+	HandshakeMs float64 `json:"handshake_ms,omitempty"`
+}
+
+type socketStats struct {
+	MSS      uint32  `json:"mss"`
+	RTTMs    float64 `json:"rtt_ms"`
+	RTTVarMs float64 `json:"rttvar_ms"`
+}
+
+func (r *Router) connection(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(headerCacheControl, valueNoStore)
+	resp := connectionResponse{Protocol: req.Proto}
+	if r.clientIPResolver != nil {
+		_, _, resp.ViaProxy = r.clientIPResolver.resolve(req)
+	}
+	if !resp.ViaProxy {
+		if _, port, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+			resp.ClientPort, _ = strconv.Atoi(port)
+		}
+	}
+	info := conninfo.FromContext(req.Context())
+	if req.TLS != nil {
+		resp.TLS = &connectionTLS{
+			Version:     tls.VersionName(req.TLS.Version),
+			CipherSuite: tls.CipherSuiteName(req.TLS.CipherSuite),
+			ALPN:        req.TLS.NegotiatedProtocol,
+			ServerName:  req.TLS.ServerName,
+			Resumed:     req.TLS.DidResume,
+		}
+		if info != nil {
+			resp.TLS.HandshakeMs = durationMs(info.Handshake)
+		}
+	}
+	if info != nil {
+		if stats, ok := conninfo.SocketStats(info.Conn); ok {
+			resp.Socket = &socketStats{
+				MSS:      stats.MSS,
+				RTTMs:    durationMs(stats.RTT),
+				RTTVarMs: durationMs(stats.RTTVar),
+			}
+		}
+	}
+	respondJSON(w, resp, http.StatusOK)
+}
+
+// durationMs converts d to milliseconds with microsecond precision.
+func durationMs(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
@@ -9,6 +9,8 @@ const (
 	headerCacheControl = "Cache-Control"
 	valueNoStore       = "no-store"
 	headerRetryAfter   = "Retry-After"
-	retryAfterSec      = "60"
 	resultsHTML        = "results.html"
+
+	headerRateLimitPolicy = "RateLimit-Policy"
+	headerRateLimit       = "RateLimit"
 )
@@ -0,0 +1,8 @@
+package api
+
+import "expvar"
+
+// speedtestMetrics is published under /debug/vars on the pprof listener.
+var speedtestMetrics = expvar.NewMap("openbyte_speedtest")
+
+const metricUploadThroughputAborts = "upload_throughput_floor_aborts"
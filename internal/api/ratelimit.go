@@ -8,7 +8,19 @@ import (
 	"github.com/saveenergy/openbyte/internal/config"
 )
 
+const (
+	rateLimitScopeIP     = "ip"
+	rateLimitScopeGlobal = "global"
+	rateLimitWindow      = time.Minute
+)
+
 type RateLimiter struct {
+	policy string
+	*rateBuckets
+}
+
+// rateBuckets holds the token buckets behind one or more policies.
+type rateBuckets struct {
 	rateLimitPerIP   int
 	globalRateLimit  int
 	ipLimits         map[string]*IPLimit
@@ -27,18 +39,57 @@ type IPLimit struct {
 	lastRefill time.Time
 }
 
+// RateLimitDecision is the outcome of one token request. Remaining and Reset
+// describe the client's per-IP bucket for the RateLimit response header.
+type RateLimitDecision struct {
+	Allowed    bool
+	Scope      string
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
 func NewRateLimiter(cfg *config.Config) *RateLimiter {
-	return newRateLimiter(cfg, NewClientIPResolver(cfg))
+	policy := config.RateLimitPolicy{PerIP: cfg.RateLimitPerIP, Global: cfg.GlobalRateLimit}
+	return newRateLimiter("", policy, NewClientIPResolver(cfg))
+}
+
+// newRateLimiters builds one limiter per enabled named policy. Policies
+// without an override in RATE_LIMIT_POLICIES share one set of buckets, so
+// RATE_LIMIT_PER_IP and GLOBAL_RATE_LIMIT remain a single budget across
+// those routes.
+func newRateLimiters(cfg *config.Config, resolver *ClientIPResolver) map[string]*RateLimiter {
+	limiters := make(map[string]*RateLimiter)
+	var shared *rateBuckets
+	for _, name := range config.RateLimitPolicyNames() {
+		policy := cfg.RateLimitPolicy(name)
+		if !policy.Enabled() {
+			continue
+		}
+		if _, configured := cfg.RateLimitPolicies[name]; configured {
+			limiters[name] = newRateLimiter(name, policy, resolver)
+			continue
+		}
+		if shared == nil {
+			shared = newRateBuckets(policy, resolver)
+		}
+		limiters[name] = &RateLimiter{policy: name, rateBuckets: shared}
+	}
+	return limiters
 }
 
-func newRateLimiter(cfg *config.Config, resolver *ClientIPResolver) *RateLimiter {
-	maxIPEntries := max(cfg.GlobalRateLimit*20, 10000)
+func newRateLimiter(name string, policy config.RateLimitPolicy, resolver *ClientIPResolver) *RateLimiter {
+	return &RateLimiter{policy: name, rateBuckets: newRateBuckets(policy, resolver)}
+}
+
+func newRateBuckets(policy config.RateLimitPolicy, resolver *ClientIPResolver) *rateBuckets {
+	maxIPEntries := max(policy.Global*20, 10000)
 	now := time.Now()
-	return &RateLimiter{
-		rateLimitPerIP:   cfg.RateLimitPerIP,
-		globalRateLimit:  cfg.GlobalRateLimit,
+	return &rateBuckets{
+		rateLimitPerIP:   policy.PerIP,
+		globalRateLimit:  policy.Global,
 		ipLimits:         make(map[string]*IPLimit),
-		globalTokens:     cfg.GlobalRateLimit,
+		globalTokens:     policy.Global,
 		globalLastRefill: now,
 		lastCleanup:      now,
 		cleanupInterval:  5 * time.Minute,
@@ -48,14 +99,32 @@ func newRateLimiter(cfg *config.Config, resolver *ClientIPResolver) *RateLimiter
 	}
 }
 
+// Policy returns the configured policy name advertised in response headers.
+func (rl *RateLimiter) Policy() string {
+	return rl.policy
+}
+
+// PerIPLimit returns the per-IP quota per rate-limit window.
+func (rl *RateLimiter) PerIPLimit() int {
+	return rl.rateLimitPerIP
+}
+
 func (rl *RateLimiter) Allow(ip string) bool {
+	return rl.Take(ip).Allowed
+}
+
+// Take consumes one token for ip when both the global and per-IP buckets
+// allow it. Rejections report the exhausted scope and how long until the
+// bucket holds a token again.
+func (rl *RateLimiter) Take(ip string) RateLimitDecision {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	now := time.Now()
 	refillTokens(&rl.globalTokens, &rl.globalLastRefill, rl.globalRateLimit, now)
 	if rl.globalTokens <= 0 {
-		return false
+		wait := untilNextToken(rl.globalLastRefill, rl.globalRateLimit, now)
+		return RateLimitDecision{Scope: rateLimitScopeGlobal, Reset: wait, RetryAfter: wait}
 	}
 
 	if rl.cleanupInterval > 0 && rl.ipLimitTTL > 0 && now.Sub(rl.lastCleanup) >= rl.cleanupInterval {
@@ -66,7 +135,9 @@ func (rl *RateLimiter) Allow(ip string) bool {
 	limit, exists := rl.ipLimits[ip]
 	if !exists {
 		if rl.maxIPEntries > 0 && len(rl.ipLimits) >= rl.maxIPEntries {
-			return false
+			// No bucket can be created until the next cleanup pass.
+			wait := max(rl.cleanupInterval-now.Sub(rl.lastCleanup), time.Second)
+			return RateLimitDecision{Scope: rateLimitScopeGlobal, Reset: wait, RetryAfter: wait}
 		}
 		limit = &IPLimit{
 			tokens:     rl.rateLimitPerIP,
@@ -77,32 +148,61 @@ func (rl *RateLimiter) Allow(ip string) bool {
 
 	refillTokens(&limit.tokens, &limit.lastRefill, rl.rateLimitPerIP, now)
 	if limit.tokens <= 0 {
-		return false
+		wait := untilNextToken(limit.lastRefill, rl.rateLimitPerIP, now)
+		return RateLimitDecision{Scope: rateLimitScopeIP, Reset: wait, RetryAfter: wait}
 	}
 
 	rl.globalTokens--
 	limit.tokens--
-	return true
+	return RateLimitDecision{
+		Allowed:   true,
+		Remaining: limit.tokens,
+		Reset:     untilFull(limit.tokens, limit.lastRefill, rl.rateLimitPerIP, now),
+	}
 }
 
+// refillTokens credits whole tokens accrued since lastRefill and carries the
+// fractional remainder forward by advancing lastRefill only for credited time.
 func refillTokens(tokens *int, lastRefill *time.Time, rate int, now time.Time) {
-	elapsed := now.Sub(*lastRefill)
-	if elapsed < time.Second {
+	if rate <= 0 {
+		return
+	}
+	if *tokens >= rate {
+		*tokens = rate
+		*lastRefill = now
 		return
 	}
-	tokensToAdd := int(elapsed.Seconds() * float64(rate) / 60.0)
+	interval := tokenInterval(rate)
+	tokensToAdd := int(now.Sub(*lastRefill) / interval)
 	if tokensToAdd <= 0 {
 		return
 	}
 	*tokens += tokensToAdd
-	if *tokens > rate {
+	if *tokens >= rate {
 		*tokens = rate
+		*lastRefill = now
+		return
+	}
+	*lastRefill = (*lastRefill).Add(time.Duration(tokensToAdd) * interval)
+}
+
+func tokenInterval(rate int) time.Duration {
+	return rateLimitWindow / time.Duration(rate)
+}
+
+func untilNextToken(lastRefill time.Time, rate int, now time.Time) time.Duration {
+	interval := tokenInterval(rate)
+	return max(interval-now.Sub(lastRefill), 0)
+}
+
+func untilFull(tokens int, lastRefill time.Time, rate int, now time.Time) time.Duration {
+	if tokens >= rate {
+		return 0
 	}
-	consumed := time.Duration(float64(tokensToAdd) / float64(rate) * 60.0 * float64(time.Second))
-	*lastRefill = (*lastRefill).Add(consumed)
+	return untilNextToken(lastRefill, rate, now) + time.Duration(rate-tokens-1)*tokenInterval(rate)
 }
 
-// cleanupExpiredIPLimits removes stale buckets while Allow holds rl.mu.
+// cleanupExpiredIPLimits removes stale buckets while Take holds rl.mu.
 func (rl *RateLimiter) cleanupExpiredIPLimits(now time.Time) {
 	for key, limit := range rl.ipLimits {
 		if now.Sub(limit.lastRefill) >= rl.ipLimitTTL {
@@ -218,3 +218,60 @@ func TestRateLimiterConcurrentExactLimits(t *testing.T) {
 		}
 	}
 }
+
+func TestRefillTokensCreditsSubSecondIntervals(t *testing.T) {
+	start := time.Unix(1, 0)
+	tokens := 0
+	lastRefill := start
+
+	refillTokens(&tokens, &lastRefill, 600, start.Add(250*time.Millisecond))
+
+	if tokens != 2 {
+		t.Fatalf("tokens = %d, want 2", tokens)
+	}
+	if want := start.Add(200 * time.Millisecond); !lastRefill.Equal(want) {
+		t.Fatalf("lastRefill = %v, want %v", lastRefill, want)
+	}
+}
+
+func TestRateLimiterTakeReportsRetryAfterForExhaustedScope(t *testing.T) {
+	limiter := newRateLimiter("test", config.RateLimitPolicy{PerIP: 2, Global: 100}, nil)
+	now := time.Now()
+	limiter.ipLimits["10.0.0.1"] = &IPLimit{tokens: 0, lastRefill: now.Add(-20 * time.Second)}
+
+	decision := limiter.Take("10.0.0.1")
+	if decision.Allowed {
+		t.Fatal("exhausted IP bucket should be rejected")
+	}
+	if decision.Scope != rateLimitScopeIP {
+		t.Fatalf("scope = %q, want %q", decision.Scope, rateLimitScopeIP)
+	}
+	if got := ceilSeconds(decision.RetryAfter); got != 10 {
+		t.Fatalf("retry after = %ds, want 10s", got)
+	}
+
+	limiter.globalTokens = 0
+	limiter.globalLastRefill = now
+	decision = limiter.Take("10.0.0.2")
+	if decision.Allowed || decision.Scope != rateLimitScopeGlobal {
+		t.Fatalf("decision = %+v, want global rejection", decision)
+	}
+	if got := ceilSeconds(decision.RetryAfter); got != 1 {
+		t.Fatalf("global retry after = %ds, want 1s", got)
+	}
+}
+
+func TestRateLimiterTakeReportsRemainingAndReset(t *testing.T) {
+	limiter := newRateLimiter("test", config.RateLimitPolicy{PerIP: 6, Global: 100}, nil)
+
+	decision := limiter.Take("10.0.0.1")
+	if !decision.Allowed {
+		t.Fatal("first request should be allowed")
+	}
+	if decision.Remaining != 5 {
+		t.Fatalf("remaining = %d, want 5", decision.Remaining)
+	}
+	if got := ceilSeconds(decision.Reset); got != 10 {
+		t.Fatalf("reset = %ds, want 10s", got)
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const adminPrefix = apiV1Prefix + "/admin"
+
+type listResultsResponse struct {
+	Results    []results.Result `json:"results"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// list serves the admin search over saved results, newest first.
+func (h *resultHandler) list(w http.ResponseWriter, r *http.Request) {
+	query, err := parseResultsQuery(r.URL.Query())
+	if err != nil {
+		respondResultError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := h.store.List(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, results.ErrInvalidCursor) {
+			respondResultError(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		msg, code := mapGetStoreError(err)
+		respondResultError(w, msg, code)
+		return
+	}
+	respondResultJSON(w, listResultsResponse{Results: page.Results, NextCursor: page.NextCursor}, http.StatusOK)
+}
+
+func parseResultsQuery(values url.Values) (results.Query, error) {
+	var (
+		q   results.Query
+		err error
+	)
+	if q.From, err = parseQueryTime(values, "from"); err != nil {
+		return q, err
+	}
+	if q.To, err = parseQueryTime(values, "to"); err != nil {
+		return q, err
+	}
+	q.ServerName = values.Get("server")
+	for _, bound := range []struct {
+		name string
+		dst  *float64
+	}{
+		{"min_download", &q.Download.Min},
+		{"max_download", &q.Download.Max},
+		{"min_upload", &q.Upload.Min},
+		{"max_upload", &q.Upload.Max},
+	} {
+		if *bound.dst, err = parseQuerySpeed(values, bound.name); err != nil {
+			return q, err
+		}
+	}
+	for _, raw := range values["grade"] {
+		for grade := range strings.SplitSeq(raw, ",") {
+			if grade = strings.TrimSpace(grade); grade != "" {
+				q.Grades = append(q.Grades, grade)
+			}
+		}
+	}
+	if raw := values.Get("ip_prefix"); raw != "" {
+		if q.IPPrefix, err = parseIPPrefix(raw); err != nil {
+			return q, err
+		}
+	}
+	q.Cursor = values.Get("cursor")
+	if raw := values.Get("limit"); raw != "" {
+		q.Limit, err = strconv.Atoi(raw)
+		if err != nil || q.Limit < 1 || q.Limit > results.MaxListLimit {
+			return q, fmt.Errorf("limit must be 1-%d", results.MaxListLimit)
+		}
+	}
+	return q, nil
+}
+
+func parseQueryTime(values url.Values, name string) (time.Time, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return t, nil
+}
+
+func parseQuerySpeed(values url.Values, name string) (float64, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 || v > 100000 {
+		return 0, fmt.Errorf("%s must be a number of Mbps between 0 and 100000", name)
+	}
+	return v, nil
+}
+
+// parseIPPrefix accepts a CIDR prefix or a single address.
+func parseIPPrefix(raw string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(raw); err == nil {
+		return prefix.Masked(), nil
+	}
+	if addr, err := netip.ParseAddr(raw); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	return netip.Prefix{}, fmt.Errorf("ip_prefix must be a CIDR prefix or IP address")
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const (
+	badgeLabel      = "speed"
+	badgeLabelColor = "#555555"
+	badgeLabelText  = "#ffffff"
+	badgePadding    = 6
+)
+
+// badgeStyle follows the shields.io style names embedders already know.
+type badgeStyle struct {
+	height    int
+	radius    int
+	gradient  bool
+	fontSize  int
+	bold      bool
+	uppercase bool
+	// charWidth and letterSpacing approximate text width without font
+	// metrics; badges only need to look right in common sans-serif fonts.
+	charWidth     float64
+	letterSpacing float64
+	textY         int
+}
+
+var badgeStyles = map[string]badgeStyle{
+	"flat":          {height: 20, radius: 3, gradient: true, fontSize: 11, charWidth: 6.6, textY: 14},
+	"flat-square":   {height: 20, fontSize: 11, charWidth: 6.6, textY: 14},
+	"for-the-badge": {height: 28, fontSize: 10, bold: true, uppercase: true, charWidth: 7.2, letterSpacing: 1, textY: 18},
+}
+
+const defaultBadgeStyle = "flat"
+
+func (s badgeStyle) textWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch r {
+		case ' ', '.', ',':
+			width += s.charWidth * 0.55
+		default:
+			width += s.charWidth
+		}
+		width += s.letterSpacing
+	}
+	return int(math.Ceil(width))
+}
+
+// badgeValue summarizes download and upload speed for a badge.
+func badgeValue(result *results.Result) string {
+	return "↓ " + formatMetric(result.DownloadMbps) + " ↑ " + formatMetric(result.UploadMbps) + " Mbps"
+}
+
+func (c *resultCards) renderBadge(result *results.Result, style badgeStyle) []byte {
+	label, value := badgeLabel, badgeValue(result)
+	if style.uppercase {
+		label, value = strings.ToUpper(label), strings.ToUpper(value)
+	}
+	labelWidth := style.textWidth(label) + 2*badgePadding
+	valueWidth := style.textWidth(value) + 2*badgePadding
+	width := labelWidth + valueWidth
+	title := html.EscapeString(badgeLabel + ": " + badgeValue(result))
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="%s">`,
+		width, style.height, title)
+	fmt.Fprintf(&svg, `<title>%s</title>`, title)
+	if style.gradient {
+		svg.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	}
+	fmt.Fprintf(&svg, `<clipPath id="r"><rect width="%d" height="%d" rx="%d" fill="#fff"/></clipPath>`,
+		width, style.height, style.radius)
+	fmt.Fprintf(&svg, `<g clip-path="url(#r)"><rect width="%d" height="%d" fill="%s"/><rect x="%d" width="%d" height="%d" fill="%s"/>`,
+		labelWidth, style.height, badgeLabelColor, labelWidth, valueWidth, style.height, c.primary)
+	if style.gradient {
+		fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="url(#s)"/>`, width, style.height)
+	}
+	svg.WriteString(`</g>`)
+	weight := "normal"
+	if style.bold {
+		weight = "bold"
+	}
+	fmt.Fprintf(&svg, `<g text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="%d" font-weight="%s" letter-spacing="%g">`,
+		style.fontSize, weight, style.letterSpacing)
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" fill="%s">%s</text>`,
+		labelWidth/2, style.textY, badgeLabelText, html.EscapeString(label))
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" fill="%s">%s</text>`,
+		labelWidth+valueWidth/2, style.textY, c.onPrimary, html.EscapeString(value))
+	svg.WriteString("</g></svg>\n")
+	return []byte(svg.String())
+}
+
+// badge serves an embeddable SVG badge with a result's speeds.
+func (h *resultHandler) badge(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("style")
+	if name == "" {
+		name = defaultBadgeStyle
+	}
+	style, ok := badgeStyles[name]
+	if !ok {
+		w.Header().Set(headerCacheControl, valueNoStore)
+		http.Error(w, "style must be flat, flat-square, or for-the-badge", http.StatusBadRequest)
+		return
+	}
+	result := h.sharedResult(w, r)
+	if result == nil {
+		return
+	}
+	writeResultImage(w, r, h.cards.renderBadge(result, style), "image/svg+xml")
+}
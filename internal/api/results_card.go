@@ -0,0 +1,409 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // brand logos may be JPEG
+	"image/png"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const (
+	resultCardWidth  = 1200
+	resultCardHeight = 630
+	// resultCardCacheMaxEntries bounds rendered cards kept in memory; a full
+	// cache is simply reset, as crawlers fetch a card shortly after sharing.
+	resultCardCacheMaxEntries = 256
+	// resultCardMaxAge lets crawlers and CDNs reuse a card briefly while a
+	// deleted result still disappears soon.
+	resultCardMaxAge = "public, max-age=600"
+
+	defaultCardPrimary   = "#00d4aa"
+	defaultCardOnPrimary = "#04231c"
+	defaultCardSecondary = "#667eea"
+	cardBackground       = "#0a0a0f"
+	cardSurface          = "#1a1a24"
+	cardText             = "#ffffff"
+	cardMuted            = "#9a9aab"
+
+	cardMaxServerName = 40
+)
+
+type resultCardFormat string
+
+const (
+	resultCardSVG resultCardFormat = "svg"
+	resultCardPNG resultCardFormat = "png"
+)
+
+type resultCardKey struct {
+	id     string
+	format resultCardFormat
+}
+
+// resultCards renders share cards and badges in the deployment's brand
+// colors and caches cards per result; results never change once saved.
+type resultCards struct {
+	primary   string
+	onPrimary string
+	secondary string
+	logo      config.BrandLogo
+	logoImage image.Image
+
+	mu    sync.Mutex
+	cache map[resultCardKey][]byte
+}
+
+func newResultCards(palette config.BrandPalette, logo config.BrandLogo) *resultCards {
+	c := &resultCards{
+		primary:   defaultCardPrimary,
+		onPrimary: defaultCardOnPrimary,
+		secondary: defaultCardSecondary,
+		cache:     make(map[resultCardKey][]byte),
+	}
+	if palette.HasPrimary {
+		c.primary, c.onPrimary = palette.Dark.Primary, palette.Dark.OnBrand
+	}
+	if palette.HasSecondary {
+		c.secondary = palette.Dark.Secondary
+	}
+	if len(logo.Data) > 0 && logo.ContentType != "" {
+		img, _, err := image.Decode(bytes.NewReader(logo.Data))
+		if err != nil {
+			slog.Warn("results: decode brand logo for cards", "error", err)
+		} else {
+			c.logo, c.logoImage = logo, img
+		}
+	}
+	return c
+}
+
+// render returns the card for result in format, from the cache when present.
+func (c *resultCards) render(result *results.Result, format resultCardFormat) ([]byte, error) {
+	key := resultCardKey{id: result.ID, format: format}
+	c.mu.Lock()
+	card, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok {
+		return card, nil
+	}
+
+	var err error
+	if format == resultCardPNG {
+		card, err = c.renderPNG(result)
+	} else {
+		card = c.renderSVG(result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= resultCardCacheMaxEntries {
+		clear(c.cache)
+	}
+	c.cache[key] = card
+	return card, nil
+}
+
+// forget drops cached cards for a deleted result.
+func (c *resultCards) forget(id string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cache, resultCardKey{id: id, format: resultCardSVG})
+	delete(c.cache, resultCardKey{id: id, format: resultCardPNG})
+}
+
+// formatMetric keeps one decimal for small values and drops it once it stops
+// being meaningful.
+func formatMetric(v float64) string {
+	if v >= 100 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+func cardServerName(name string) string {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) <= cardMaxServerName {
+		return name
+	}
+	return string([]rune(name)[:cardMaxServerName-1]) + "…"
+}
+
+func (c *resultCards) renderSVG(result *results.Result) []byte {
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		resultCardWidth, resultCardHeight, resultCardWidth, resultCardHeight)
+	fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", cardBackground)
+	fmt.Fprintf(&svg, `<rect x="40" y="40" width="1120" height="550" rx="32" fill="%s"/>`+"\n", cardSurface)
+	svg.WriteString(`<g font-family="DM Sans, Helvetica, Arial, sans-serif">` + "\n")
+	if c.logoImage != nil {
+		fmt.Fprintf(&svg, `<image x="80" y="80" width="360" height="64" preserveAspectRatio="xMinYMid meet" href="data:%s;base64,%s"/>`+"\n",
+			c.logo.ContentType, base64.StdEncoding.EncodeToString(c.logo.Data))
+	} else {
+		fmt.Fprintf(&svg, `<text x="80" y="130" font-size="52" font-weight="700" fill="%s">open<tspan fill="%s">Byte</tspan></text>`+"\n",
+			cardText, c.primary)
+	}
+	if name := cardServerName(result.ServerName); name != "" {
+		fmt.Fprintf(&svg, `<text x="80" y="200" font-size="28" fill="%s">Tested against %s</text>`+"\n",
+			cardMuted, html.EscapeString(name))
+	}
+	writeSVGMetric(&svg, 80, "Download", formatMetric(result.DownloadMbps), c.primary)
+	writeSVGMetric(&svg, 620, "Upload", formatMetric(result.UploadMbps), c.secondary)
+	fmt.Fprintf(&svg, `<text x="80" y="520" font-size="32" fill="%s">%s</text>`+"\n",
+		cardText, html.EscapeString(cardSummary(result, " · ")))
+	fmt.Fprintf(&svg, `<text x="1120" y="124" font-size="24" text-anchor="end" fill="%s">%s</text>`+"\n",
+		cardMuted, result.CreatedAt.UTC().Format("2006-01-02"))
+	svg.WriteString("</g>\n</svg>\n")
+	return []byte(svg.String())
+}
+
+func writeSVGMetric(svg *strings.Builder, x int, label, value, fill string) {
+	fmt.Fprintf(svg, `<text x="%d" y="270" font-size="28" fill="%s">%s</text>`+"\n", x, cardMuted, label)
+	fmt.Fprintf(svg, `<text x="%d" y="390" font-size="112" font-weight="700" fill="%s">%s<tspan font-size="36" font-weight="400" fill="%s"> Mbps</tspan></text>`+"\n",
+		x, fill, value, cardMuted)
+}
+
+// cardSummary lists latency, jitter, and the bufferbloat grade when known.
+func cardSummary(result *results.Result, sep string) string {
+	parts := []string{
+		"Latency " + formatMetric(result.LatencyMs) + " ms",
+		"Jitter " + formatMetric(result.JitterMs) + " ms",
+	}
+	if grade := strings.TrimSpace(result.BufferbloatGrade); grade != "" {
+		parts = append(parts, "Bufferbloat "+grade)
+	}
+	return strings.Join(parts, sep)
+}
+
+// renderPNG draws the card with a built-in bitmap font so it needs no font
+// files or image libraries beyond the standard library.
+func (c *resultCards) renderPNG(result *results.Result) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, resultCardWidth, resultCardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(hexColor(cardBackground)), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(40, 40, 1160, 590), image.NewUniform(hexColor(cardSurface)), image.Point{}, draw.Src)
+
+	primary, secondary := hexColor(c.primary), hexColor(c.secondary)
+	text, muted := hexColor(cardText), hexColor(cardMuted)
+	if c.logoImage != nil {
+		drawScaled(img, c.logoImage, image.Rect(80, 80, 440, 144))
+	} else {
+		x := drawText(img, 80, 88, 8, "OPEN", text)
+		drawText(img, x, 88, 8, "BYTE", primary)
+	}
+	if name := cardServerName(result.ServerName); name != "" {
+		drawText(img, 80, 176, 3, "TESTED AGAINST "+name, muted)
+	}
+	drawPNGMetric(img, 80, "DOWNLOAD", formatMetric(result.DownloadMbps), primary, muted)
+	drawPNGMetric(img, 620, "UPLOAD", formatMetric(result.UploadMbps), secondary, muted)
+	drawText(img, 80, 496, 3, cardSummary(result, "   "), text)
+	date := result.CreatedAt.UTC().Format("2006-01-02")
+	drawText(img, 1120-textWidth(date, 3), 102, 3, date, muted)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawPNGMetric(img *image.RGBA, x int, label, value string, fill, muted color.RGBA) {
+	drawText(img, x, 240, 4, label, muted)
+	drawText(img, x, 290, 12, value, fill)
+	drawText(img, x, 390, 4, "MBPS", muted)
+}
+
+func hexColor(hex string) color.RGBA {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 24)
+	if err != nil || len(hex) != 7 {
+		return color.RGBA{A: 0xff}
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
+
+// drawScaled fits src into dst's box, keeping its aspect ratio and aligning
+// it left, with nearest-neighbour sampling.
+func drawScaled(img *image.RGBA, src image.Image, box image.Rectangle) {
+	sb := src.Bounds()
+	if sb.Dx() == 0 || sb.Dy() == 0 {
+		return
+	}
+	scale := min(float64(box.Dx())/float64(sb.Dx()), float64(box.Dy())/float64(sb.Dy()))
+	w, h := max(1, int(float64(sb.Dx())*scale)), max(1, int(float64(sb.Dy())*scale))
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			scaled.Set(x, y, src.At(sb.Min.X+x*sb.Dx()/w, sb.Min.Y+y*sb.Dy()/h))
+		}
+	}
+	at := image.Pt(box.Min.X, box.Min.Y+(box.Dy()-h)/2)
+	draw.Draw(img, image.Rectangle{Min: at, Max: at.Add(scaled.Bounds().Size())}, scaled, image.Point{}, draw.Over)
+}
+
+// drawText writes s in the bitmap font with each font pixel scale pixels
+// wide, and returns the x position after the last glyph.
+func drawText(img *image.RGBA, x, y, scale int, s string, fill color.RGBA) int {
+	for _, r := range strings.ToUpper(s) {
+		rows, ok := cardGlyphs[r]
+		if !ok {
+			rows = cardGlyphs['?']
+		}
+		for row, bits := range rows {
+			for col := range cardGlyphWidth {
+				if bits&(1<<(cardGlyphWidth-1-col)) == 0 {
+					continue
+				}
+				px := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(img, px, image.NewUniform(fill), image.Point{}, draw.Src)
+			}
+		}
+		x += (cardGlyphWidth + 1) * scale
+	}
+	return x
+}
+
+func textWidth(s string, scale int) int {
+	return utf8.RuneCountInString(s) * (cardGlyphWidth + 1) * scale
+}
+
+const cardGlyphWidth = 5
+
+// cardGlyphs is a 5x7 bitmap font; each row's low five bits are its pixels,
+// leftmost first. Characters outside it render as '?'.
+var cardGlyphs = map[rune][7]uint8{
+	' ': {},
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A': {0x0E, 0x11, 0x11, 0x11, 0x1F, 0x11, 0x11},
+	'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S': {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	',': {0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08},
+	':': {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'+': {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	'/': {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'(': {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')': {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'?': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+	'…': {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x15},
+}
+
+// serveCard serves the share card for a saved result in format.
+func (h *resultHandler) serveCard(w http.ResponseWriter, r *http.Request, format resultCardFormat) {
+	result := h.sharedResult(w, r)
+	if result == nil {
+		return
+	}
+	id := result.ID
+	card, err := h.cards.render(result, format)
+	if err != nil {
+		slog.Warn("results: render card failed", "id", id, "format", format, "error", err)
+		w.Header().Set(headerCacheControl, valueNoStore)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "image/png"
+	if format == resultCardSVG {
+		contentType = "image/svg+xml"
+	}
+	writeResultImage(w, r, card, contentType)
+}
+
+// sharedResult looks up the result an image route is for, writing a plain
+// error and returning nil when there is nothing to render. It always
+// consults the store so deleted and expired results stop rendering even
+// while their card is still cached.
+func (h *resultHandler) sharedResult(w http.ResponseWriter, r *http.Request) *results.Result {
+	id := r.PathValue("id")
+	if !validResultID(id) {
+		w.Header().Set(headerCacheControl, valueNoStore)
+		http.NotFound(w, r)
+		return nil
+	}
+	result, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		msg, code := mapGetStoreError(err)
+		w.Header().Set(headerCacheControl, valueNoStore)
+		http.Error(w, msg, code)
+		return nil
+	}
+	if result == nil {
+		h.cards.forget(id)
+		w.Header().Set(headerCacheControl, valueNoStore)
+		http.NotFound(w, r)
+		return nil
+	}
+	return result
+}
+
+// writeResultImage serves a rendered card or badge with a short public cache
+// lifetime and an ETag, so crawlers and embeds can revalidate cheaply.
+func writeResultImage(w http.ResponseWriter, r *http.Request, data []byte, contentType string) {
+	sum := sha256.Sum256(data)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set(headerCacheControl, resultCardMaxAge)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (h *resultHandler) cardSVG(w http.ResponseWriter, r *http.Request) {
+	h.serveCard(w, r, resultCardSVG)
+}
+
+func (h *resultHandler) cardPNG(w http.ResponseWriter, r *http.Request) {
+	h.serveCard(w, r, resultCardPNG)
+}
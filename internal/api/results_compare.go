@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const (
+	minCompareResults = 2
+	maxCompareResults = 5
+)
+
+// metricDelta is how far a metric moved from the baseline. Percent is null
+// when the baseline value is zero.
+type metricDelta struct {
+	Delta   float64  `json:"delta"`
+	Percent *float64 `json:"percent"`
+}
+
+type resultDelta struct {
+	ID              string      `json:"id"`
+	DownloadMbps    metricDelta `json:"download_mbps"`
+	UploadMbps      metricDelta `json:"upload_mbps"`
+	LatencyMs       metricDelta `json:"latency_ms"`
+	JitterMs        metricDelta `json:"jitter_ms"`
+	LoadedLatencyMs metricDelta `json:"loaded_latency_ms"`
+}
+
+type compareResponse struct {
+	// Baseline is the first requested ID; every delta is relative to it.
+	Baseline string           `json:"baseline"`
+	Results  []results.Result `json:"results"`
+	Deltas   []resultDelta    `json:"deltas"`
+}
+
+// compare returns two to five results side by side, in request order, with
+// each later result's change from the first.
+func (h *resultHandler) compare(w http.ResponseWriter, r *http.Request) {
+	ids, msg := parseCompareIDs(r.URL.Query().Get("ids"))
+	if msg != "" {
+		respondResultError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	compared := make([]results.Result, 0, len(ids))
+	for _, id := range ids {
+		result, err := h.store.Get(r.Context(), id)
+		if err != nil {
+			msg, code := mapGetStoreError(err)
+			respondResultError(w, msg, code)
+			return
+		}
+		if result == nil {
+			respondResultError(w, "result not found: "+id, http.StatusNotFound)
+			return
+		}
+		compared = append(compared, *result)
+	}
+
+	baseline := compared[0]
+	deltas := make([]resultDelta, 0, len(compared)-1)
+	for _, result := range compared[1:] {
+		deltas = append(deltas, resultDelta{
+			ID:              result.ID,
+			DownloadMbps:    newMetricDelta(baseline.DownloadMbps, result.DownloadMbps),
+			UploadMbps:      newMetricDelta(baseline.UploadMbps, result.UploadMbps),
+			LatencyMs:       newMetricDelta(baseline.LatencyMs, result.LatencyMs),
+			JitterMs:        newMetricDelta(baseline.JitterMs, result.JitterMs),
+			LoadedLatencyMs: newMetricDelta(baseline.LoadedLatencyMs, result.LoadedLatencyMs),
+		})
+	}
+	respondResultJSON(w, compareResponse{Baseline: baseline.ID, Results: compared, Deltas: deltas}, http.StatusOK)
+}
+
+// parseCompareIDs splits the comma-separated ids parameter, returning a
+// client error message when it is unusable.
+func parseCompareIDs(raw string) ([]string, string) {
+	if raw == "" {
+		return nil, "ids is required"
+	}
+	ids := strings.Split(raw, ",")
+	if len(ids) < minCompareResults || len(ids) > maxCompareResults {
+		return nil, fmt.Sprintf("ids must list %d to %d result IDs", minCompareResults, maxCompareResults)
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !validResultID(id) {
+			return nil, "invalid result ID"
+		}
+		if seen[id] {
+			return nil, "result IDs must be distinct"
+		}
+		seen[id] = true
+	}
+	return ids, ""
+}
+
+func newMetricDelta(baseline, value float64) metricDelta {
+	d := metricDelta{Delta: roundHundredths(value - baseline)}
+	if baseline != 0 {
+		percent := roundHundredths((value - baseline) / baseline * 100)
+		d.Percent = &percent
+	}
+	return d
+}
+
+func roundHundredths(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+// resultFormat is a representation GET /api/v1/results/{id} can return.
+type resultFormat struct {
+	ext         string
+	mediaType   string
+	contentType string
+}
+
+// resultFormats is in order of preference when Accept ranks several equally;
+// JSON comes first so "*/*" keeps the original response.
+var resultFormats = []resultFormat{
+	{ext: ".json", mediaType: contentTypeJSON, contentType: contentTypeJSON},
+	{ext: ".txt", mediaType: "text/plain", contentType: "text/plain; charset=utf-8"},
+	{ext: ".csv", mediaType: "text/csv", contentType: "text/csv; charset=utf-8"},
+	{ext: ".jsonld", mediaType: "application/ld+json", contentType: "application/ld+json"},
+}
+
+// requestedResultFormat returns the result ID and its format. An extension
+// on the ID wins over Accept so plain links can name a format; an unknown
+// extension stays on the ID and fails ID validation. ok is false when
+// Accept allows none of the formats.
+func requestedResultFormat(r *http.Request) (id string, format resultFormat, ok bool) {
+	id = r.PathValue("id")
+	if ext := path.Ext(id); ext != "" {
+		for _, f := range resultFormats {
+			if f.ext == ext {
+				return strings.TrimSuffix(id, ext), f, true
+			}
+		}
+		return id, resultFormats[0], true
+	}
+	format, ok = negotiateResultFormat(strings.Join(r.Header.Values("Accept"), ","))
+	return id, format, ok
+}
+
+// negotiateResultFormat picks the format Accept ranks highest.
+func negotiateResultFormat(accept string) (resultFormat, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return resultFormats[0], true
+	}
+	best, bestQuality := -1, 0.0
+	for i, f := range resultFormats {
+		if quality := mediaTypeQuality(accept, f.mediaType); quality > bestQuality {
+			best, bestQuality = i, quality
+		}
+	}
+	if best < 0 {
+		return resultFormat{}, false
+	}
+	return resultFormats[best], true
+}
+
+// mediaTypeQuality returns the q-value of the most specific Accept range
+// that matches mediaType, or 0 when none does.
+func mediaTypeQuality(accept, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for part := range strings.SplitSeq(accept, ",") {
+		fields := strings.Split(part, ";")
+		var s int
+		switch strings.ToLower(strings.TrimSpace(fields[0])) {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s >= specificity {
+			quality, specificity = qualityParam(fields[1:]), s
+		}
+	}
+	return quality
+}
+
+func resultMediaTypes() string {
+	types := make([]string, len(resultFormats))
+	for i, f := range resultFormats {
+		types[i] = f.mediaType
+	}
+	return strings.Join(types, ", ")
+}
+
+func (h *resultHandler) writeResult(w http.ResponseWriter, r *http.Request, result *results.Result, format resultFormat) {
+	var body bytes.Buffer
+	var err error
+	switch format.ext {
+	case ".txt":
+		err = writeResultText(&body, result)
+	case ".csv":
+		err = results.WriteCSV(&body, *result)
+	case ".jsonld":
+		err = json.NewEncoder(&body).Encode(newResultJSONLD(h.origin(r), result))
+	default:
+		respondResultJSON(w, result, http.StatusOK)
+		return
+	}
+	if err != nil {
+		slog.Warn("results: encode result", "id", result.ID, "format", format.ext, "error", err)
+		respondResultError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(headerCacheControl, valueNoStore)
+	w.Header().Set(headerContentType, format.contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body.Bytes()); err != nil {
+		slog.Warn("results: write result", "id", result.ID, "error", err)
+	}
+}
+
+// writeResultText writes an aligned summary for terminals, leaving out
+// fields the result does not have.
+func writeResultText(buf *bytes.Buffer, result *results.Result) error {
+	fmt.Fprintf(buf, "openByte result %s\n\n", result.ID)
+	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", label, value)
+		}
+	}
+	row("Download", formatMetric(result.DownloadMbps)+" Mbps")
+	row("Upload", formatMetric(result.UploadMbps)+" Mbps")
+	row("Latency", formatMetric(result.LatencyMs)+" ms")
+	row("Jitter", formatMetric(result.JitterMs)+" ms")
+	row("Loaded latency", formatMetric(result.LoadedLatencyMs)+" ms")
+	row("Bufferbloat", printableText(result.BufferbloatGrade))
+	row("Server", printableText(result.ServerName))
+	row("IPv4", printableText(result.IPv4))
+	row("IPv6", printableText(result.IPv6))
+	if result.ASN != 0 {
+		row("Network", strings.TrimSpace(fmt.Sprintf("AS%d %s", result.ASN, printableText(result.ASOrganization))))
+	}
+	row("Country", printableText(result.Country))
+	row("Tested", result.CreatedAt.UTC().Format(time.DateTime+" UTC"))
+	if !result.ExpiresAt.IsZero() {
+		row("Expires", result.ExpiresAt.UTC().Format(time.DateTime+" UTC"))
+	}
+	return tw.Flush()
+}
+
+// printableText drops control characters so submitted fields cannot emit
+// terminal escape sequences.
+func printableText(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// resultJSONLD describes a result as a schema.org Dataset. Client
+// addresses are left out; JSON-LD is meant for republishing.
+type resultJSONLD struct {
+	Context          string           `json:"@context"`
+	Type             string           `json:"@type"`
+	ID               string           `json:"@id"`
+	Identifier       string           `json:"identifier"`
+	Name             string           `json:"name"`
+	Description      string           `json:"description"`
+	URL              string           `json:"url"`
+	Image            string           `json:"image"`
+	DateCreated      time.Time        `json:"dateCreated"`
+	Expires          time.Time        `json:"expires"`
+	VariableMeasured []jsonLDProperty `json:"variableMeasured"`
+}
+
+// jsonLDProperty is a schema.org PropertyValue; unit codes are UN/CEFACT
+// Common Codes.
+type jsonLDProperty struct {
+	Type       string `json:"@type"`
+	PropertyID string `json:"propertyID"`
+	Name       string `json:"name"`
+	Value      any    `json:"value"`
+	UnitText   string `json:"unitText,omitempty"`
+	UnitCode   string `json:"unitCode,omitempty"`
+}
+
+const (
+	unitCodeMbps        = "E20"
+	unitCodeMillisecond = "C26"
+)
+
+func newResultJSONLD(origin string, result *results.Result) resultJSONLD {
+	pageURL := origin + "/results/" + result.ID
+	measured := []jsonLDProperty{
+		{Type: "PropertyValue", PropertyID: "download_mbps", Name: "Download", Value: result.DownloadMbps, UnitText: "Mbit/s", UnitCode: unitCodeMbps},
+		{Type: "PropertyValue", PropertyID: "upload_mbps", Name: "Upload", Value: result.UploadMbps, UnitText: "Mbit/s", UnitCode: unitCodeMbps},
+		{Type: "PropertyValue", PropertyID: "latency_ms", Name: "Latency", Value: result.LatencyMs, UnitText: "ms", UnitCode: unitCodeMillisecond},
+		{Type: "PropertyValue", PropertyID: "jitter_ms", Name: "Jitter", Value: result.JitterMs, UnitText: "ms", UnitCode: unitCodeMillisecond},
+		{Type: "PropertyValue", PropertyID: "loaded_latency_ms", Name: "Loaded latency", Value: result.LoadedLatencyMs, UnitText: "ms", UnitCode: unitCodeMillisecond},
+	}
+	if grade := strings.TrimSpace(result.BufferbloatGrade); grade != "" {
+		measured = append(measured, jsonLDProperty{Type: "PropertyValue", PropertyID: "bufferbloat_grade", Name: "Bufferbloat grade", Value: grade})
+	}
+	return resultJSONLD{
+		Context:          "https://schema.org",
+		Type:             "Dataset",
+		ID:               pageURL,
+		Identifier:       result.ID,
+		Name:             resultTitle(result),
+		Description:      resultDescription(result),
+		URL:              pageURL,
+		Image:            pageURL + "/card.png",
+		DateCreated:      result.CreatedAt,
+		Expires:          result.ExpiresAt,
+		VariableMeasured: measured,
+	}
+}
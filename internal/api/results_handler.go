@@ -3,10 +3,14 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
+	"github.com/saveenergy/openbyte/internal/geoip"
 	"github.com/saveenergy/openbyte/internal/httpbody"
 	"github.com/saveenergy/openbyte/internal/results"
 )
@@ -16,10 +20,22 @@ const maxResultBodyBytes = 4096
 var errTrailingJSON = errors.New("request body must contain a single JSON object")
 
 type resultHandler struct {
-	store *results.Store
+	store      results.Storage
+	statsCache *statsCache
+	// snapshots is set when the backend supports admin-triggered snapshots.
+	snapshots *snapshotTarget
+	cards     *resultCards
+	// plausibility judges saved results; nil skips the check.
+	plausibility *plausibilityPolicy
+	// origin returns the scheme and host for absolute URLs in responses.
+	origin func(*http.Request) string
+	// geoIP annotates saved results with the submitter's network; nil
+	// leaves them unannotated.
+	geoIP    *geoip.DB
+	clientIP func(*http.Request) string
 }
 
-func newResultHandler(store *results.Store) *resultHandler {
+func newResultHandler(store results.Storage) *resultHandler {
 	if store == nil {
 		return nil
 	}
@@ -36,11 +52,20 @@ type saveResultRequest struct {
 	IPv4             string  `json:"ipv4"`
 	IPv6             string  `json:"ipv6"`
 	ServerName       string  `json:"server_name"`
+	// RetentionDays shortens the result's lifetime; zero uses the server
+	// default.
+	RetentionDays int `json:"retention_days"`
 }
 
 type saveResultResponse struct {
 	ID  string `json:"id"`
 	URL string `json:"url"`
+	// ExpiresAt is when the result link stops working.
+	ExpiresAt time.Time `json:"expires_at"`
+	// DeleteToken lets whoever shared the result remove it again. It is
+	// returned only once.
+	DeleteToken  string `json:"delete_token"`
+	Plausibility string `json:"plausibility,omitempty"`
 }
 
 func (h *resultHandler) save(w http.ResponseWriter, r *http.Request) {
@@ -64,23 +89,7 @@ func (h *resultHandler) save(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.DownloadMbps < 0 || req.UploadMbps < 0 || req.LatencyMs < 0 ||
-		req.JitterMs < 0 || req.LoadedLatencyMs < 0 {
-		respondResultError(w, "numeric fields must be >= 0", http.StatusBadRequest)
-		return
-	}
-	if req.DownloadMbps > 100000 || req.UploadMbps > 100000 ||
-		req.LatencyMs > 60000 || req.JitterMs > 60000 || req.LoadedLatencyMs > 60000 {
-		respondResultError(w, "values out of reasonable range", http.StatusBadRequest)
-		return
-	}
-	if len(req.ServerName) > 200 || len(req.IPv4) > 45 || len(req.IPv6) > 45 ||
-		len(req.BufferbloatGrade) > 5 {
-		respondResultError(w, "field too long", http.StatusBadRequest)
-		return
-	}
-
-	id, err := h.store.Save(r.Context(), results.Result{
+	result := results.Result{
 		DownloadMbps:     req.DownloadMbps,
 		UploadMbps:       req.UploadMbps,
 		LatencyMs:        req.LatencyMs,
@@ -90,7 +99,37 @@ func (h *resultHandler) save(w http.ResponseWriter, r *http.Request) {
 		IPv4:             req.IPv4,
 		IPv6:             req.IPv6,
 		ServerName:       req.ServerName,
-	})
+	}
+	if err := results.Validate(result); err != nil {
+		respondResultError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, maxRetention := h.store.Retention()
+	maxDays := retentionDays(maxRetention)
+	if req.RetentionDays < 0 || req.RetentionDays > maxDays {
+		respondResultError(w, fmt.Sprintf("retention_days must be between 1 and %d, or 0 for the default", maxDays), http.StatusBadRequest)
+		return
+	}
+	plausibility, reason := h.plausibility.check(r, req.DownloadMbps, req.UploadMbps)
+	if plausibility == results.PlausibilityImplausible {
+		speedtestMetrics.Add(metricImplausibleResults, 1)
+		slog.Info("results: implausible result", "reason", reason, "rejected", h.plausibility.reject)
+		if h.plausibility.reject {
+			respondResultError(w, "implausible result: "+reason, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	var network geoip.Info
+	if h.geoIP != nil {
+		network = h.geoIP.Lookup(net.ParseIP(h.clientIP(r)))
+	}
+	if req.RetentionDays > 0 {
+		result.ExpiresAt = time.Now().UTC().Add(time.Duration(req.RetentionDays) * 24 * time.Hour)
+	}
+	result.Plausibility = plausibility
+	result.ASN, result.ASOrganization, result.Country = network.ASN, network.Organization, network.Country
+
+	saved, err := h.store.SaveWithDeleteToken(r.Context(), result)
 	if err != nil {
 		slog.Warn("results: save failed", "error", err)
 		msg, code := mapSaveStoreError(err)
@@ -98,15 +137,28 @@ func (h *resultHandler) save(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondResultJSON(w, saveResultResponse{ID: id, URL: "/results/" + id}, http.StatusCreated)
+	respondResultJSON(w, saveResultResponse{
+		ID:           saved.ID,
+		URL:          "/results/" + saved.ID,
+		ExpiresAt:    saved.ExpiresAt,
+		DeleteToken:  saved.DeleteToken,
+		Plausibility: plausibility,
+	}, http.StatusCreated)
 }
 
+// get returns a result as JSON, CSV, plain text, or JSON-LD, chosen by an
+// extension on the ID or else by the Accept header.
 func (h *resultHandler) get(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
+	w.Header().Add("Vary", "Accept")
+	id, format, ok := requestedResultFormat(r)
 	if !validResultID(id) {
 		respondResultError(w, "invalid result ID", http.StatusBadRequest)
 		return
 	}
+	if !ok {
+		respondResultError(w, "acceptable formats are "+resultMediaTypes(), http.StatusNotAcceptable)
+		return
+	}
 
 	result, err := h.store.Get(r.Context(), id)
 	if err != nil {
@@ -119,7 +171,45 @@ func (h *resultHandler) get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondResultJSON(w, result, http.StatusOK)
+	h.writeResult(w, r, result, format)
+}
+
+// delete removes a result for the holder of its delete token, sent as
+// "Authorization: Bearer obd_...".
+func (h *resultHandler) delete(w http.ResponseWriter, r *http.Request) {
+	httpbody.DrainAndClose(w, r)
+	id := r.PathValue("id")
+	if !validResultID(id) {
+		respondResultError(w, "invalid result ID", http.StatusBadRequest)
+		return
+	}
+	token, ok := bearerToken(r)
+	if !ok || token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="openbyte-results"`)
+		respondResultError(w, "delete token required", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.store.Delete(r.Context(), id, token)
+	switch {
+	case err == nil:
+		h.cards.forget(id)
+		w.Header().Set(headerCacheControl, valueNoStore)
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, results.ErrNotFound):
+		respondResultError(w, "result not found", http.StatusNotFound)
+	case errors.Is(err, results.ErrInvalidDeleteToken):
+		respondResultError(w, "invalid delete token", http.StatusForbidden)
+	default:
+		slog.Warn("results: delete failed", "error", err)
+		msg, code := mapGetStoreError(err)
+		respondResultError(w, msg, code)
+	}
+}
+
+// retentionDays converts a retention to whole days, rounding down.
+func retentionDays(d time.Duration) int {
+	return int(d / (24 * time.Hour))
 }
 
 func respondResultError(w http.ResponseWriter, msg string, code int) {
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	oEmbedPath = apiV1Prefix + "/oembed"
+	// oEmbedCacheAge matches the card lifetime the embed points at.
+	oEmbedCacheAge = 600
+)
+
+// oEmbedResponse is an oEmbed 1.0 "rich" response: a linked result card,
+// with the same card as thumbnail for consumers that only show those.
+type oEmbedResponse struct {
+	Type            string `json:"type"`
+	Version         string `json:"version"`
+	Title           string `json:"title"`
+	ProviderName    string `json:"provider_name"`
+	ProviderURL     string `json:"provider_url"`
+	CacheAge        int    `json:"cache_age"`
+	HTML            string `json:"html"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+	ThumbnailURL    string `json:"thumbnail_url"`
+	ThumbnailWidth  int    `json:"thumbnail_width"`
+	ThumbnailHeight int    `json:"thumbnail_height"`
+}
+
+// oEmbed describes a result page on this server for embedding consumers.
+func (r *Router) oEmbed(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	if format := query.Get("format"); format != "" && format != "json" {
+		respondResultError(w, "only the json format is supported", http.StatusNotImplemented)
+		return
+	}
+	maxWidth, okWidth := oEmbedDimension(query.Get("maxwidth"))
+	maxHeight, okHeight := oEmbedDimension(query.Get("maxheight"))
+	if !okWidth || !okHeight {
+		respondResultError(w, "maxwidth and maxheight must be positive integers", http.StatusBadRequest)
+		return
+	}
+	rawURL := query.Get("url")
+	if rawURL == "" {
+		respondResultError(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	id, ok := resultIDFromURL(rawURL, req.Host)
+	if !ok {
+		respondResultError(w, "url is not a result on this server", http.StatusNotFound)
+		return
+	}
+	result, err := r.resultsHandler.store.Get(req.Context(), id)
+	if err != nil {
+		msg, code := mapGetStoreError(err)
+		respondResultError(w, msg, code)
+		return
+	}
+	if result == nil {
+		respondResultError(w, "result not found", http.StatusNotFound)
+		return
+	}
+
+	origin := r.requestOrigin(req)
+	pageURL := origin + "/results/" + id
+	cardURL := pageURL + "/card.png"
+	title := resultTitle(result)
+	width, height := fitCard(maxWidth, maxHeight)
+	w.Header().Set(headerCacheControl, "public, max-age="+strconv.Itoa(oEmbedCacheAge))
+	respondJSON(w, oEmbedResponse{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        title,
+		ProviderName: r.serverName,
+		ProviderURL:  origin + "/",
+		CacheAge:     oEmbedCacheAge,
+		HTML: fmt.Sprintf(`<a href="%s"><img src="%s" width="%d" height="%d" alt="%s"></a>`,
+			html.EscapeString(pageURL), html.EscapeString(cardURL), width, height, html.EscapeString(title)),
+		Width:           width,
+		Height:          height,
+		ThumbnailURL:    cardURL,
+		ThumbnailWidth:  width,
+		ThumbnailHeight: height,
+	}, http.StatusOK)
+}
+
+// oEmbedDimension parses an optional maxwidth or maxheight; zero means unset.
+func oEmbedDimension(raw string) (int, bool) {
+	if raw == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(raw)
+	return n, err == nil && n > 0
+}
+
+// resultIDFromURL accepts /results/{id} URLs on host, or without a host.
+func resultIDFromURL(raw, host string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	if u.Host != "" && !strings.EqualFold(u.Host, host) {
+		return "", false
+	}
+	id, ok := strings.CutPrefix(strings.TrimSuffix(u.Path, "/"), "/results/")
+	return id, ok && validResultID(id)
+}
+
+// fitCard scales the result card down, keeping its aspect ratio, to fit the
+// consumer's bounds.
+func fitCard(maxWidth, maxHeight int) (int, int) {
+	width, height := resultCardWidth, resultCardHeight
+	if maxWidth > 0 && width > maxWidth {
+		width, height = maxWidth, height*maxWidth/width
+	}
+	if maxHeight > 0 && height > maxHeight {
+		width, height = width*maxHeight/height, maxHeight
+	}
+	return max(width, 1), max(height, 1)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const (
+	// linkCapacityTolerance allows for measurement overhead around the
+	// configured link capacity.
+	linkCapacityTolerance = 1.1
+	// observedTolerance and observedSlackMbps allow for clients leaving
+	// ramp-up out of their measurement, which the server's whole-transfer
+	// average includes.
+	observedTolerance = 1.5
+	observedSlackMbps = 1
+)
+
+const metricImplausibleResults = "implausible_results"
+
+// plausibilityPolicy judges saved results against the server's link
+// capacity and the rates it carried for the saving client.
+type plausibilityPolicy struct {
+	capacityMbps float64
+	reject       bool
+	observed     *observedThroughput
+	clientIP     func(*http.Request) string
+}
+
+func newPlausibilityPolicy(cfg *config.Config, observed *observedThroughput, clientIP func(*http.Request) string) *plausibilityPolicy {
+	return &plausibilityPolicy{
+		capacityMbps: float64(cfg.LinkCapacityMbps),
+		reject:       cfg.PlausibilityMode == config.PlausibilityModeReject,
+		observed:     observed,
+		clientIP:     clientIP,
+	}
+}
+
+// check returns the result's plausibility status and, for implausible
+// results, the reason. A result is plausible only when every direction it
+// reports was checked against a recent observation.
+func (p *plausibilityPolicy) check(r *http.Request, download, upload float64) (string, string) {
+	if p == nil {
+		return "", ""
+	}
+	if p.capacityMbps > 0 {
+		limit := p.capacityMbps * linkCapacityTolerance
+		if download > limit || upload > limit {
+			return results.PlausibilityImplausible,
+				fmt.Sprintf("exceeds the server link capacity of %g Mbps", p.capacityMbps)
+		}
+	}
+	observedDown, observedUp := p.observed.peak(p.clientIP(r), time.Now())
+	status := results.PlausibilityPlausible
+	for _, m := range []struct {
+		name               string
+		reported, observed float64
+	}{
+		{"download", download, observedDown},
+		{"upload", upload, observedUp},
+	} {
+		switch {
+		case m.reported == 0:
+		case m.observed == 0:
+			status = results.PlausibilityUnverified
+		case m.reported > m.observed*observedTolerance+observedSlackMbps:
+			return results.PlausibilityImplausible,
+				fmt.Sprintf("%s exceeds the %s Mbps this server carried", m.name, formatMetric(m.observed))
+		}
+	}
+	return status, ""
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+// The results page marks its generic share metadata so it can be replaced
+// with the shared result's values for link previews.
+const (
+	sharePreviewStart = "<!-- share-preview:start -->"
+	sharePreviewEnd   = "<!-- share-preview:end -->"
+	// maxResultsPageBytes guards against an oversized WEB_ROOT override.
+	maxResultsPageBytes = 1 << 20
+)
+
+var sharePreviewTemplate = template.Must(template.New("share-preview").Parse(`<meta name="description" content="{{.Description}}" />
+    <meta property="og:title" content="{{.Title}}" />
+    <meta property="og:description" content="{{.Description}}" />
+    <meta property="og:type" content="website" />
+    <meta property="og:url" content="{{.URL}}" />
+    <meta property="og:image" content="{{.Image}}" />
+    <meta property="og:image:type" content="image/png" />
+    <meta property="og:image:width" content="{{.Width}}" />
+    <meta property="og:image:height" content="{{.Height}}" />
+    <meta property="og:image:alt" content="{{.Title}}" />
+    <meta name="twitter:card" content="summary_large_image" />
+    <meta name="twitter:title" content="{{.Title}}" />
+    <meta name="twitter:description" content="{{.Description}}" />
+    <meta name="twitter:image" content="{{.Image}}" />
+    <link rel="alternate" type="application/json+oembed" href="{{.OEmbed}}" title="{{.Title}}" />`))
+
+type sharePreview struct {
+	Title       string
+	Description string
+	URL         string
+	Image       string
+	OEmbed      string
+	Width       int
+	Height      int
+}
+
+// serveResultsPage serves the shared-result page. For a stored result the
+// generic share metadata is replaced with its values, so link previews in
+// chat apps show the result without running JavaScript.
+func (r *Router) serveResultsPage(w http.ResponseWriter, req *http.Request, staticHandler http.Handler) {
+	id := req.PathValue("id")
+	if !validResultID(id) {
+		http.NotFound(w, req)
+		return
+	}
+	if page := r.resultsPreviewPage(req, id); page != nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set(headerCacheControl, valueNoStore)
+		w.Header().Set("Content-Length", strconv.Itoa(len(page)))
+		w.WriteHeader(http.StatusOK)
+		if req.Method == http.MethodHead {
+			return
+		}
+		if _, err := w.Write(page); err != nil {
+			slog.Warn("results: write page", "id", id, "error", err)
+		}
+		return
+	}
+	staticReq := req.Clone(req.Context())
+	staticReq.URL.Path = "/" + resultsHTML
+	staticReq.URL.RawPath = ""
+	staticHandler.ServeHTTP(w, staticReq)
+}
+
+// resultsPreviewPage returns the results page with share metadata for id,
+// or nil to fall back to the static page: the result is missing or the store
+// failed (the page reports either itself), or a WEB_ROOT override lacks the
+// markers.
+func (r *Router) resultsPreviewPage(req *http.Request, id string) []byte {
+	result, err := r.resultsHandler.store.Get(req.Context(), id)
+	if err != nil || result == nil {
+		return nil
+	}
+	f, err := r.webFS.Open(resultsHTML)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	page, err := io.ReadAll(io.LimitReader(f, maxResultsPageBytes))
+	if err != nil {
+		return nil
+	}
+	before, rest, ok := bytes.Cut(page, []byte(sharePreviewStart))
+	if !ok {
+		return nil
+	}
+	_, after, ok := bytes.Cut(rest, []byte(sharePreviewEnd))
+	if !ok {
+		return nil
+	}
+
+	origin := r.requestOrigin(req)
+	pageURL := origin + "/results/" + id
+	title := resultTitle(result)
+	var meta bytes.Buffer
+	if err := sharePreviewTemplate.Execute(&meta, sharePreview{
+		Title:       title,
+		Description: resultDescription(result),
+		URL:         pageURL,
+		Image:       pageURL + "/card.png",
+		OEmbed:      origin + oEmbedPath + "?format=json&url=" + url.QueryEscape(pageURL),
+		Width:       resultCardWidth,
+		Height:      resultCardHeight,
+	}); err != nil {
+		slog.Warn("results: render share preview", "id", id, "error", err)
+		return nil
+	}
+
+	out := make([]byte, 0, len(page)+meta.Len())
+	out = append(out, before...)
+	out = append(out, meta.Bytes()...)
+	return append(out, after...)
+}
+
+func resultTitle(result *results.Result) string {
+	return "Speed test: " + formatMetric(result.DownloadMbps) + " Mbps down, " +
+		formatMetric(result.UploadMbps) + " Mbps up"
+}
+
+// resultDescription is the one-line summary shared result metadata carries.
+func resultDescription(result *results.Result) string {
+	description := cardSummary(result, " · ")
+	if name := strings.TrimSpace(result.ServerName); name != "" {
+		description += " · tested against " + name
+	}
+	return description
+}
+
+// requestOrigin is the scheme and host clients used to reach this server,
+// for absolute URLs that crawlers require in share metadata.
+func (r *Router) requestOrigin(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil || (r.clientIPResolver != nil && r.clientIPResolver.ForwardedHTTPS(req)) {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/saveenergy/openbyte/internal/httpbody"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+// snapshotter is a results backend that can write database snapshots.
+type snapshotter interface {
+	Backup(ctx context.Context, dir string, keep int) (results.Snapshot, error)
+}
+
+// snapshotTarget is where admin-triggered snapshots go and how many are kept.
+type snapshotTarget struct {
+	store snapshotter
+	dir   string
+	keep  int
+}
+
+// snapshot writes a snapshot of the results database on demand.
+func (h *resultHandler) snapshot(w http.ResponseWriter, r *http.Request) {
+	httpbody.DrainAndClose(w, r)
+	snap, err := h.snapshots.store.Backup(r.Context(), h.snapshots.dir, h.snapshots.keep)
+	if err != nil {
+		slog.Error("results snapshot failed", "error", err)
+		msg, code := mapGetStoreError(err)
+		respondResultError(w, msg, code)
+		return
+	}
+	slog.Info("results snapshot written", "name", snap.Name, "size_bytes", snap.SizeBytes)
+	respondResultJSON(w, snap, http.StatusCreated)
+}
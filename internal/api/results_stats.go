@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+// statsCacheMaxEntries bounds distinct cached queries; admin dashboards
+// repeat a handful of ranges, so a full cache is simply reset.
+const statsCacheMaxEntries = 256
+
+type statsResponse struct {
+	GroupBy     results.StatsPeriod   `json:"group_by,omitempty"`
+	ByServer    bool                  `json:"by_server"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Buckets     []results.StatsBucket `json:"buckets"`
+}
+
+type statsCacheEntry struct {
+	response statsResponse
+	expires  time.Time
+}
+
+// statsCache keeps aggregate responses for ttl so dashboards polling the
+// same range do not rescan the results table. A zero ttl disables caching.
+type statsCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[results.StatsQuery]statsCacheEntry
+}
+
+func newStatsCache(ttl time.Duration) *statsCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &statsCache{ttl: ttl, entries: make(map[results.StatsQuery]statsCacheEntry)}
+}
+
+func (c *statsCache) get(q results.StatsQuery, now time.Time) (statsResponse, bool) {
+	if c == nil {
+		return statsResponse{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[q]
+	if !ok || !now.Before(entry.expires) {
+		return statsResponse{}, false
+	}
+	return entry.response, true
+}
+
+func (c *statsCache) put(q results.StatsQuery, response statsResponse, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= statsCacheMaxEntries {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= statsCacheMaxEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[q] = statsCacheEntry{response: response, expires: now.Add(c.ttl)}
+}
+
+// stats serves aggregate throughput and latency statistics over saved results.
+func (h *resultHandler) stats(w http.ResponseWriter, r *http.Request) {
+	query, err := parseStatsQuery(r.URL.Query())
+	if err != nil {
+		respondResultError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	if cached, ok := h.statsCache.get(query, now); ok {
+		respondResultJSON(w, cached, http.StatusOK)
+		return
+	}
+	buckets, err := h.store.Stats(r.Context(), query)
+	if err != nil {
+		msg, code := mapGetStoreError(err)
+		respondResultError(w, msg, code)
+		return
+	}
+	response := statsResponse{
+		GroupBy:     query.Period,
+		ByServer:    query.ByServer,
+		GeneratedAt: now,
+		Buckets:     buckets,
+	}
+	h.statsCache.put(query, response, now)
+	respondResultJSON(w, response, http.StatusOK)
+}
+
+// parseStatsQuery normalizes the query so equivalent requests share a
+// cache entry.
+func parseStatsQuery(values url.Values) (results.StatsQuery, error) {
+	var (
+		q   results.StatsQuery
+		err error
+	)
+	if q.From, err = parseQueryTime(values, "from"); err != nil {
+		return q, err
+	}
+	if q.To, err = parseQueryTime(values, "to"); err != nil {
+		return q, err
+	}
+	q.From, q.To = q.From.UTC(), q.To.UTC()
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return q, errors.New("from must be before to")
+	}
+	q.ServerName = values.Get("server")
+	switch period := results.StatsPeriod(values.Get("group_by")); period {
+	case results.StatsPeriodNone, results.StatsPeriodHour, results.StatsPeriodDay, results.StatsPeriodWeek:
+		q.Period = period
+	default:
+		return q, errors.New("group_by must be hour, day or week")
+	}
+	if raw := values.Get("by_server"); raw != "" {
+		if q.ByServer, err = strconv.ParseBool(raw); err != nil {
+			return q, errors.New("by_server must be a boolean")
+		}
+	}
+	return q, nil
+}
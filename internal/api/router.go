@@ -2,11 +2,15 @@ package api
 
 import (
 	"log/slog"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/saveenergy/openbyte/internal/accesslist"
+	"github.com/saveenergy/openbyte/internal/apikeys"
 	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/geoip"
 	"github.com/saveenergy/openbyte/internal/results"
 	"github.com/saveenergy/openbyte/web"
 )
@@ -19,12 +23,15 @@ type Router struct {
 	privacyURL       string
 	speedtest        *SpeedTestHandler
 	resultsHandler   *resultHandler
-	limiter          *RateLimiter
+	limiters         map[string]*RateLimiter
 	clientIPResolver *ClientIPResolver
+	accessList       *accesslist.List
+	apiKeys          *apikeys.Store
+	geoIP            *geoip.DB
 	webFS            http.FileSystem
 }
 
-func NewRouter(cfg *config.Config, resultsStore *results.Store) *Router {
+func NewRouter(cfg *config.Config, resultsStore results.Storage) *Router {
 	if cfg == nil {
 		cfg = config.DefaultConfig()
 	}
@@ -33,6 +40,8 @@ func NewRouter(cfg *config.Config, resultsStore *results.Store) *Router {
 	maxDur := max(1, int(cfg.MaxTestDuration/time.Second))
 	resolver := NewClientIPResolver(cfg)
 	speedtest := NewSpeedTestHandlerWithPolicy(cfg.MaxConcurrentTransfers, maxDur, cfg.MaxConcurrentPerIP, resolver)
+	speedtest.transferTokens = newTransferTokens(cfg)
+	speedtest.uploadFloor = newUploadThroughputFloor(cfg.UploadMinThroughputKbps, cfg.UploadThroughputGrace)
 
 	serverName := strings.TrimSpace(cfg.ServerName)
 	if serverName == "" {
@@ -45,18 +54,41 @@ func NewRouter(cfg *config.Config, resultsStore *results.Store) *Router {
 	palette, brandingConfigured := cfg.BrandPalette()
 	brandLogo := cfg.BrandLogo()
 	impressumConfigured := cfg.ImpressumURL != ""
-	return &Router{
+	resultsHandler := newResultHandler(resultsStore)
+	if resultsHandler != nil {
+		resultsHandler.statsCache = newStatsCache(cfg.StatsCacheTTL)
+		resultsHandler.cards = newResultCards(palette, brandLogo)
+		resultsHandler.plausibility = newPlausibilityPolicy(cfg, speedtest.observed, resolver.FromRequest)
+		resultsHandler.clientIP = resolver.FromRequest
+		if store, ok := resultsStore.(snapshotter); ok {
+			resultsHandler.snapshots = &snapshotTarget{store: store, dir: cfg.ResultBackupDir(), keep: cfg.BackupKeep}
+		}
+	}
+	router := &Router{
 		serverName:       serverName,
 		brandingCSS:      renderBrandingCSS(palette, brandingConfigured, len(brandLogo.Data) > 0, impressumConfigured),
 		brandLogo:        brandLogo,
 		impressumURL:     cfg.ImpressumURL,
 		privacyURL:       cfg.PrivacyURL,
 		speedtest:        speedtest,
-		resultsHandler:   newResultHandler(resultsStore),
-		limiter:          newRateLimiter(cfg, resolver),
+		resultsHandler:   resultsHandler,
+		limiters:         newRateLimiters(cfg, resolver),
 		clientIPResolver: resolver,
 		webFS:            webFS,
 	}
+	if resultsHandler != nil {
+		resultsHandler.origin = router.requestOrigin
+	}
+	return router
+}
+
+// SetGeoIP installs the databases that annotate bootstrap pings and saved
+// results with the client's network. Call it before SetupRoutes.
+func (r *Router) SetGeoIP(db *geoip.DB) {
+	r.geoIP = db
+	if r.resultsHandler != nil {
+		r.resultsHandler.geoIP = db
+	}
 }
 
 func (r *Router) SetupRoutes() http.Handler {
@@ -64,12 +96,23 @@ func (r *Router) SetupRoutes() http.Handler {
 	staticHandler := staticCacheMiddleware(newStaticAllowlistHandler(r.webFS))
 
 	if r.resultsHandler != nil {
-		mux.HandleFunc("POST "+apiV1Prefix+"/results", applyRateLimit(r.limiter, r.resultsHandler.save))
-		mux.HandleFunc("GET "+apiV1Prefix+"/results/{id}", applyRateLimit(r.limiter, r.resultsHandler.get))
+		mux.HandleFunc("POST "+apiV1Prefix+"/results", r.rateLimited(config.RateLimitPolicyResultsSave, r.resultsHandler.save))
+		mux.HandleFunc("GET "+apiV1Prefix+"/results/{id}", r.rateLimited(config.RateLimitPolicyResultsRead, r.resultsHandler.get))
+		mux.HandleFunc("GET "+apiV1Prefix+"/results/compare", r.rateLimited(config.RateLimitPolicyResultsRead, r.resultsHandler.compare))
+		mux.HandleFunc("DELETE "+apiV1Prefix+"/results/{id}", r.rateLimited(config.RateLimitPolicyResultsSave, r.resultsHandler.delete))
+		mux.HandleFunc("GET "+oEmbedPath, r.rateLimited(config.RateLimitPolicyResultsRead, r.oEmbed))
+		if r.apiKeys != nil {
+			mux.HandleFunc("GET "+adminPrefix+"/results", r.adminOnly(r.resultsHandler.list))
+			mux.HandleFunc("GET "+adminPrefix+"/stats", r.adminOnly(r.resultsHandler.stats))
+			if r.resultsHandler.snapshots != nil {
+				mux.HandleFunc("POST "+adminPrefix+"/snapshots", r.adminOnly(r.resultsHandler.snapshot))
+			}
+		}
 	}
 	mux.HandleFunc("GET "+apiV1Prefix+"/download", r.speedtest.Download)
 	mux.HandleFunc("POST "+apiV1Prefix+"/upload", r.speedtest.Upload)
-	mux.HandleFunc("GET "+apiV1Prefix+"/ping", r.ping)
+	mux.HandleFunc("GET "+apiV1Prefix+"/ping", allowAnyOrigin(r.rateLimited(config.RateLimitPolicyPing, r.ping)))
+	mux.HandleFunc("GET "+apiV1Prefix+"/connection", r.rateLimited(config.RateLimitPolicyPing, r.connection))
 
 	mux.HandleFunc("GET /health", r.HealthCheck)
 	mux.HandleFunc("GET "+brandingCSSPath, r.serveBrandingCSS)
@@ -87,19 +130,12 @@ func (r *Router) SetupRoutes() http.Handler {
 
 	if r.resultsHandler != nil {
 		resultsPageHandler := func(w http.ResponseWriter, req *http.Request) {
-			if !validResultID(req.PathValue("id")) {
-				http.NotFound(w, req)
-				return
-			}
-			staticReq := req.Clone(req.Context())
-			staticReq.URL.Path = "/" + resultsHTML
-			staticReq.URL.RawPath = ""
-			staticHandler.ServeHTTP(w, staticReq)
-		}
-		if r.limiter != nil {
-			resultsPageHandler = applyRateLimit(r.limiter, resultsPageHandler)
+			r.serveResultsPage(w, req, staticHandler)
 		}
-		mux.HandleFunc("GET /results/{id}", resultsPageHandler)
+		mux.HandleFunc("GET /results/{id}", r.rateLimited(config.RateLimitPolicyResultsPage, resultsPageHandler))
+		mux.HandleFunc("GET /results/{id}/card.svg", r.rateLimited(config.RateLimitPolicyResultsPage, r.resultsHandler.cardSVG))
+		mux.HandleFunc("GET /results/{id}/card.png", r.rateLimited(config.RateLimitPolicyResultsPage, r.resultsHandler.cardPNG))
+		mux.HandleFunc("GET /results/{id}/badge.svg", r.rateLimited(config.RateLimitPolicyResultsPage, r.resultsHandler.badge))
 	}
 	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		// The static allowlist intentionally cleans paths before resolving assets.
@@ -111,17 +147,29 @@ func (r *Router) SetupRoutes() http.Handler {
 		staticHandler.ServeHTTP(w, req)
 	}))
 
-	handler := rejectBodylessRequestBodies(mux)
+	handler := r.apiKeyMiddleware(mux)
+	handler = r.accessListMiddleware(handler)
+	handler = rejectBodylessRequestBodies(handler)
 	handler = SecurityHeadersMiddleware(handler)
 	return r.LoggingMiddleware(handler)
 }
 
 func (r *Router) ping(w http.ResponseWriter, req *http.Request) {
-	serverName := ""
-	if req.URL.RawQuery != "" && req.URL.Query().Get("meta") == "1" {
-		serverName = r.serverName
+	if req.URL.RawQuery == "" || req.URL.Query().Get("meta") != "1" {
+		r.speedtest.ping(w, req, nil)
+		return
+	}
+	meta := pingResponse{ServerName: r.serverName}
+	if r.resultsHandler != nil {
+		defaultRetention, maxRetention := r.resultsHandler.store.Retention()
+		meta.ResultRetentionDays = retentionDays(defaultRetention)
+		meta.ResultMaxRetentionDays = retentionDays(maxRetention)
+	}
+	if r.geoIP != nil {
+		network := r.geoIP.Lookup(net.ParseIP(r.resolveClientIP(req)))
+		meta.ASN, meta.ASOrganization, meta.Country = network.ASN, network.Organization, network.Country
 	}
-	r.speedtest.ping(w, req, serverName)
+	r.speedtest.ping(w, req, &meta)
 }
 
 func (r *Router) HealthCheck(w http.ResponseWriter, req *http.Request) {
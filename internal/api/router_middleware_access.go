@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/saveenergy/openbyte/internal/accesslist"
+	"github.com/saveenergy/openbyte/internal/httpbody"
+)
+
+const errForbidden = "forbidden"
+
+type limitExemptKey struct{}
+
+// SetAccessList installs the operator CIDR lists evaluated before routing.
+// Call it before SetupRoutes.
+func (r *Router) SetAccessList(list *accesslist.List) {
+	r.accessList = list
+}
+
+// accessListMiddleware rejects denylisted clients on every route and marks
+// allowlisted clients as exempt from rate and concurrency limits.
+func (r *Router) accessListMiddleware(next http.Handler) http.Handler {
+	if r.accessList == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch r.accessList.Check(net.ParseIP(r.resolveClientIP(req))) {
+		case accesslist.Denied:
+			httpbody.Abort(w, req)
+			w.Header().Set(headerCacheControl, valueNoStore)
+			if hasAPIPathPrefix(req.URL.Path) {
+				respondJSON(w, map[string]string{"error": errForbidden}, http.StatusForbidden)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		case accesslist.Allowed:
+			req = req.WithContext(withLimitExemption(req.Context()))
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func withLimitExemption(ctx context.Context) context.Context {
+	return context.WithValue(ctx, limitExemptKey{}, true)
+}
+
+// limitsExempt reports whether the request bypasses rate and concurrency limits.
+func limitsExempt(ctx context.Context) bool {
+	exempt, _ := ctx.Value(limitExemptKey{}).(bool)
+	return exempt
+}
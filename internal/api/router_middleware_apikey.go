@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/saveenergy/openbyte/internal/apikeys"
+	"github.com/saveenergy/openbyte/internal/httpbody"
+)
+
+const (
+	errInvalidAPIKey = "invalid API key"
+	bearerPrefix     = "Bearer "
+	apiKeyPrefix     = "obk_"
+)
+
+type apiKeyContextKey struct{}
+
+// SetAPIKeys enables API key authentication for automation clients. Call it
+// before SetupRoutes.
+func (r *Router) SetAPIKeys(store *apikeys.Store) {
+	r.apiKeys = store
+}
+
+// apiKeyMiddleware authenticates "Authorization: Bearer obk_..." requests.
+// Requests without an openByte key stay anonymous; presented keys that fail
+// authentication are rejected instead of silently downgraded.
+func (r *Router) apiKeyMiddleware(next http.Handler) http.Handler {
+	if r.apiKeys == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token, presented := bearerAPIKey(req)
+		if !presented {
+			next.ServeHTTP(w, req)
+			return
+		}
+		key, err := r.apiKeys.Authenticate(req.Context(), token)
+		if err != nil {
+			httpbody.DrainAndClose(w, req)
+			w.Header().Set(headerCacheControl, valueNoStore)
+			if errors.Is(err, apikeys.ErrInvalidKey) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="openbyte"`)
+				respondJSON(w, map[string]string{"error": errInvalidAPIKey}, http.StatusUnauthorized)
+				return
+			}
+			slog.Warn("API key lookup failed", "error", err)
+			respondJSON(w, map[string]string{"error": "API key store unavailable"}, http.StatusServiceUnavailable)
+			return
+		}
+		setRequestLogKeyID(req.Context(), key.ID)
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), apiKeyContextKey{}, &key)))
+	})
+}
+
+// adminOnly admits requests authenticated with an admin API key.
+func (r *Router) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		key := apiKeyFromContext(req.Context())
+		switch {
+		case key == nil:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="openbyte"`)
+			respondResultError(w, "admin API key required", http.StatusUnauthorized)
+		case !key.Admin:
+			respondResultError(w, "API key is not an admin key", http.StatusForbidden)
+		default:
+			next(w, req)
+		}
+	}
+}
+
+func bearerAPIKey(req *http.Request) (string, bool) {
+	token, ok := bearerToken(req)
+	return token, ok && strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// bearerToken returns the credentials of a Bearer Authorization header. The
+// scheme is case-insensitive (RFC 7235).
+func bearerToken(req *http.Request) (string, bool) {
+	auth := req.Header.Get("Authorization")
+	if len(auth) < len(bearerPrefix) || !strings.EqualFold(auth[:len(bearerPrefix)], bearerPrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(auth[len(bearerPrefix):]), true
+}
+
+// apiKeyFromContext returns the authenticated key, or nil for anonymous requests.
+func apiKeyFromContext(ctx context.Context) *apikeys.Key {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*apikeys.Key)
+	return key
+}
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"bytes"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -106,3 +109,20 @@ func TestShouldLogRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestLoggingMiddlewareRecordsAPIKeyID(t *testing.T) {
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+	defer slog.SetDefault(previous)
+
+	handler := (&Router{}).LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setRequestLogKeyID(r.Context(), "abc123abc123")
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/download", nil))
+
+	if !strings.Contains(logged.String(), "key_id=abc123abc123") {
+		t.Fatalf("request log = %q, want key_id", logged.String())
+	}
+}
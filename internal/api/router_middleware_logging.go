@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"time"
@@ -24,6 +25,20 @@ func hasUploadPathSuffix(path string) bool {
 	return lp >= uploadPathSuffixLen && path[lp-uploadPathSuffixLen:] == uploadPathSuffix
 }
 
+// requestLogInfo collects identity details that inner middleware discovers
+// after the logging middleware has already wrapped the request.
+type requestLogInfo struct {
+	keyID string
+}
+
+type requestLogInfoKey struct{}
+
+func setRequestLogKeyID(ctx context.Context, keyID string) {
+	if info, ok := ctx.Value(requestLogInfoKey{}).(*requestLogInfo); ok {
+		info.keyID = keyID
+	}
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -68,18 +83,23 @@ func (r *Router) LoggingMiddleware(next http.Handler) http.Handler {
 		if hasAPIPathPrefix(path) && !shouldSkipRequestLog(path) {
 			start := time.Now()
 			rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			info := &requestLogInfo{}
 
-			next.ServeHTTP(rw, req)
+			next.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), requestLogInfoKey{}, info)))
 
 			duration := time.Since(start)
 			if shouldLogRequest(path, rw.statusCode, duration) {
-				slog.Info("HTTP request",
+				attrs := []any{
 					"method", req.Method,
 					"path", path,
 					"status", rw.statusCode,
-					"duration_ms", float64(duration.Microseconds())/1000,
+					"duration_ms", float64(duration.Microseconds()) / 1000,
 					"ip", r.resolveClientIP(req),
-				)
+				}
+				if info.keyID != "" {
+					attrs = append(attrs, "key_id", info.keyID)
+				}
+				slog.Info("HTTP request", attrs...)
 			}
 		} else {
 			next.ServeHTTP(w, req)
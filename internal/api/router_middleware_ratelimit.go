@@ -1,16 +1,79 @@
 package api
 
-import "net/http"
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
 
-// applyRateLimit wraps a handler with rate limit checking.
+type rateLimitErrorResponse struct {
+	Error  string `json:"error"`
+	Policy string `json:"policy"`
+	Scope  string `json:"scope"`
+}
+
+// applyRateLimit wraps a handler with rate limit checking. Every response
+// advertises the policy using the IETF RateLimit-Policy and RateLimit fields.
 func applyRateLimit(limiter *RateLimiter, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ip := limiter.ClientIP(r)
-		if !limiter.Allow(ip) {
-			w.Header().Set(headerRetryAfter, retryAfterSec)
-			respondJSON(w, map[string]string{"error": errRateLimitExceeded}, http.StatusTooManyRequests)
+		if limitsExempt(r.Context()) {
+			next(w, r)
+			return
+		}
+		identity := limiter.ClientIP(r)
+		if key := apiKeyFromContext(r.Context()); key != nil {
+			// Keyed clients get their own bucket instead of sharing their IP's.
+			identity = "key:" + key.ID
+		}
+		decision := limiter.Take(identity)
+		setRateLimitHeaders(w.Header(), limiter, decision)
+		if !decision.Allowed {
+			w.Header().Set(headerRetryAfter, strconv.Itoa(max(ceilSeconds(decision.RetryAfter), 1)))
+			respondJSON(w, rateLimitErrorResponse{
+				Error:  errRateLimitExceeded,
+				Policy: limiter.Policy(),
+				Scope:  decision.Scope,
+			}, http.StatusTooManyRequests)
 			return
 		}
 		next(w, r)
 	}
 }
+
+// allowAnyOrigin opens a route to cross-origin callers before rate limiting
+// runs, so a 429 reaches the caller's script with its RateLimit and
+// Retry-After headers instead of surfacing as a network error.
+func allowAnyOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Expose-Headers",
+				headerRateLimitPolicy+", "+headerRateLimit+", "+headerRetryAfter)
+		}
+		next(w, r)
+	}
+}
+
+// rateLimited applies the named policy when it is enabled.
+func (r *Router) rateLimited(policy string, next http.HandlerFunc) http.HandlerFunc {
+	limiter := r.limiters[policy]
+	if limiter == nil {
+		return next
+	}
+	return applyRateLimit(limiter, next)
+}
+
+func setRateLimitHeaders(header http.Header, limiter *RateLimiter, decision RateLimitDecision) {
+	policy := strconv.Quote(limiter.Policy())
+	header.Set(headerRateLimitPolicy,
+		policy+";q="+strconv.Itoa(limiter.PerIPLimit())+";w="+strconv.Itoa(ceilSeconds(rateLimitWindow)))
+	header.Set(headerRateLimit,
+		policy+";r="+strconv.Itoa(decision.Remaining)+";t="+strconv.Itoa(ceilSeconds(decision.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
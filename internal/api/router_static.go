@@ -157,24 +157,31 @@ func encodingQuality(header, wanted string) (float64, bool) {
 		if !strings.EqualFold(strings.TrimSpace(fields[0]), wanted) {
 			continue
 		}
-		quality = 1
+		quality = qualityParam(fields[1:])
 		found = true
-		for _, param := range fields[1:] {
-			key, value, ok := strings.Cut(param, "=")
-			if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
-				continue
-			}
-			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
-			if err != nil || parsed < 0 || parsed > 1 {
-				quality = 0
-			} else {
-				quality = parsed
-			}
-		}
 	}
 	return quality, found
 }
 
+// qualityParam returns the q parameter of an Accept-style list element; a
+// missing q means 1 and a malformed one 0.
+func qualityParam(params []string) float64 {
+	quality := 1.0
+	for _, param := range params {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			quality = 0
+		} else {
+			quality = parsed
+		}
+	}
+	return quality
+}
+
 func isAllowedStaticAsset(name string) bool {
 	return embeddedStaticAssets[name]
 }
@@ -182,6 +189,7 @@ func isAllowedStaticAsset(name string) bool {
 // Extension-less aliases for embedded HTML pages ("/privacy" -> privacy.html).
 var staticCleanHTMLPaths = map[string]bool{
 	"results": true,
+	"compare": true,
 	"privacy": true,
 }
 
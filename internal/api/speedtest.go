@@ -19,6 +19,11 @@ type SpeedTestHandler struct {
 	uploadBufPool      sync.Pool
 	ipMu               sync.Mutex
 	activeByIP         map[string]*speedtestIPCounts
+	keyMu              sync.Mutex
+	keyUsage           map[string]*apiKeyUsage
+	transferTokens     *transferTokens
+	uploadFloor        uploadThroughputFloor
+	observed           *observedThroughput
 }
 
 type speedtestIPCounts struct {
@@ -61,6 +66,8 @@ func NewSpeedTestHandlerWithPolicy(maxConcurrent, maxDurationSec, maxConcurrentP
 		clientIPResolver:   resolver,
 		randomData:         make([]byte, speedtestRandomSize),
 		activeByIP:         make(map[string]*speedtestIPCounts),
+		keyUsage:           make(map[string]*apiKeyUsage),
+		observed:           newObservedThroughput(),
 		uploadBufPool: sync.Pool{
 			New: func() any { return newUploadBuffer() },
 		},
@@ -82,11 +89,17 @@ func (h *SpeedTestHandler) resolveClientIP(r *http.Request) string {
 	return h.clientIPResolver.FromRequest(r)
 }
 
-func (h *SpeedTestHandler) tryAcquireSpeedtestSlot(clientIP string, isDownload bool) bool {
+// tryAcquireSpeedtestSlot reserves a transfer slot. Exempt (allowlisted)
+// clients are counted but never rejected and skip per-IP tracking.
+func (h *SpeedTestHandler) tryAcquireSpeedtestSlot(clientIP string, isDownload, exempt bool) bool {
 	counter := &h.activeUploads
 	if isDownload {
 		counter = &h.activeDownloads
 	}
+	if exempt {
+		atomic.AddInt64(counter, 1)
+		return true
+	}
 	if atomic.AddInt64(counter, 1) > h.maxConcurrent {
 		atomic.AddInt64(counter, -1)
 		return false
@@ -98,13 +111,15 @@ func (h *SpeedTestHandler) tryAcquireSpeedtestSlot(clientIP string, isDownload b
 	return true
 }
 
-func (h *SpeedTestHandler) releaseSpeedtestSlot(clientIP string, isDownload bool) {
+func (h *SpeedTestHandler) releaseSpeedtestSlot(clientIP string, isDownload, exempt bool) {
 	if isDownload {
 		atomic.AddInt64(&h.activeDownloads, -1)
 	} else {
 		atomic.AddInt64(&h.activeUploads, -1)
 	}
-	h.releasePerIP(clientIP, isDownload)
+	if !exempt {
+		h.releasePerIP(clientIP, isDownload)
+	}
 }
 
 func (h *SpeedTestHandler) tryAcquirePerIP(clientIP string, isDownload bool) bool {
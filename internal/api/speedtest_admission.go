@@ -0,0 +1,166 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/apikeys"
+)
+
+// transferAdmission records which limits a transfer acquired so release
+// mirrors acquisition exactly.
+type transferAdmission struct {
+	clientIP       string
+	exempt         bool
+	key            *apikeys.Key
+	maxDurationSec int
+}
+
+// transferRejection is a refused transfer start. A zero code means admitted.
+type transferRejection struct {
+	msg        string
+	code       int
+	retryAfter time.Duration
+}
+
+// apiKeyUsage tracks one key's live transfers, start-rate bucket, and daily
+// quota. Usage is process-local and resets on restart.
+type apiKeyUsage struct {
+	downloads   int
+	uploads     int
+	startTokens int
+	startRefill time.Time
+	quotaDay    int64
+	quotaUsed   int
+}
+
+// admitTransfer applies allowlist exemption, API key policy, and the global
+// and per-IP concurrency limits, in that order.
+func (h *SpeedTestHandler) admitTransfer(r *http.Request, isDownload bool) (transferAdmission, transferRejection) {
+	admission := transferAdmission{
+		clientIP:       h.resolveClientIP(r),
+		exempt:         limitsExempt(r.Context()),
+		key:            apiKeyFromContext(r.Context()),
+		maxDurationSec: h.maxDurationSec,
+	}
+	if admission.key != nil && admission.key.Policy.MaxDuration > 0 {
+		admission.maxDurationSec = int(admission.key.Policy.MaxDuration / time.Second)
+	}
+	busy := transferRejection{msg: "too many concurrent uploads", code: http.StatusServiceUnavailable}
+	if isDownload {
+		busy.msg = "too many concurrent downloads"
+	}
+	switch {
+	case admission.exempt:
+		h.tryAcquireSpeedtestSlot(admission.clientIP, isDownload, true)
+	case admission.key != nil:
+		if rejection := h.tryAcquireAPIKeySlot(admission.key, isDownload, time.Now()); rejection.code != 0 {
+			if rejection.msg == "" {
+				rejection.msg = busy.msg
+			}
+			return admission, rejection
+		}
+	default:
+		if !h.tryAcquireSpeedtestSlot(admission.clientIP, isDownload, false) {
+			return admission, busy
+		}
+	}
+	return admission, transferRejection{}
+}
+
+func (h *SpeedTestHandler) releaseTransfer(admission transferAdmission, isDownload bool) {
+	if admission.key != nil && !admission.exempt {
+		h.releaseAPIKeySlot(admission.key, isDownload)
+		return
+	}
+	h.releaseSpeedtestSlot(admission.clientIP, isDownload, admission.exempt)
+}
+
+// tryAcquireAPIKeySlot admits a keyed transfer. Keys replace the per-IP
+// concurrency limit with their own but still share the server-wide limit.
+// Start-rate tokens and quota are consumed only when the transfer starts.
+func (h *SpeedTestHandler) tryAcquireAPIKeySlot(key *apikeys.Key, isDownload bool, now time.Time) transferRejection {
+	h.keyMu.Lock()
+	defer h.keyMu.Unlock()
+	usage := h.keyUsage[key.ID]
+	if usage == nil {
+		usage = &apiKeyUsage{startTokens: key.Policy.StartRate, startRefill: now}
+		h.keyUsage[key.ID] = usage
+	}
+
+	limit := key.Policy.MaxConcurrent
+	if limit <= 0 {
+		limit = h.maxConcurrentPerIP
+	}
+	active := usage.uploads
+	if isDownload {
+		active = usage.downloads
+	}
+	if limit > 0 && active >= limit {
+		return transferRejection{code: http.StatusServiceUnavailable}
+	}
+
+	if rate := key.Policy.StartRate; rate > 0 {
+		refillTokens(&usage.startTokens, &usage.startRefill, rate, now)
+		if usage.startTokens <= 0 {
+			return transferRejection{
+				msg:        "API key start rate exceeded",
+				code:       http.StatusTooManyRequests,
+				retryAfter: untilNextToken(usage.startRefill, rate, now),
+			}
+		}
+	}
+
+	day := now.UTC().Unix() / int64(24*time.Hour/time.Second)
+	if usage.quotaDay != day {
+		usage.quotaDay = day
+		usage.quotaUsed = 0
+	}
+	if quota := key.Policy.DailyQuota; quota > 0 && usage.quotaUsed >= quota {
+		nextDay := time.Unix((day+1)*int64(24*time.Hour/time.Second), 0)
+		return transferRejection{
+			msg:        "API key daily quota exceeded",
+			code:       http.StatusTooManyRequests,
+			retryAfter: nextDay.Sub(now),
+		}
+	}
+
+	if !h.tryAcquireSpeedtestSlot("", isDownload, false) {
+		return transferRejection{code: http.StatusServiceUnavailable}
+	}
+	if key.Policy.StartRate > 0 {
+		usage.startTokens--
+	}
+	usage.quotaUsed++
+	if isDownload {
+		usage.downloads++
+	} else {
+		usage.uploads++
+	}
+	return transferRejection{}
+}
+
+func (h *SpeedTestHandler) releaseAPIKeySlot(key *apikeys.Key, isDownload bool) {
+	h.releaseSpeedtestSlot("", isDownload, false)
+	h.keyMu.Lock()
+	defer h.keyMu.Unlock()
+	usage := h.keyUsage[key.ID]
+	if usage == nil {
+		return
+	}
+	if isDownload {
+		if usage.downloads > 0 {
+			usage.downloads--
+		}
+	} else if usage.uploads > 0 {
+		usage.uploads--
+	}
+}
+
+func respondTransferRejection(w http.ResponseWriter, rejection transferRejection) {
+	if rejection.retryAfter > 0 {
+		w.Header().Set(headerRetryAfter, strconv.Itoa(max(ceilSeconds(rejection.retryAfter), 1)))
+	}
+	respondSpeedtestError(w, rejection.msg, rejection.code)
+}
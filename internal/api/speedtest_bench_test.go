@@ -43,9 +43,9 @@ func BenchmarkReadUploadBody(b *testing.B) {
 	b.ResetTimer()
 	for range b.N {
 		body := bytes.NewReader(data)
-		n, failed := readUploadBody(ctx, body, nil, deadline, pool)
-		if failed || n != bodySize {
-			b.Fatalf("readUploadBody: n=%d failed=%v", n, failed)
+		n, outcome := readUploadBody(ctx, body, nil, deadline, pool, time.Now(), uploadThroughputFloor{})
+		if outcome != uploadReadDone || n != bodySize {
+			b.Fatalf("readUploadBody: n=%d outcome=%v", n, outcome)
 		}
 	}
 }
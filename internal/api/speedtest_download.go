@@ -6,7 +6,9 @@ import (
 	"time"
 )
 
-func streamDownload(w http.ResponseWriter, r *http.Request, randomSource []byte, chunkSize int, duration time.Duration) {
+// streamDownload writes random data for duration and returns how many bytes
+// were written.
+func streamDownload(w http.ResponseWriter, r *http.Request, randomSource []byte, chunkSize int, duration time.Duration) int64 {
 	flusher, canFlush := w.(http.Flusher)
 	streamDeadline := time.Now().Add(duration)
 	writeDeadline := streamDeadline.Add(speedtestCloseGrace)
@@ -14,6 +16,7 @@ func streamDownload(w http.ResponseWriter, r *http.Request, randomSource []byte,
 	writeCount := 0
 	flushInterval := 8
 	offset := 0
+	var written int64
 	var nextDeadlineRefresh time.Time
 
 	for {
@@ -22,15 +25,16 @@ func streamDownload(w http.ResponseWriter, r *http.Request, randomSource []byte,
 			break
 		}
 		if r.Context().Err() != nil {
-			return
+			return written
 		}
 		if !now.Before(nextDeadlineRefresh) {
 			_ = refreshWriteDeadline(controller, writeDeadline)
 			nextDeadlineRefresh = now.Add(speedtestDeadlineRefreshPeriod)
 		}
 		if writeChunkFromSource(w, randomSource, chunkSize, &offset) != nil {
-			return
+			return written
 		}
+		written += int64(chunkSize)
 		writeCount++
 		if canFlush && writeCount%flushInterval == 0 {
 			flusher.Flush()
@@ -40,6 +44,7 @@ func streamDownload(w http.ResponseWriter, r *http.Request, randomSource []byte,
 		_ = refreshWriteDeadline(controller, writeDeadline)
 		flusher.Flush()
 	}
+	return written
 }
 
 func writeChunkFromSource(w http.ResponseWriter, source []byte, chunkSize int, offset *int) error {
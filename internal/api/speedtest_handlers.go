@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -10,19 +11,25 @@ import (
 	"github.com/saveenergy/openbyte/internal/httpbody"
 )
 
+const errUploadTooSlow = "upload below minimum throughput"
+
 func respondSpeedtestError(w http.ResponseWriter, msg string, code int) {
 	respondJSON(w, map[string]string{"error": msg}, code)
 }
 
 func (h *SpeedTestHandler) Download(w http.ResponseWriter, r *http.Request) {
-	clientIP := h.resolveClientIP(r)
-	if !h.tryAcquireSpeedtestSlot(clientIP, true) {
-		respondSpeedtestError(w, "too many concurrent downloads", http.StatusServiceUnavailable)
+	if !h.transferAuthorized(r) {
+		respondSpeedtestError(w, errTransferToken, http.StatusForbidden)
+		return
+	}
+	admission, rejection := h.admitTransfer(r, true)
+	if rejection.code != 0 {
+		respondTransferRejection(w, rejection)
 		return
 	}
-	defer h.releaseSpeedtestSlot(clientIP, true)
+	defer h.releaseTransfer(admission, true)
 
-	duration, chunkSize, parseErr := parseDownloadParams(r, h.maxDurationSec)
+	duration, chunkSize, parseErr := parseDownloadParams(r, admission.maxDurationSec)
 	if parseErr != nil {
 		respondSpeedtestError(w, parseErr.Error(), http.StatusBadRequest)
 		return
@@ -31,20 +38,27 @@ func (h *SpeedTestHandler) Download(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set(headerContentType, contentTypeOctetStream)
 	w.Header().Set(headerCacheControl, valueNoStore)
 
-	streamDownload(w, r, h.randomData, chunkSize, duration)
+	start := time.Now()
+	written := streamDownload(w, r, h.randomData, chunkSize, duration)
+	h.observed.record(admission.clientIP, true, start, time.Now(), written)
 }
 
 func (h *SpeedTestHandler) Upload(w http.ResponseWriter, r *http.Request) {
-	clientIP := h.resolveClientIP(r)
-	if !h.tryAcquireSpeedtestSlot(clientIP, false) {
+	if !h.transferAuthorized(r) {
 		httpbody.DrainAndClose(w, r)
-		respondSpeedtestError(w, "too many concurrent uploads", http.StatusServiceUnavailable)
+		respondSpeedtestError(w, errTransferToken, http.StatusForbidden)
 		return
 	}
-	defer h.releaseSpeedtestSlot(clientIP, false)
+	admission, rejection := h.admitTransfer(r, false)
+	if rejection.code != 0 {
+		httpbody.DrainAndClose(w, r)
+		respondTransferRejection(w, rejection)
+		return
+	}
+	defer h.releaseTransfer(admission, false)
 
 	startTime := time.Now()
-	deadline := uploadReadDeadline(startTime, h.maxDurationSec)
+	deadline := uploadReadDeadline(startTime, admission.maxDurationSec)
 	controller := http.NewResponseController(w)
 	_ = refreshReadDeadline(controller, deadline)
 	readCtx, cancel := context.WithDeadline(r.Context(), deadline)
@@ -59,12 +73,25 @@ func (h *SpeedTestHandler) Upload(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	totalBytes, readFailed := readUploadBody(readCtx, r.Body, controller, deadline, &h.uploadBufPool)
-	if readFailed {
+	totalBytes, outcome := readUploadBody(readCtx, r.Body, controller, deadline, &h.uploadBufPool, startTime, h.uploadFloor)
+	switch outcome {
+	case uploadReadFailed:
 		httpbody.Abort(w, r)
 		respondSpeedtestError(w, "upload failed", http.StatusInternalServerError)
 		return
+	case uploadReadTooSlow:
+		speedtestMetrics.Add(metricUploadThroughputAborts, 1)
+		slog.Warn("Upload aborted below minimum throughput",
+			"ip", admission.clientIP,
+			"bytes", totalBytes,
+			"elapsed_ms", time.Since(startTime).Milliseconds(),
+			"min_kbps", h.uploadFloor.bytesPerSec*8/1000,
+		)
+		httpbody.Abort(w, r)
+		respondSpeedtestError(w, errUploadTooSlow, http.StatusRequestTimeout)
+		return
 	}
+	h.observed.record(admission.clientIP, false, startTime, time.Now(), totalBytes)
 	if readCtx.Err() != nil || !time.Now().Before(deadline) {
 		httpbody.Abort(w, r)
 	} else {
@@ -75,21 +102,43 @@ func (h *SpeedTestHandler) Upload(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *SpeedTestHandler) Ping(w http.ResponseWriter, r *http.Request) {
-	h.ping(w, r, "")
+	h.ping(w, r, nil)
 }
 
 type pingResponse struct {
 	ClientIP   string `json:"client_ip"`
 	ServerName string `json:"server_name,omitempty"`
+	// TransferToken authorizes downloads and uploads when the server
+	// requires transfer tokens; it is only issued on same-origin bootstrap.
+	TransferToken          string `json:"transfer_token,omitempty"`
+	TransferTokenExpiresIn int    `json:"transfer_token_expires_in,omitempty"`
+	// ResultRetentionDays and ResultMaxRetentionDays describe shared-result
+	// lifetimes when the server stores results.
+	ResultRetentionDays    int `json:"result_retention_days,omitempty"`
+	ResultMaxRetentionDays int `json:"result_max_retention_days,omitempty"`
+	// ASN, ASOrganization, and Country describe the client's network when
+	// the server has GeoIP databases.
+	ASN            uint32 `json:"asn,omitempty"`
+	ASOrganization string `json:"as_org,omitempty"`
+	Country        string `json:"country,omitempty"`
+	// ClientAddress classifies ClientIP on bootstrap pings.
+	ClientAddress *clientAddress `json:"client_address,omitempty"`
 }
 
-func (h *SpeedTestHandler) ping(w http.ResponseWriter, r *http.Request, serverName string) {
+// ping answers with the client IP. A non-nil meta is the bootstrap metadata
+// to include; ping adds the per-client transfer token and the address
+// classification to it.
+func (h *SpeedTestHandler) ping(w http.ResponseWriter, r *http.Request, meta *pingResponse) {
 	w.Header().Set(headerCacheControl, valueNoStore)
 	if r.Header.Get("Origin") != "" {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 	}
-	respondJSON(w, pingResponse{
-		ClientIP:   h.resolveClientIP(r),
-		ServerName: serverName,
-	}, http.StatusOK)
+	var resp pingResponse
+	if meta != nil {
+		resp = *meta
+		resp.TransferToken, resp.TransferTokenExpiresIn = h.transferTokenFor(r)
+		resp.ClientAddress = h.clientIPResolver.classify(r)
+	}
+	resp.ClientIP = h.resolveClientIP(r)
+	respondJSON(w, resp, http.StatusOK)
 }
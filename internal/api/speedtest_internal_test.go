@@ -1,11 +1,16 @@
 package api
 
 import (
+	"context"
+	"expvar"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/saveenergy/openbyte/internal/apikeys"
 	"github.com/saveenergy/openbyte/internal/config"
 )
 
@@ -80,3 +85,173 @@ func TestNewRouterUsesExplicitTransferLimit(t *testing.T) {
 		t.Fatalf("max concurrent transfers = %d, want 73", got)
 	}
 }
+
+func TestExemptSpeedtestSlotsBypassConcurrencyLimits(t *testing.T) {
+	handler := NewSpeedTestHandlerWithPolicy(1, 60, 1, nil)
+
+	if !handler.tryAcquireSpeedtestSlot("192.0.2.1", true, false) {
+		t.Fatal("first download should acquire a slot")
+	}
+	if handler.tryAcquireSpeedtestSlot("192.0.2.2", true, false) {
+		t.Fatal("second client should hit the global limit")
+	}
+	if !handler.tryAcquireSpeedtestSlot("192.0.2.1", true, true) {
+		t.Fatal("exempt client should bypass global and per-IP limits")
+	}
+	if got := handler.activeDownloads; got != 2 {
+		t.Fatalf("active downloads = %d, want 2", got)
+	}
+
+	handler.releaseSpeedtestSlot("192.0.2.1", true, true)
+	if counts := handler.activeByIP["192.0.2.1"]; counts == nil || counts.downloads != 1 {
+		t.Fatalf("exempt release changed per-IP counts: %+v", counts)
+	}
+	handler.releaseSpeedtestSlot("192.0.2.1", true, false)
+	if got := handler.activeDownloads; got != 0 {
+		t.Fatalf("active downloads = %d, want 0", got)
+	}
+}
+
+func TestAPIKeySlotsApplyKeyPolicy(t *testing.T) {
+	handler := NewSpeedTestHandlerWithPolicy(10, 60, 1, nil)
+	key := &apikeys.Key{ID: "abc123abc123", Policy: apikeys.Policy{MaxConcurrent: 2, StartRate: 3}}
+	now := time.Now()
+
+	for i := range 2 {
+		if rejection := handler.tryAcquireAPIKeySlot(key, true, now); rejection.code != 0 {
+			t.Fatalf("download %d rejected: %+v", i, rejection)
+		}
+	}
+	if rejection := handler.tryAcquireAPIKeySlot(key, true, now); rejection.code != http.StatusServiceUnavailable {
+		t.Fatalf("third concurrent download = %+v, want 503", rejection)
+	}
+	if len(handler.activeByIP) != 0 {
+		t.Fatalf("keyed transfers should not use per-IP tracking: %v", handler.activeByIP)
+	}
+
+	handler.releaseAPIKeySlot(key, true)
+	if rejection := handler.tryAcquireAPIKeySlot(key, true, now); rejection.code != 0 {
+		t.Fatalf("third start rejected: %+v", rejection)
+	}
+	handler.releaseAPIKeySlot(key, true)
+	rejection := handler.tryAcquireAPIKeySlot(key, true, now)
+	if rejection.code != http.StatusTooManyRequests || rejection.retryAfter != 20*time.Second {
+		t.Fatalf("fourth start = %+v, want 429 after 20s", rejection)
+	}
+}
+
+func TestAPIKeyMaxDurationOverridesServerLimit(t *testing.T) {
+	handler := NewSpeedTestHandlerWithPolicy(10, 30, 4, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/download", nil)
+	key := &apikeys.Key{ID: "abc123abc123", Policy: apikeys.Policy{MaxDuration: 120 * time.Second}}
+	req = req.WithContext(context.WithValue(req.Context(), apiKeyContextKey{}, key))
+
+	admission, rejection := handler.admitTransfer(req, true)
+	if rejection.code != 0 {
+		t.Fatalf("admission rejected: %+v", rejection)
+	}
+	defer handler.releaseTransfer(admission, true)
+	if admission.maxDurationSec != 120 {
+		t.Fatalf("max duration = %d, want 120", admission.maxDurationSec)
+	}
+}
+
+func TestUploadThroughputFloor(t *testing.T) {
+	floor := newUploadThroughputFloor(8, 10*time.Second)
+
+	if floor.violated(0, 9*time.Second) {
+		t.Fatal("floor should not apply during the grace period")
+	}
+	if !floor.violated(9_999, 10*time.Second) {
+		t.Fatal("9999 bytes in 10s is below 8 kbit/s")
+	}
+	if floor.violated(10_000, 10*time.Second) {
+		t.Fatal("10000 bytes in 10s meets 8 kbit/s")
+	}
+	if newUploadThroughputFloor(0, time.Second).violated(0, time.Hour) {
+		t.Fatal("zero rate should disable the floor")
+	}
+}
+
+func TestUploadBelowThroughputFloorFreesSlot(t *testing.T) {
+	handler := NewSpeedTestHandlerWithPolicy(10, 60, 1, nil)
+	handler.uploadFloor = newUploadThroughputFloor(8, time.Second)
+	before := speedtestMetrics.Get(metricUploadThroughputAborts)
+
+	pr, pw := io.Pipe()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				_ = pw.Close()
+				return
+			case <-ticker.C:
+				if _, err := pw.Write([]byte{'x'}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/upload", pr)
+	rec := httptest.NewRecorder()
+	handler.Upload(rec, req)
+
+	if rec.Code != http.StatusRequestTimeout {
+		t.Fatalf("slow upload status = %d, want %d", rec.Code, http.StatusRequestTimeout)
+	}
+	if handler.activeUploads != 0 || len(handler.activeByIP) != 0 {
+		t.Fatalf("slow upload kept its slot: active=%d perIP=%v", handler.activeUploads, handler.activeByIP)
+	}
+	after, _ := speedtestMetrics.Get(metricUploadThroughputAborts).(*expvar.Int)
+	beforeValue := int64(0)
+	if counter, ok := before.(*expvar.Int); ok {
+		beforeValue = counter.Value()
+	}
+	if after == nil || after.Value() != beforeValue+1 {
+		t.Fatalf("throughput abort metric = %v, want %d", after, beforeValue+1)
+	}
+}
+
+func TestReadUploadBodyStopsBelowThroughputFloor(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	body := strings.NewReader("tiny")
+	n, outcome := readUploadBody(context.Background(), body, nil, time.Now().Add(time.Minute), nil, start, newUploadThroughputFloor(8, 10*time.Second))
+	if outcome != uploadReadTooSlow || n != 0 {
+		t.Fatalf("readUploadBody = %d, %v; want 0, too slow", n, outcome)
+	}
+}
+
+func TestObservedThroughputGroupsParallelTransfersIntoSessions(t *testing.T) {
+	o := newObservedThroughput()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	const ip = "192.0.2.1"
+	// Four parallel 10 s streams of 12.5 MB each: 40 Mbps together.
+	for i := range 4 {
+		start := base.Add(time.Duration(i) * 10 * time.Millisecond)
+		o.record(ip, true, start, start.Add(10*time.Second), 12_500_000)
+	}
+	if down, up := o.peak(ip, base.Add(time.Minute)); down < 39.5 || down > 40 || up != 0 {
+		t.Fatalf("peak = %.2f/%.2f, want about 40 down and nothing up", down, up)
+	}
+
+	// A slower later run starts a new session; the faster one stays the
+	// peak until it leaves the window.
+	later := base.Add(time.Minute)
+	o.record(ip, true, later, later.Add(10*time.Second), 12_500_000)
+	if down, _ := o.peak(ip, later.Add(10*time.Second)); down < 39.5 {
+		t.Fatalf("peak after slower run = %.2f, want the earlier 40", down)
+	}
+	if down, _ := o.peak(ip, base.Add(10*time.Second+observedWindow+time.Second)); down != 10 {
+		t.Fatalf("peak after window = %.2f, want the later 10", down)
+	}
+
+	// Sub-second transfers carry no meaningful rate.
+	o.record("192.0.2.2", false, base, base.Add(100*time.Millisecond), 1_000_000)
+	if _, up := o.peak("192.0.2.2", base); up != 0 {
+		t.Fatalf("short upload peak = %.2f, want none", up)
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// observedWindow is how long transfer rates are kept for checking the
+	// result a client saves after its test.
+	observedWindow = 15 * time.Minute
+	// observedSessionGap separates test runs: a transfer that starts longer
+	// than this after the previous one ended begins a new session.
+	observedSessionGap = 5 * time.Second
+	// observedMinDuration ignores sessions too short for a meaningful rate.
+	observedMinDuration = time.Second
+	observedMaxClients  = 10000
+)
+
+// transferSession is one test run in one direction: the span of a client's
+// overlapping transfers and the bytes they carried together, so parallel
+// streams add up to the rate the client saw.
+type transferSession struct {
+	start, end time.Time
+	bytes      int64
+}
+
+func (s transferSession) mbps() float64 {
+	elapsed := s.end.Sub(s.start)
+	if elapsed < observedMinDuration {
+		return 0
+	}
+	return float64(s.bytes) * 8 / elapsed.Seconds() / 1_000_000
+}
+
+// observedDirection keeps the current session and the fastest earlier one.
+type observedDirection struct {
+	current  transferSession
+	peakMbps float64
+	peakAt   time.Time
+}
+
+func (d *observedDirection) add(start, end time.Time, bytes int64) {
+	if d.current.end.IsZero() || start.After(d.current.end.Add(observedSessionGap)) {
+		d.foldCurrent()
+		d.current = transferSession{start: start, end: end, bytes: bytes}
+		return
+	}
+	if start.Before(d.current.start) {
+		d.current.start = start
+	}
+	if end.After(d.current.end) {
+		d.current.end = end
+	}
+	d.current.bytes += bytes
+}
+
+func (d *observedDirection) foldCurrent() {
+	rate := d.current.mbps()
+	if rate > 0 && (rate >= d.peakMbps || d.current.end.Sub(d.peakAt) > observedWindow) {
+		d.peakMbps, d.peakAt = rate, d.current.end
+	}
+}
+
+// best is the fastest session rate within the window, or zero.
+func (d *observedDirection) best(now time.Time) float64 {
+	var best float64
+	if now.Sub(d.current.end) <= observedWindow {
+		best = d.current.mbps()
+	}
+	if now.Sub(d.peakAt) <= observedWindow {
+		best = max(best, d.peakMbps)
+	}
+	return best
+}
+
+type observedClient struct {
+	download, upload observedDirection
+	lastSeen         time.Time
+}
+
+// observedThroughput remembers the rates the server carried per client, so
+// saved results can be checked against them.
+type observedThroughput struct {
+	mu      sync.Mutex
+	clients map[string]*observedClient
+}
+
+func newObservedThroughput() *observedThroughput {
+	return &observedThroughput{clients: make(map[string]*observedClient)}
+}
+
+// record adds a finished transfer. When the table is full of recent clients,
+// new clients are not tracked; their results stay unverified.
+func (o *observedThroughput) record(clientIP string, isDownload bool, start, end time.Time, bytes int64) {
+	if o == nil || clientIP == "" || bytes <= 0 {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	client := o.clients[clientIP]
+	if client == nil {
+		if len(o.clients) >= observedMaxClients {
+			o.pruneLocked(end)
+		}
+		if len(o.clients) >= observedMaxClients {
+			return
+		}
+		client = &observedClient{}
+		o.clients[clientIP] = client
+	}
+	if isDownload {
+		client.download.add(start, end, bytes)
+	} else {
+		client.upload.add(start, end, bytes)
+	}
+	if end.After(client.lastSeen) {
+		client.lastSeen = end
+	}
+}
+
+func (o *observedThroughput) pruneLocked(now time.Time) {
+	for ip, client := range o.clients {
+		if now.Sub(client.lastSeen) > observedWindow {
+			delete(o.clients, ip)
+		}
+	}
+}
+
+// peak returns the fastest recent download and upload rates observed for
+// clientIP in Mbps; zero means none were observed.
+func (o *observedThroughput) peak(clientIP string, now time.Time) (download, upload float64) {
+	if o == nil || clientIP == "" {
+		return 0, 0
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	client := o.clients[clientIP]
+	if client == nil {
+		return 0, 0
+	}
+	return client.download.best(now), client.upload.best(now)
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/config"
+)
+
+const (
+	transferTokenParam = "token"
+	errTransferToken   = "valid transfer token required"
+	// ephemeralTransferKeyID names the per-process key used when no shared
+	// keys are configured; tokens then stop verifying after a restart.
+	ephemeralTransferKeyID = "local"
+)
+
+// transferTokens signs and verifies the short-lived tokens that authorize
+// download and upload starts. A token is bound to the client IP it was
+// issued to, so a third-party page cannot relay tokens to its own visitors.
+//
+// Format: <key-id>.<unix-expiry>.<base64url HMAC-SHA256>.
+type transferTokens struct {
+	keys []config.TransferTokenKey
+	ttl  time.Duration
+}
+
+// newTransferTokens returns nil unless REQUIRE_TRANSFER_TOKENS is set.
+func newTransferTokens(cfg *config.Config) *transferTokens {
+	if !cfg.TransferTokensRequired {
+		return nil
+	}
+	keys := cfg.TransferTokenKeys
+	if len(keys) == 0 {
+		secret := make([]byte, sha256.Size)
+		rand.Read(secret)
+		keys = []config.TransferTokenKey{{ID: ephemeralTransferKeyID, Secret: secret}}
+		slog.Info("Transfer tokens use a per-process key; set TRANSFER_TOKEN_KEYS to share keys across instances")
+	}
+	return &transferTokens{keys: keys, ttl: cfg.TransferTokenTTL}
+}
+
+// issue signs a token for clientIP with the first configured key.
+func (t *transferTokens) issue(clientIP string, now time.Time) (string, time.Time) {
+	key := t.keys[0]
+	// Round up so the token lives at least the advertised TTL.
+	expires := now.Add(t.ttl + time.Second - 1).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return key.ID + "." + exp + "." + transferTokenMAC(key.Secret, key.ID, exp, clientIP), expires
+}
+
+// verify accepts unexpired tokens signed by any configured key for clientIP.
+func (t *transferTokens) verify(token, clientIP string, now time.Time) bool {
+	keyID, rest, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	exp, mac, ok := strings.Cut(rest, ".")
+	if !ok {
+		return false
+	}
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() >= expUnix || expUnix > now.Add(t.ttl).Unix()+1 {
+		return false
+	}
+	for _, key := range t.keys {
+		if key.ID == keyID {
+			return hmac.Equal([]byte(mac), []byte(transferTokenMAC(key.Secret, keyID, exp, clientIP)))
+		}
+	}
+	return false
+}
+
+func transferTokenMAC(secret []byte, keyID, exp, clientIP string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("openbyte-transfer\x00" + keyID + "\x00" + exp + "\x00" + clientIP))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// transferAuthorized reports whether r may start a transfer. API key holders
+// authenticate separately and do not need a token.
+func (h *SpeedTestHandler) transferAuthorized(r *http.Request) bool {
+	if h.transferTokens == nil || apiKeyFromContext(r.Context()) != nil {
+		return true
+	}
+	if r.URL.RawQuery == "" {
+		return false
+	}
+	token := r.URL.Query().Get(transferTokenParam)
+	return token != "" && h.transferTokens.verify(token, h.resolveClientIP(r), time.Now())
+}
+
+// transferTokenFor issues a token only to same-origin page loads. Ping allows
+// any origin for address-family probes, so cross-site callers must not be
+// able to read a token from it.
+func (h *SpeedTestHandler) transferTokenFor(r *http.Request) (string, int) {
+	if h.transferTokens == nil || !sameOriginRequest(r) {
+		return "", 0
+	}
+	token, _ := h.transferTokens.issue(h.resolveClientIP(r), time.Now())
+	return token, int(h.transferTokens.ttl / time.Second)
+}
+
+// sameOriginRequest rejects requests the browser marks as cross-origin.
+// Non-browser clients send neither header and are bound by the client IP.
+func sameOriginRequest(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(parsed.Host, r.Host)
+}
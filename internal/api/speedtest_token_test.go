@@ -0,0 +1,36 @@
+package api
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/config"
+)
+
+func TestTransferTokenExpiry(t *testing.T) {
+	tokens := &transferTokens{
+		keys: []config.TransferTokenKey{{ID: "k1", Secret: bytes.Repeat([]byte{7}, 32)}},
+		ttl:  time.Minute,
+	}
+	issuedAt := time.Unix(1_800_000_000, 0)
+	token, expires := tokens.issue("192.0.2.1", issuedAt)
+
+	if !expires.Equal(issuedAt.Add(time.Minute)) {
+		t.Fatalf("expires = %v, want %v", expires, issuedAt.Add(time.Minute))
+	}
+	if !tokens.verify(token, "192.0.2.1", issuedAt.Add(59*time.Second)) {
+		t.Fatal("token should verify before expiry")
+	}
+	if tokens.verify(token, "192.0.2.1", expires) {
+		t.Fatal("token should not verify at expiry")
+	}
+	if tokens.verify(token, "192.0.2.1", issuedAt.Add(-time.Hour)) {
+		t.Fatal("token expiring beyond the TTL should be rejected")
+	}
+	for _, malformed := range []string{"", "k1", "k1.", "k1.x.y", "k1..", ".1800000060."} {
+		if tokens.verify(malformed, "192.0.2.1", issuedAt) {
+			t.Fatalf("malformed token %q verified", malformed)
+		}
+	}
+}
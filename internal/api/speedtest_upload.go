@@ -15,6 +15,36 @@ type uploadResponse struct {
 	ThroughputMbps float64 `json:"throughput_mbps"`
 }
 
+// uploadThroughputFloor is the minimum average upload rate enforced once the
+// grace period has passed. A zero rate disables it.
+type uploadThroughputFloor struct {
+	bytesPerSec float64
+	grace       time.Duration
+}
+
+func newUploadThroughputFloor(kbps int, grace time.Duration) uploadThroughputFloor {
+	if kbps <= 0 {
+		return uploadThroughputFloor{}
+	}
+	return uploadThroughputFloor{bytesPerSec: float64(kbps) * 1000 / 8, grace: grace}
+}
+
+// violated reports whether totalBytes over elapsed falls below the floor.
+func (f uploadThroughputFloor) violated(totalBytes int64, elapsed time.Duration) bool {
+	if f.bytesPerSec <= 0 || elapsed < f.grace {
+		return false
+	}
+	return float64(totalBytes) < f.bytesPerSec*elapsed.Seconds()
+}
+
+type uploadReadOutcome int
+
+const (
+	uploadReadDone uploadReadOutcome = iota
+	uploadReadFailed
+	uploadReadTooSlow
+)
+
 func uploadReadDeadline(start time.Time, maxDurationSec int) time.Time {
 	if maxDurationSec <= 0 {
 		maxDurationSec = 300
@@ -28,7 +58,9 @@ func readUploadBody(
 	controller *http.ResponseController,
 	deadline time.Time,
 	pool *sync.Pool,
-) (totalBytes int64, readFailed bool) {
+	start time.Time,
+	floor uploadThroughputFloor,
+) (totalBytes int64, outcome uploadReadOutcome) {
 	bufPtr := getUploadBuf(pool)
 	buf := *bufPtr
 	if pool != nil {
@@ -38,12 +70,15 @@ func readUploadBody(
 	for {
 		select {
 		case <-readCtx.Done():
-			return totalBytes, false
+			return totalBytes, uploadReadDone
 		default:
 		}
 		now := time.Now()
 		if now.After(deadline) {
-			return totalBytes, false
+			return totalBytes, uploadReadDone
+		}
+		if floor.violated(totalBytes, now.Sub(start)) {
+			return totalBytes, uploadReadTooSlow
 		}
 		if controller != nil && !now.Before(nextDeadlineRefresh) {
 			_ = refreshReadDeadline(controller, deadline)
@@ -51,8 +86,11 @@ func readUploadBody(
 		}
 		n, err := body.Read(buf)
 		totalBytes += int64(n)
+		if errors.Is(err, io.EOF) {
+			return totalBytes, uploadReadDone
+		}
 		if err != nil {
-			return totalBytes, !errors.Is(err, io.EOF)
+			return totalBytes, uploadReadFailed
 		}
 	}
 }
@@ -0,0 +1,312 @@
+// Package apikeys stores API keys for automation clients.
+//
+// Keys are issued as "obk_<id>_<secret>". Only a SHA-256 digest of the secret
+// is persisted; the ID is public and identifies the client in logs and limits.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers sqlite driver used by sql.Open("sqlite", ...).
+)
+
+// FileName is the key database looked up inside DATA_DIR.
+const FileName = "apikeys.db"
+
+const (
+	tokenPrefix   = "obk_"
+	idBytes       = 6
+	secretBytes   = 32
+	cacheTTL      = 30 * time.Second
+	busyTimeoutMS = 5000
+
+	// MaxDuration bounds the per-key transfer duration override.
+	MaxDuration = time.Hour
+)
+
+var (
+	// ErrInvalidKey reports a malformed, unknown, or revoked key.
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrNotFound reports a revoke of an unknown key ID.
+	ErrNotFound = errors.New("API key not found")
+)
+
+// Policy holds the per-key limits. Zero values fall back to server defaults
+// (concurrency, duration) or disable the limit (start rate, quota).
+type Policy struct {
+	MaxConcurrent int           // concurrent transfers per direction
+	StartRate     int           // transfer starts per minute
+	DailyQuota    int           // transfer starts per UTC day
+	MaxDuration   time.Duration // whole seconds, at most MaxDuration
+}
+
+// Validate rejects negative limits and fractional or oversized durations.
+func (p Policy) Validate() error {
+	if p.MaxConcurrent < 0 || p.StartRate < 0 || p.DailyQuota < 0 {
+		return fmt.Errorf("API key limits must be >= 0")
+	}
+	if p.MaxDuration < 0 || p.MaxDuration%time.Second != 0 || p.MaxDuration > MaxDuration {
+		return fmt.Errorf("API key max duration must be whole seconds up to %s", MaxDuration)
+	}
+	return nil
+}
+
+// Key is a stored API key without its secret.
+type Key struct {
+	ID     string
+	Name   string
+	Policy Policy
+	// Admin keys may also call the /api/v1/admin endpoints.
+	Admin     bool
+	CreatedAt time.Time
+	RevokedAt time.Time
+}
+
+// Revoked reports whether the key may no longer authenticate.
+func (k Key) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+type storedKey struct {
+	Key
+	secretHash []byte
+}
+
+// Store persists keys in SQLite and serves authentication from an in-memory
+// snapshot refreshed every cacheTTL so CLI changes reach a running server.
+type Store struct {
+	db *sql.DB
+
+	mu       sync.Mutex
+	snapshot map[string]storedKey
+	loadedAt time.Time
+}
+
+// Open opens or creates the key database at path.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	for _, stmt := range []string{
+		fmt.Sprintf("PRAGMA busy_timeout=%d", busyTimeoutMS),
+		"PRAGMA journal_mode=WAL",
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			secret_hash BLOB NOT NULL,
+			max_concurrent INTEGER NOT NULL DEFAULT 0,
+			start_rate INTEGER NOT NULL DEFAULT 0,
+			daily_quota INTEGER NOT NULL DEFAULT 0,
+			max_duration_sec INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP,
+			admin INTEGER NOT NULL DEFAULT 0
+		)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("initialize API key store: %w", err)
+		}
+	}
+	if err := addAdminColumn(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize API key store: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// addAdminColumn upgrades key databases created before admin keys existed.
+func addAdminColumn(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `ALTER TABLE api_keys ADD COLUMN admin INTEGER NOT NULL DEFAULT 0`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *Store) Close() {
+	if err := s.db.Close(); err != nil {
+		slog.Warn("API key store: close failed", "error", err)
+	}
+}
+
+// Create issues a new key and returns it with the plaintext token, which is
+// not recoverable afterwards. Admin keys may also use the admin endpoints.
+func (s *Store) Create(ctx context.Context, name string, policy Policy, admin bool) (Key, string, error) {
+	if err := policy.Validate(); err != nil {
+		return Key{}, "", err
+	}
+	id, err := randomHex(idBytes)
+	if err != nil {
+		return Key{}, "", fmt.Errorf("generate key id: %w", err)
+	}
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, "", fmt.Errorf("generate key secret: %w", err)
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+	key := Key{ID: id, Name: name, Policy: policy, Admin: admin, CreatedAt: time.Now().UTC()}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO api_keys (id, name, secret_hash, max_concurrent, start_rate,
+			daily_quota, max_duration_sec, created_at, admin)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, hashSecret(encodedSecret), policy.MaxConcurrent, policy.StartRate,
+		policy.DailyQuota, int(policy.MaxDuration/time.Second), key.CreatedAt, admin,
+	)
+	if err != nil {
+		return Key{}, "", fmt.Errorf("insert API key: %w", err)
+	}
+	s.invalidate()
+	return key, tokenPrefix + id + "_" + encodedSecret, nil
+}
+
+// List returns every key, newest first, including revoked keys.
+func (s *Store) List(ctx context.Context) ([]Key, error) {
+	stored, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(stored))
+	for _, key := range stored {
+		keys = append(keys, key.Key)
+	}
+	return keys, nil
+}
+
+// Revoke disables a key immediately for new lookups in this process and
+// within cacheTTL for running servers.
+func (s *Store) Revoke(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("revoke API key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	s.invalidate()
+	return nil
+}
+
+// Authenticate resolves a presented token to its key. Unknown, malformed,
+// and revoked tokens all return ErrInvalidKey.
+func (s *Store) Authenticate(ctx context.Context, token string) (Key, error) {
+	id, secret, ok := parseToken(token)
+	if !ok {
+		return Key{}, ErrInvalidKey
+	}
+	snapshot, err := s.cached(ctx)
+	if err != nil {
+		return Key{}, err
+	}
+	stored, found := snapshot[id]
+	if !found || stored.Revoked() || subtle.ConstantTimeCompare(stored.secretHash, hashSecret(secret)) != 1 {
+		return Key{}, ErrInvalidKey
+	}
+	return stored.Key, nil
+}
+
+func (s *Store) cached(ctx context.Context) (map[string]storedKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snapshot != nil && time.Since(s.loadedAt) < cacheTTL {
+		return s.snapshot, nil
+	}
+	stored, err := s.load(ctx)
+	if err != nil {
+		if s.snapshot != nil {
+			slog.Warn("API key store: refresh failed; using cached keys", "error", err)
+			return s.snapshot, nil
+		}
+		return nil, err
+	}
+	snapshot := make(map[string]storedKey, len(stored))
+	for _, key := range stored {
+		snapshot[key.ID] = key
+	}
+	s.snapshot = snapshot
+	s.loadedAt = time.Now()
+	return snapshot, nil
+}
+
+func (s *Store) invalidate() {
+	s.mu.Lock()
+	s.snapshot = nil
+	s.mu.Unlock()
+}
+
+func (s *Store) load(ctx context.Context) ([]storedKey, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, secret_hash, max_concurrent, start_rate, daily_quota,
+			max_duration_sec, created_at, revoked_at, admin
+		FROM api_keys ORDER BY created_at DESC, id`)
+	if err != nil {
+		return nil, fmt.Errorf("query API keys: %w", err)
+	}
+	defer rows.Close()
+	var keys []storedKey
+	for rows.Next() {
+		var (
+			key         storedKey
+			durationSec int
+			revokedAt   sql.NullTime
+		)
+		if err := rows.Scan(&key.ID, &key.Name, &key.secretHash, &key.Policy.MaxConcurrent,
+			&key.Policy.StartRate, &key.Policy.DailyQuota, &durationSec, &key.CreatedAt, &revokedAt,
+			&key.Admin); err != nil {
+			return nil, fmt.Errorf("scan API key: %w", err)
+		}
+		key.Policy.MaxDuration = time.Duration(durationSec) * time.Second
+		if revokedAt.Valid {
+			key.RevokedAt = revokedAt.Time
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// parseToken splits "obk_<id>_<secret>".
+func parseToken(token string) (id, secret string, ok bool) {
+	rest, found := strings.CutPrefix(token, tokenPrefix)
+	if !found {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || len(id) != idBytes*2 || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
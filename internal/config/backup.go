@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultBackupKeep = 7
+	maxBackupKeep     = 1000
+	minBackupInterval = time.Minute
+
+	defaultReplicaSnapshotInterval = time.Hour
+)
+
+func (c *Config) loadBackupEnv() error {
+	if dir := os.Getenv("BACKUP_DIR"); dir != "" {
+		c.BackupDir = dir
+	}
+	if raw := os.Getenv("BACKUP_INTERVAL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid BACKUP_INTERVAL %q: must be a non-negative duration (e.g. 6h, 0 disables)", raw)
+		}
+		c.BackupInterval = d
+	}
+	if keep, ok, err := parsePositiveIntEnv("BACKUP_KEEP"); err != nil {
+		return err
+	} else if ok {
+		c.BackupKeep = keep
+	}
+	if dir := os.Getenv("REPLICA_DIR"); dir != "" {
+		c.ReplicaDir = dir
+	}
+	if raw := os.Getenv("REPLICA_SNAPSHOT_INTERVAL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid REPLICA_SNAPSHOT_INTERVAL %q: must be a positive duration (e.g. 1h)", raw)
+		}
+		c.ReplicaSnapshotInterval = d
+	}
+	return nil
+}
+
+// ResultBackupDir returns where results database snapshots are written,
+// DATA_DIR/backups unless BACKUP_DIR is set.
+func (c *Config) ResultBackupDir() string {
+	if c.BackupDir != "" {
+		return c.BackupDir
+	}
+	return filepath.Join(c.DataDir, "backups")
+}
+
+func (c *Config) validateBackup() error {
+	if c.BackupInterval < 0 || (c.BackupInterval > 0 && c.BackupInterval < minBackupInterval) {
+		return fmt.Errorf("backup interval must be 0 or at least %s", minBackupInterval)
+	}
+	if c.BackupInterval > 0 && c.ResultsBackend != ResultsBackendSQLite {
+		return fmt.Errorf("scheduled backups require the %s results backend", ResultsBackendSQLite)
+	}
+	if c.BackupKeep < 1 || c.BackupKeep > maxBackupKeep {
+		return fmt.Errorf("backup keep must be 1-%d snapshots", maxBackupKeep)
+	}
+	if c.ReplicaDir == "" {
+		return nil
+	}
+	if c.ResultsBackend != ResultsBackendSQLite {
+		return fmt.Errorf("replication requires the %s results backend", ResultsBackendSQLite)
+	}
+	if filepath.Clean(c.ReplicaDir) == filepath.Clean(c.DataDir) {
+		return fmt.Errorf("replica directory must differ from the data directory")
+	}
+	if c.ReplicaSnapshotInterval < minBackupInterval {
+		return fmt.Errorf("replica snapshot interval must be at least %s", minBackupInterval)
+	}
+	return nil
+}
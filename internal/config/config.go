@@ -4,6 +4,33 @@ import "time"
 
 const DefaultServerName = "openByte Server"
 
+// Results storage backends.
+const (
+	ResultsBackendSQLite = "sqlite"
+	ResultsBackendMemory = "memory"
+	ResultsBackendNDJSON = "ndjson"
+)
+
+// What happens to saved results faster than the server could have measured.
+const (
+	PlausibilityModeFlag   = "flag"
+	PlausibilityModeReject = "reject"
+)
+
+// Proxy headers that can carry the client address, named for
+// CLIENT_IP_HEADERS.
+const (
+	ClientIPHeaderForwarded     = "forwarded"
+	ClientIPHeaderXForwardedFor = "x-forwarded-for"
+	ClientIPHeaderXRealIP       = "x-real-ip"
+)
+
+// DefaultClientIPHeaders returns the proxy headers consulted when
+// CLIENT_IP_HEADERS is unset.
+func DefaultClientIPHeaders() []string {
+	return []string{ClientIPHeaderXForwardedFor, ClientIPHeaderXRealIP}
+}
+
 type Config struct {
 	Port        string
 	BindAddress string
@@ -25,13 +52,69 @@ type Config struct {
 	GlobalRateLimit        int
 	MaxConcurrentTransfers int
 	MaxConcurrentPerIP     int
+	// UploadMinThroughputKbps aborts uploads whose average rate stays below
+	// this many kbit/s after UploadThroughputGrace. Zero disables the floor.
+	UploadMinThroughputKbps int
+	UploadThroughputGrace   time.Duration
+	// RateLimitPolicies overrides the per-route budgets keyed by policy name.
+	RateLimitPolicies map[string]RateLimitPolicy
+
+	// TransferTokensRequired makes download and upload require a short-lived
+	// HMAC token issued by the bootstrap ping.
+	TransferTokensRequired bool
+	TransferTokenTTL       time.Duration
+	TransferTokenKeys      []TransferTokenKey
 
 	TrustProxyHeaders bool
 	TrustedProxyCIDRs []string
+	// ClientIPHeaders lists the proxy headers that may name the client, in
+	// precedence order. The first one present decides.
+	ClientIPHeaders []string
+	// ProxyProtocol reads PROXY protocol headers on connections from
+	// TrustedProxyCIDRs, so RemoteAddr is the client behind a layer 4
+	// load balancer.
+	ProxyProtocol bool
 
-	WebRoot          string
-	DataDir          string
+	WebRoot string
+	DataDir string
+	// ResultsBackend selects where shared results live: sqlite
+	// (DATA_DIR/results.db), memory, or ndjson (DATA_DIR/results.ndjson).
+	ResultsBackend   string
 	MaxStoredResults int
+	// ResultRetentionDays is how long a shared result lives unless the sharer
+	// asks for less. ResultMaxRetentionDays caps every result, including
+	// ones already stored; zero means the same as ResultRetentionDays.
+	ResultRetentionDays    int
+	ResultMaxRetentionDays int
+	// LinkCapacityMbps is the speed of the server's network link; saved
+	// results faster than it are implausible. Zero leaves it unchecked.
+	LinkCapacityMbps int
+	// PlausibilityMode is flag to store implausible results marked as such,
+	// or reject to refuse them.
+	PlausibilityMode string
+	// IPStorageMode is how saved results keep client addresses: full,
+	// truncate, hash (keyed with IPHashKey), or drop. IPAnonymizeAfterDays
+	// additionally drops addresses from older results; zero disables it.
+	IPStorageMode        string
+	IPHashKey            []byte
+	IPAnonymizeAfterDays int
+	// FieldEncryptionKeys enable AES-GCM encryption of personal result
+	// fields at rest when set.
+	FieldEncryptionKeys []FieldEncryptionKey
+	// StatsCacheTTL is how long admin aggregate statistics are reused. Zero
+	// disables the cache.
+	StatsCacheTTL time.Duration
+	// BackupInterval schedules SQLite snapshots of the results database into
+	// BackupDir (DATA_DIR/backups when empty), keeping the newest BackupKeep.
+	// Zero disables scheduled snapshots.
+	BackupDir      string
+	BackupInterval time.Duration
+	BackupKeep     int
+	// ReplicaDir, when set, receives a continuously updated replica of the
+	// results database, such as on a mounted network volume. Its base
+	// snapshot is refreshed every ReplicaSnapshotInterval.
+	ReplicaDir              string
+	ReplicaSnapshotInterval time.Duration
 
 	BrandPrimaryColorDark    string
 	BrandPrimaryColorLight   string
@@ -50,28 +133,48 @@ type Config struct {
 
 func DefaultConfig() *Config {
 	return &Config{
-		Port:                   "8080",
-		BindAddress:            "0.0.0.0",
-		ServerName:             DefaultServerName,
-		MaxTestDuration:        300 * time.Second,
-		PprofEnabled:           false,
-		PprofAddress:           "127.0.0.1:6060",
-		RateLimitPerIP:         100,
-		GlobalRateLimit:        1000,
-		MaxConcurrentTransfers: 200,
-		MaxConcurrentPerIP:     64,
-		TrustProxyHeaders:      false,
-		TrustedProxyCIDRs:      nil,
-		WebRoot:                "",
-		DataDir:                "./data",
-		MaxStoredResults:       10000,
-		TLSCertFile:            "",
-		TLSKeyFile:             "",
-		TLSAutoGen:             false,
-		HTTP2Enabled:           true,
+		Port:                    "8080",
+		BindAddress:             "0.0.0.0",
+		ServerName:              DefaultServerName,
+		MaxTestDuration:         300 * time.Second,
+		PprofEnabled:            false,
+		PprofAddress:            "127.0.0.1:6060",
+		RateLimitPerIP:          100,
+		GlobalRateLimit:         1000,
+		MaxConcurrentTransfers:  200,
+		MaxConcurrentPerIP:      64,
+		UploadMinThroughputKbps: 8,
+		UploadThroughputGrace:   10 * time.Second,
+		TransferTokenTTL:        defaultTransferTokenTTL,
+		TrustProxyHeaders:       false,
+		TrustedProxyCIDRs:       nil,
+		ClientIPHeaders:         DefaultClientIPHeaders(),
+		WebRoot:                 "",
+		DataDir:                 "./data",
+		ResultsBackend:          ResultsBackendSQLite,
+		MaxStoredResults:        10000,
+		ResultRetentionDays:     90,
+		PlausibilityMode:        PlausibilityModeFlag,
+		IPStorageMode:           IPStorageFull,
+		StatsCacheTTL:           5 * time.Minute,
+		BackupKeep:              defaultBackupKeep,
+		ReplicaSnapshotInterval: defaultReplicaSnapshotInterval,
+		TLSCertFile:             "",
+		TLSKeyFile:              "",
+		TLSAutoGen:              false,
+		HTTP2Enabled:            true,
 	}
 }
 
+// ResultRetention returns the default and maximum lifetime of shared results.
+func (c *Config) ResultRetention() (defaultRetention, maxRetention time.Duration) {
+	maxDays := c.ResultMaxRetentionDays
+	if maxDays == 0 {
+		maxDays = c.ResultRetentionDays
+	}
+	return time.Duration(c.ResultRetentionDays) * 24 * time.Hour, time.Duration(maxDays) * 24 * time.Hour
+}
+
 func (c *Config) LoadFromEnv() error {
 	if err := c.loadCoreEnv(); err != nil {
 		return err
@@ -80,9 +183,21 @@ func (c *Config) LoadFromEnv() error {
 	if err := c.loadLimitsAndNetworkEnv(); err != nil {
 		return err
 	}
+	if err := c.loadTransferTokenEnv(); err != nil {
+		return err
+	}
 	if err := c.loadStorageEnv(); err != nil {
 		return err
 	}
+	if err := c.loadIPPrivacyEnv(); err != nil {
+		return err
+	}
+	if err := c.loadFieldEncryptionEnv(); err != nil {
+		return err
+	}
+	if err := c.loadBackupEnv(); err != nil {
+		return err
+	}
 	c.loadTLSEnv()
 	return c.loadBrandingEnv()
 }
@@ -94,12 +209,24 @@ func (c *Config) Validate() error {
 	if err := c.validateLimits(); err != nil {
 		return err
 	}
+	if err := c.validateTransferTokens(); err != nil {
+		return err
+	}
 	if err := c.validateLegalURLs(); err != nil {
 		return err
 	}
 	if err := c.validateProxyAndStorage(); err != nil {
 		return err
 	}
+	if err := c.validateIPPrivacy(); err != nil {
+		return err
+	}
+	if err := c.validateFieldEncryption(); err != nil {
+		return err
+	}
+	if err := c.validateBackup(); err != nil {
+		return err
+	}
 	if err := c.validateTLS(); err != nil {
 		return err
 	}
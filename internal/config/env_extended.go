@@ -1,7 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func (c *Config) loadRuntimeEnv() {
@@ -32,10 +36,38 @@ func (c *Config) loadLimitsAndNetworkEnv() error {
 	} else if ok {
 		c.MaxConcurrentPerIP = limit
 	}
+	if raw := os.Getenv("UPLOAD_MIN_THROUGHPUT_KBPS"); raw != "" {
+		kbps, err := strconv.Atoi(raw)
+		if err != nil || kbps < 0 {
+			return fmt.Errorf("invalid UPLOAD_MIN_THROUGHPUT_KBPS %q: must be a non-negative integer", raw)
+		}
+		c.UploadMinThroughputKbps = kbps
+	}
+	if raw := os.Getenv("UPLOAD_THROUGHPUT_GRACE"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < time.Second {
+			return fmt.Errorf("invalid UPLOAD_THROUGHPUT_GRACE %q: must be a duration >= 1s (e.g. 10s)", raw)
+		}
+		c.UploadThroughputGrace = d
+	}
+	if entries := envCSV("RATE_LIMIT_POLICIES"); entries != nil {
+		policies, err := parseRateLimitPolicies(entries)
+		if err != nil {
+			return err
+		}
+		c.RateLimitPolicies = policies
+	}
 	c.TrustProxyHeaders = c.TrustProxyHeaders || envBool("TRUST_PROXY_HEADERS")
 	if cidrs := envCSV("TRUSTED_PROXY_CIDRS"); cidrs != nil {
 		c.TrustedProxyCIDRs = cidrs
 	}
+	c.ProxyProtocol = c.ProxyProtocol || envBool("PROXY_PROTOCOL")
+	if headers := envCSV("CLIENT_IP_HEADERS"); headers != nil {
+		for i, header := range headers {
+			headers[i] = strings.ToLower(header)
+		}
+		c.ClientIPHeaders = headers
+	}
 	if webRoot := os.Getenv("WEB_ROOT"); webRoot != "" {
 		c.WebRoot = webRoot
 	}
@@ -46,11 +78,41 @@ func (c *Config) loadStorageEnv() error {
 	if dataDir := os.Getenv("DATA_DIR"); dataDir != "" {
 		c.DataDir = dataDir
 	}
+	if backend := os.Getenv("RESULTS_BACKEND"); backend != "" {
+		c.ResultsBackend = strings.ToLower(strings.TrimSpace(backend))
+	}
 	if max, ok, err := parsePositiveIntEnv("MAX_STORED_RESULTS"); err != nil {
 		return err
 	} else if ok {
 		c.MaxStoredResults = max
 	}
+	if days, ok, err := parsePositiveIntEnv("RESULT_RETENTION_DAYS"); err != nil {
+		return err
+	} else if ok {
+		c.ResultRetentionDays = days
+	}
+	if days, ok, err := parsePositiveIntEnv("RESULT_MAX_RETENTION_DAYS"); err != nil {
+		return err
+	} else if ok {
+		c.ResultMaxRetentionDays = days
+	}
+	if raw := os.Getenv("LINK_CAPACITY_MBPS"); raw != "" {
+		mbps, err := strconv.Atoi(raw)
+		if err != nil || mbps < 0 {
+			return fmt.Errorf("invalid LINK_CAPACITY_MBPS %q: must be a non-negative integer", raw)
+		}
+		c.LinkCapacityMbps = mbps
+	}
+	if mode := os.Getenv("PLAUSIBILITY_MODE"); mode != "" {
+		c.PlausibilityMode = strings.ToLower(strings.TrimSpace(mode))
+	}
+	if raw := os.Getenv("STATS_CACHE_TTL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid STATS_CACHE_TTL %q: must be a non-negative duration (e.g. 5m, 0 disables)", raw)
+		}
+		c.StatsCacheTTL = d
+	}
 	return nil
 }
 
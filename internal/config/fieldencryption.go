@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const fieldEncryptionKeySize = 32
+
+// FieldEncryptionKey is one AES-256 key for personal result fields at rest.
+// The first configured key encrypts; every key decrypts.
+type FieldEncryptionKey struct {
+	ID  string
+	Key []byte
+}
+
+// loadFieldEncryptionEnv reads FIELD_ENCRYPTION_KEYS or, so keys can stay out
+// of the environment, FIELD_ENCRYPTION_KEY_FILE with one entry per line.
+func (c *Config) loadFieldEncryptionEnv() error {
+	entries := envCSV("FIELD_ENCRYPTION_KEYS")
+	source := "FIELD_ENCRYPTION_KEYS"
+	if path := os.Getenv("FIELD_ENCRYPTION_KEY_FILE"); path != "" {
+		if entries != nil {
+			return fmt.Errorf("set only one of FIELD_ENCRYPTION_KEYS and FIELD_ENCRYPTION_KEY_FILE")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read FIELD_ENCRYPTION_KEY_FILE: %w", err)
+		}
+		for line := range strings.Lines(string(data)) {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				entries = append(entries, line)
+			}
+		}
+		source = "FIELD_ENCRYPTION_KEY_FILE"
+	}
+	if entries == nil {
+		return nil
+	}
+	keys := make([]FieldEncryptionKey, 0, len(entries))
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return fmt.Errorf("invalid %s entry: want id:base64key", source)
+		}
+		key, err := decodeBase64Key(strings.TrimSpace(encoded))
+		if err != nil {
+			return fmt.Errorf("invalid %s key %q: %w", source, strings.TrimSpace(id), err)
+		}
+		keys = append(keys, FieldEncryptionKey{ID: strings.TrimSpace(id), Key: key})
+	}
+	c.FieldEncryptionKeys = keys
+	return nil
+}
+
+func (c *Config) validateFieldEncryption() error {
+	seen := make(map[string]bool, len(c.FieldEncryptionKeys))
+	for _, key := range c.FieldEncryptionKeys {
+		if !validKeyID(key.ID) {
+			return fmt.Errorf("field encryption key ID %q must be 1-%d letters, digits, or dashes", key.ID, maxKeyID)
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("duplicate field encryption key ID %q", key.ID)
+		}
+		seen[key.ID] = true
+		if len(key.Key) != fieldEncryptionKeySize {
+			return fmt.Errorf("field encryption key %q must be exactly %d bytes", key.ID, fieldEncryptionKeySize)
+		}
+	}
+	return nil
+}
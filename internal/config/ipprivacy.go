@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Stored-result IP modes; they mirror results.IPMode.
+const (
+	IPStorageFull     = "full"
+	IPStorageTruncate = "truncate"
+	IPStorageHash     = "hash"
+	IPStorageDrop     = "drop"
+
+	minIPHashKey = 32
+)
+
+func (c *Config) loadIPPrivacyEnv() error {
+	if mode := os.Getenv("IP_STORAGE_MODE"); mode != "" {
+		c.IPStorageMode = strings.ToLower(strings.TrimSpace(mode))
+	}
+	if encoded := os.Getenv("IP_HASH_KEY"); encoded != "" {
+		key, err := decodeBase64Key(strings.TrimSpace(encoded))
+		if err != nil {
+			return fmt.Errorf("invalid IP_HASH_KEY: %w", err)
+		}
+		c.IPHashKey = key
+	}
+	if days, ok, err := parsePositiveIntEnv("IP_ANONYMIZE_AFTER_DAYS"); err != nil {
+		return err
+	} else if ok {
+		c.IPAnonymizeAfterDays = days
+	}
+	return nil
+}
+
+// IPAnonymizeAfter returns how old a result gets before its addresses are
+// dropped, or zero to keep them for the result's lifetime.
+func (c *Config) IPAnonymizeAfter() time.Duration {
+	return time.Duration(c.IPAnonymizeAfterDays) * 24 * time.Hour
+}
+
+func (c *Config) validateIPPrivacy() error {
+	switch c.IPStorageMode {
+	case IPStorageFull, IPStorageTruncate, IPStorageDrop:
+	case IPStorageHash:
+		if len(c.IPHashKey) < minIPHashKey {
+			return fmt.Errorf("IP hash mode requires a key of at least %d bytes", minIPHashKey)
+		}
+	default:
+		return fmt.Errorf("IP storage mode must be one of %s, %s, %s, or %s",
+			IPStorageFull, IPStorageTruncate, IPStorageHash, IPStorageDrop)
+	}
+	if c.IPAnonymizeAfterDays < 0 || c.IPAnonymizeAfterDays > maxResultRetentionDays {
+		return fmt.Errorf("IP anonymization age must be 0-%d days", maxResultRetentionDays)
+	}
+	return nil
+}
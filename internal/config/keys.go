@@ -0,0 +1,37 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// maxKeyID is the longest ID accepted for a named key.
+const maxKeyID = 16
+
+// decodeBase64Key decodes a secret in standard or URL-safe base64, padded or
+// not.
+func decodeBase64Key(encoded string) ([]byte, error) {
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding,
+	} {
+		if key, err := encoding.DecodeString(encoded); err == nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("not valid base64")
+}
+
+// validKeyID reports whether id is 1-maxKeyID letters, digits, or dashes.
+func validKeyID(id string) bool {
+	if id == "" || len(id) > maxKeyID {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Named rate-limit policies. Each policy keeps its own per-IP and global
+// token buckets so one route cannot exhaust another route's budget.
+const (
+	RateLimitPolicyResultsSave = "results-save"
+	RateLimitPolicyResultsRead = "results-read"
+	RateLimitPolicyResultsPage = "results-page"
+	RateLimitPolicyPing        = "ping"
+)
+
+const rateLimitPolicyOff = "off"
+
+// RateLimitPolicy is a requests-per-minute budget. A zero PerIP disables the
+// policy so the route is not rate limited.
+type RateLimitPolicy struct {
+	PerIP  int
+	Global int
+}
+
+// Enabled reports whether requests under this policy consume tokens.
+func (p RateLimitPolicy) Enabled() bool {
+	return p.PerIP > 0
+}
+
+// RateLimitPolicyNames lists the policies in the order routes document them.
+func RateLimitPolicyNames() []string {
+	return []string{
+		RateLimitPolicyResultsSave,
+		RateLimitPolicyResultsRead,
+		RateLimitPolicyResultsPage,
+		RateLimitPolicyPing,
+	}
+}
+
+// RateLimitPolicy returns the effective budget for name. Result policies
+// inherit RATE_LIMIT_PER_IP and GLOBAL_RATE_LIMIT; ping stays disabled unless
+// configured because latency sampling issues several requests per second.
+func (c *Config) RateLimitPolicy(name string) RateLimitPolicy {
+	if policy, ok := c.RateLimitPolicies[name]; ok {
+		return policy
+	}
+	if name == RateLimitPolicyPing {
+		return RateLimitPolicy{}
+	}
+	return RateLimitPolicy{PerIP: c.RateLimitPerIP, Global: c.GlobalRateLimit}
+}
+
+func isRateLimitPolicyName(name string) bool {
+	for _, known := range RateLimitPolicyNames() {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRateLimitPolicies reads RATE_LIMIT_POLICIES entries of the form
+// name=PER_IP/GLOBAL or name=off.
+func parseRateLimitPolicies(entries []string) (map[string]RateLimitPolicy, error) {
+	policies := make(map[string]RateLimitPolicy, len(entries))
+	for _, entry := range entries {
+		name, budget, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		budget = strings.TrimSpace(budget)
+		if !ok || name == "" || budget == "" {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_POLICIES entry %q: want name=PER_IP/GLOBAL or name=off", entry)
+		}
+		if budget == rateLimitPolicyOff {
+			policies[name] = RateLimitPolicy{}
+			continue
+		}
+		perIPRaw, globalRaw, ok := strings.Cut(budget, "/")
+		perIP, perIPErr := strconv.Atoi(strings.TrimSpace(perIPRaw))
+		global, globalErr := strconv.Atoi(strings.TrimSpace(globalRaw))
+		if !ok || perIPErr != nil || globalErr != nil || perIP <= 0 || global <= 0 {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_POLICIES entry %q: budgets must be positive integers", entry)
+		}
+		policies[name] = RateLimitPolicy{PerIP: perIP, Global: global}
+	}
+	return policies, nil
+}
+
+func (c *Config) validateRateLimitPolicies() error {
+	for name, policy := range c.RateLimitPolicies {
+		if !isRateLimitPolicyName(name) {
+			return fmt.Errorf("unknown rate limit policy %q", name)
+		}
+		if !policy.Enabled() {
+			continue
+		}
+		if policy.Global <= 0 {
+			return fmt.Errorf("rate limit policy %q: global limit must be > 0", name)
+		}
+		if policy.Global < policy.PerIP {
+			return fmt.Errorf("rate limit policy %q: global limit must be >= per-IP limit", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTransferTokenTTL = 10 * time.Minute
+	minTransferTokenTTL     = time.Minute
+	maxTransferTokenTTL     = 24 * time.Hour
+	minTransferTokenSecret  = 32
+)
+
+// TransferTokenKey is one HMAC signing key for transfer tokens. The first
+// configured key signs; every key verifies, so operators rotate by prepending
+// a new key and dropping the old one after the token TTL has passed.
+type TransferTokenKey struct {
+	ID     string
+	Secret []byte
+}
+
+func (c *Config) loadTransferTokenEnv() error {
+	c.TransferTokensRequired = c.TransferTokensRequired || envBool("REQUIRE_TRANSFER_TOKENS")
+	if raw := os.Getenv("TRANSFER_TOKEN_TTL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid TRANSFER_TOKEN_TTL %q: %w", raw, err)
+		}
+		c.TransferTokenTTL = d
+	}
+	if entries := envCSV("TRANSFER_TOKEN_KEYS"); entries != nil {
+		keys, err := parseTransferTokenKeys(entries)
+		if err != nil {
+			return err
+		}
+		c.TransferTokenKeys = keys
+	}
+	return nil
+}
+
+// parseTransferTokenKeys reads "id:base64secret" entries.
+func parseTransferTokenKeys(entries []string) ([]TransferTokenKey, error) {
+	keys := make([]TransferTokenKey, 0, len(entries))
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid TRANSFER_TOKEN_KEYS entry %q: want id:base64secret", entry)
+		}
+		secret, err := decodeBase64Key(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRANSFER_TOKEN_KEYS secret for key %q: %w", id, err)
+		}
+		keys = append(keys, TransferTokenKey{ID: strings.TrimSpace(id), Secret: secret})
+	}
+	return keys, nil
+}
+
+func (c *Config) validateTransferTokens() error {
+	if c.TransferTokenTTL < minTransferTokenTTL || c.TransferTokenTTL > maxTransferTokenTTL {
+		return fmt.Errorf("transfer token TTL must be between %s and %s", minTransferTokenTTL, maxTransferTokenTTL)
+	}
+	seen := make(map[string]bool, len(c.TransferTokenKeys))
+	for _, key := range c.TransferTokenKeys {
+		if !validKeyID(key.ID) {
+			return fmt.Errorf("transfer token key ID %q must be 1-%d letters, digits, or dashes", key.ID, maxKeyID)
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("duplicate transfer token key ID %q", key.ID)
+		}
+		seen[key.ID] = true
+		if len(key.Secret) < minTransferTokenSecret {
+			return fmt.Errorf("transfer token key %q must be at least %d bytes", key.ID, minTransferTokenSecret)
+		}
+	}
+	return nil
+}
@@ -10,6 +10,9 @@ import (
 	"time"
 )
 
+// maxResultRetentionDays bounds result lifetimes to ten years.
+const maxResultRetentionDays = 3650
+
 func (c *Config) validatePorts() error {
 	if c.Port == "" {
 		return fmt.Errorf("port cannot be empty")
@@ -49,7 +52,13 @@ func (c *Config) validateLimits() error {
 	if c.MaxConcurrentPerIP <= 0 {
 		return fmt.Errorf("max concurrent per IP must be > 0")
 	}
-	return nil
+	if c.UploadMinThroughputKbps < 0 {
+		return fmt.Errorf("upload minimum throughput must be >= 0")
+	}
+	if c.UploadMinThroughputKbps > 0 && c.UploadThroughputGrace < time.Second {
+		return fmt.Errorf("upload throughput grace must be >= 1s")
+	}
+	return c.validateRateLimitPolicies()
 }
 
 func validateLegalURL(name, value string) error {
@@ -70,23 +79,68 @@ func (c *Config) validateLegalURLs() error {
 	return validateLegalURL("PRIVACY_URL", c.PrivacyURL)
 }
 
+// TrustedProxyNetworks parses TrustedProxyCIDRs, skipping blank entries.
+func (c *Config) TrustedProxyNetworks() ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(c.TrustedProxyCIDRs))
+	for _, entry := range c.TrustedProxyCIDRs {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR: %s", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
 func (c *Config) validateProxyAndStorage() error {
 	if c.DataDir == "" {
 		return fmt.Errorf("data directory cannot be empty")
 	}
+	switch c.ResultsBackend {
+	case ResultsBackendSQLite, ResultsBackendMemory, ResultsBackendNDJSON:
+	default:
+		return fmt.Errorf("results backend must be %s, %s, or %s",
+			ResultsBackendSQLite, ResultsBackendMemory, ResultsBackendNDJSON)
+	}
 	if c.MaxStoredResults <= 0 {
 		return fmt.Errorf("max stored results must be > 0")
 	}
-	if len(c.TrustedProxyCIDRs) > 0 {
-		for _, entry := range c.TrustedProxyCIDRs {
-			if _, _, err := net.ParseCIDR(entry); err != nil {
-				return fmt.Errorf("invalid trusted proxy CIDR: %s", entry)
-			}
-		}
+	if c.ResultRetentionDays < 1 || c.ResultRetentionDays > maxResultRetentionDays {
+		return fmt.Errorf("result retention must be 1-%d days", maxResultRetentionDays)
+	}
+	if c.ResultMaxRetentionDays < 0 || c.ResultMaxRetentionDays > maxResultRetentionDays {
+		return fmt.Errorf("result maximum retention must be 0-%d days", maxResultRetentionDays)
+	}
+	if c.ResultMaxRetentionDays > 0 && c.ResultRetentionDays > c.ResultMaxRetentionDays {
+		return fmt.Errorf("result retention must not exceed the maximum retention")
+	}
+	if c.LinkCapacityMbps < 0 {
+		return fmt.Errorf("link capacity must be >= 0")
+	}
+	switch c.PlausibilityMode {
+	case PlausibilityModeFlag, PlausibilityModeReject:
+	default:
+		return fmt.Errorf("plausibility mode must be %s or %s", PlausibilityModeFlag, PlausibilityModeReject)
+	}
+	if c.StatsCacheTTL < 0 {
+		return fmt.Errorf("stats cache TTL must be >= 0")
+	}
+	if _, err := c.TrustedProxyNetworks(); err != nil {
+		return err
 	}
 	if c.TrustProxyHeaders && len(c.TrustedProxyCIDRs) == 0 {
 		return fmt.Errorf("trusted proxy CIDRs required when trust proxy headers is enabled")
 	}
+	if c.ProxyProtocol && len(c.TrustedProxyCIDRs) == 0 {
+		return fmt.Errorf("trusted proxy CIDRs required when PROXY protocol is enabled")
+	}
+	if err := validateClientIPHeaders(c.ClientIPHeaders); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -104,3 +158,23 @@ func (c *Config) validateTLS() error {
 	}
 	return nil
 }
+
+func validateClientIPHeaders(headers []string) error {
+	if len(headers) == 0 {
+		return fmt.Errorf("client IP headers must name at least one header")
+	}
+	seen := make(map[string]bool, len(headers))
+	for _, header := range headers {
+		switch header {
+		case ClientIPHeaderForwarded, ClientIPHeaderXForwardedFor, ClientIPHeaderXRealIP:
+		default:
+			return fmt.Errorf("client IP header must be %s, %s, or %s: %q",
+				ClientIPHeaderForwarded, ClientIPHeaderXForwardedFor, ClientIPHeaderXRealIP, header)
+		}
+		if seen[header] {
+			return fmt.Errorf("client IP header listed twice: %s", header)
+		}
+		seen[header] = true
+	}
+	return nil
+}
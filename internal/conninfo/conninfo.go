@@ -0,0 +1,156 @@
+// Package conninfo records facts about the connection behind each request:
+// the connection itself, when it was accepted, and how long its TLS
+// handshake took.
+//
+// Install ConnContext (or a TLSListener's ConnContext) as the server's
+// ConnContext and read the facts back with FromContext.
+package conninfo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Info describes one client connection.
+type Info struct {
+	// Conn is the accepted connection; a *tls.Conn when TLS terminates here.
+	Conn     net.Conn
+	Accepted time.Time
+	// Handshake is how long the TLS handshake took, or zero when unknown.
+	Handshake time.Duration
+}
+
+type contextKey struct{}
+
+// ConnContext stores the connection's Info in ctx. Its signature matches
+// http.Server.ConnContext.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, contextKey{}, &Info{Conn: c, Accepted: time.Now()})
+}
+
+// FromContext returns the connection Info stored by ConnContext, or nil.
+func FromContext(ctx context.Context) *Info {
+	info, _ := ctx.Value(contextKey{}).(*Info)
+	return info
+}
+
+// TLSListener completes each TLS handshake before handing the connection to
+// the server, so the handshake can be timed. Connections that fail the
+// handshake are closed and never returned.
+type TLSListener struct {
+	net.Listener
+	config  *tls.Config
+	timeout time.Duration
+
+	ready     chan *tls.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu         sync.Mutex
+	handshakes map[*tls.Conn]handshake
+}
+
+type handshake struct {
+	accepted time.Time
+	duration time.Duration
+}
+
+// NewTLSListener wraps inner, handshaking with config and giving up on
+// clients that do not finish within timeout.
+func NewTLSListener(inner net.Listener, config *tls.Config, timeout time.Duration) *TLSListener {
+	l := &TLSListener{
+		Listener:   inner,
+		config:     config,
+		timeout:    timeout,
+		ready:      make(chan *tls.Conn),
+		done:       make(chan struct{}),
+		handshakes: make(map[*tls.Conn]handshake),
+	}
+	go l.acceptLoop()
+	return l
+}
+
+func (l *TLSListener) acceptLoop() {
+	var backoff time.Duration
+	for {
+		raw, err := l.Listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			l.closeOnce.Do(func() { close(l.done) })
+			return
+		}
+		if err != nil {
+			// Transient failures such as running out of file descriptors;
+			// back off like http.Server does.
+			backoff = min(max(2*backoff, 5*time.Millisecond), time.Second)
+			select {
+			case <-time.After(backoff):
+				continue
+			case <-l.done:
+				return
+			}
+		}
+		backoff = 0
+		go l.handshake(raw)
+	}
+}
+
+func (l *TLSListener) handshake(raw net.Conn) {
+	accepted := time.Now()
+	conn := tls.Server(raw, l.config)
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return
+	}
+	l.mu.Lock()
+	l.handshakes[conn] = handshake{accepted: accepted, duration: time.Since(accepted)}
+	l.mu.Unlock()
+	select {
+	case l.ready <- conn:
+	case <-l.done:
+		l.take(conn)
+		_ = conn.Close()
+	}
+}
+
+// Accept returns the next connection whose handshake completed.
+func (l *TLSListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.ready:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting; handshakes in progress are discarded.
+func (l *TLSListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+// ConnContext is ConnContext with the handshake duration filled in for
+// connections accepted by l.
+func (l *TLSListener) ConnContext(ctx context.Context, c net.Conn) context.Context {
+	ctx = ConnContext(ctx, c)
+	if conn, ok := c.(*tls.Conn); ok {
+		if h, ok := l.take(conn); ok {
+			info := FromContext(ctx)
+			info.Accepted, info.Handshake = h.accepted, h.duration
+		}
+	}
+	return ctx
+}
+
+func (l *TLSListener) take(conn *tls.Conn) (handshake, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.handshakes[conn]
+	delete(l.handshakes, conn)
+	return h, ok
+}
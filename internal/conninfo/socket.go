@@ -0,0 +1,37 @@
+package conninfo
+
+import (
+	"net"
+	"syscall"
+	"time"
+)
+
+// Socket holds kernel TCP statistics for a connection.
+type Socket struct {
+	// MSS is the maximum segment size the kernel sends with, in bytes.
+	MSS    uint32
+	RTT    time.Duration
+	RTTVar time.Duration
+}
+
+// SocketStats returns the kernel's TCP statistics for c. It reports false
+// on platforms without TCP_INFO and for connections that are not TCP.
+func SocketStats(c net.Conn) (Socket, bool) {
+	// Unwrap TLS and PROXY protocol connections down to the socket.
+	for {
+		wrapper, ok := c.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		c = wrapper.NetConn()
+	}
+	conn, ok := c.(syscall.Conn)
+	if !ok {
+		return Socket{}, false
+	}
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return Socket{}, false
+	}
+	return socketStats(raw)
+}
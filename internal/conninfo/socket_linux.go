@@ -0,0 +1,26 @@
+//go:build linux
+
+package conninfo
+
+import (
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func socketStats(raw syscall.RawConn) (Socket, bool) {
+	var info *unix.TCPInfo
+	var sockErr error
+	err := raw.Control(func(fd uintptr) {
+		info, sockErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil || sockErr != nil {
+		return Socket{}, false
+	}
+	return Socket{
+		MSS:    info.Snd_mss,
+		RTT:    time.Duration(info.Rtt) * time.Microsecond,
+		RTTVar: time.Duration(info.Rttvar) * time.Microsecond,
+	}, true
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package conninfo
+
+import "syscall"
+
+func socketStats(syscall.RawConn) (Socket, bool) {
+	return Socket{}, false
+}
@@ -0,0 +1,252 @@
+// Package geoip annotates client addresses with their autonomous system and
+// country from MaxMind DB (.mmdb) files, such as GeoLite2-ASN and
+// GeoLite2-Country, without network lookups.
+//
+// Every *.mmdb file in the directory is used; a missing file means no
+// enrichment so deployments opt in by adding one. When several files know
+// a field, the first by file name wins.
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pattern matches the database files looked up inside DATA_DIR.
+const Pattern = "*.mmdb"
+
+// Maximum accepted database size; larger files are rejected on load.
+const maxFileBytes = 512 << 20
+
+// Info is what the databases know about an address. Zero fields are
+// unknown.
+type Info struct {
+	ASN uint32
+	// Organization is the name registered for the autonomous system.
+	Organization string
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country string
+}
+
+func (i Info) complete() bool {
+	return i.ASN != 0 && i.Organization != "" && i.Country != ""
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+type database struct {
+	name   string
+	reader *reader
+}
+
+// DB is a hot-reloadable set of MaxMind DB files in one directory.
+type DB struct {
+	dir string
+
+	mu        sync.RWMutex
+	databases []database
+	// files is the directory listing of the last load attempt, so polling
+	// skips a failed version until it changes again.
+	files map[string]fileState
+}
+
+// Open loads the database files in dir. No files yield a DB that annotates
+// nothing; a malformed file is an error.
+func Open(dir string) (*DB, error) {
+	db := &DB{dir: dir}
+	if err := db.Reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Dir returns the directory holding the database files.
+func (db *DB) Dir() string {
+	return db.dir
+}
+
+// Reload re-reads every database file unconditionally. On error the
+// previous databases stay active.
+func (db *DB) Reload() error {
+	files, err := db.list()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	loaded := make([]database, 0, len(names))
+	for _, name := range names {
+		r, err := load(filepath.Join(db.dir, name), files[name].size)
+		if err != nil {
+			db.remember(files)
+			return fmt.Errorf("load GeoIP database %s: %w", name, err)
+		}
+		loaded = append(loaded, database{name: name, reader: r})
+	}
+	db.mu.Lock()
+	db.databases, db.files = loaded, files
+	db.mu.Unlock()
+	if len(loaded) > 0 {
+		types := make([]string, len(loaded))
+		for i, d := range loaded {
+			types[i] = d.name + " (" + d.reader.databaseType + ")"
+		}
+		slog.Info("GeoIP databases loaded", "dir", db.dir, "databases", strings.Join(types, ", "))
+	}
+	return nil
+}
+
+// ReloadIfChanged reloads when a database file appeared, disappeared, or
+// changed size or modification time since the last load attempt.
+func (db *DB) ReloadIfChanged() error {
+	files, err := db.list()
+	if err != nil {
+		return err
+	}
+	db.mu.RLock()
+	unchanged := len(files) == len(db.files)
+	for name, state := range files {
+		previous, ok := db.files[name]
+		if !ok || !previous.modTime.Equal(state.modTime) || previous.size != state.size {
+			unchanged = false
+			break
+		}
+	}
+	db.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	return db.Reload()
+}
+
+func (db *DB) list() (map[string]fileState, error) {
+	matches, err := filepath.Glob(filepath.Join(db.dir, Pattern))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]fileState, len(matches))
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stat GeoIP database: %w", err)
+		}
+		if info.Mode().IsRegular() {
+			files[filepath.Base(path)] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return files, nil
+}
+
+func (db *DB) remember(files map[string]fileState) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.files = files
+}
+
+func load(path string, size int64) (*reader, error) {
+	if size > maxFileBytes {
+		return nil, fmt.Errorf("exceeds %d bytes", maxFileBytes)
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newReader(buf)
+}
+
+// Lookup returns what the databases know about ip. A nil DB or address
+// yields an empty Info.
+func (db *DB) Lookup(ip net.IP) Info {
+	var info Info
+	if db == nil || ip == nil {
+		return info
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, d := range db.databases {
+		record, err := d.reader.lookup(ip)
+		if err != nil {
+			slog.Debug("GeoIP lookup failed", "database", d.name, "error", err)
+			continue
+		}
+		info.merge(record)
+		if info.complete() {
+			break
+		}
+	}
+	return info
+}
+
+// merge fills unknown fields from a record in the MaxMind GeoLite2 or
+// IPinfo layout.
+func (i *Info) merge(record map[string]any) {
+	if record == nil {
+		return
+	}
+	if i.ASN == 0 {
+		i.ASN = asnOf(record["autonomous_system_number"])
+		if i.ASN == 0 {
+			i.ASN = asnOf(record["asn"])
+		}
+	}
+	if i.Organization == "" {
+		i.Organization = firstString(record, "autonomous_system_organization", "as_name", "isp")
+	}
+	if i.Country == "" {
+		for _, key := range []string{"country", "registered_country"} {
+			if country, ok := record[key].(map[string]any); ok {
+				if code, _ := country["iso_code"].(string); code != "" {
+					i.Country = code
+					break
+				}
+			}
+		}
+		if i.Country == "" {
+			i.Country = firstString(record, "country_code")
+		}
+		i.Country = strings.ToUpper(i.Country)
+	}
+}
+
+// asnOf accepts a number or an "AS13335" string.
+func asnOf(v any) uint32 {
+	if n, ok := asUint(v); ok && n <= 0xffffffff {
+		return uint32(n)
+	}
+	if s, ok := v.(string); ok {
+		s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "AS")
+		if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+			return uint32(n)
+		}
+	}
+	return 0
+}
+
+func firstString(record map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, _ := record[key].(string); strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
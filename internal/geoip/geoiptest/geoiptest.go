@@ -0,0 +1,215 @@
+// Package geoiptest writes small MaxMind DB files for tests.
+package geoiptest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"math"
+	"net"
+	"os"
+	"sort"
+)
+
+// Network maps a CIDR to the record returned for addresses inside it.
+type Network struct {
+	CIDR   string
+	Record map[string]any
+}
+
+// Raw is written to the data section as is, for records a well-formed
+// encoder would not produce.
+type Raw []byte
+
+// WriteFile writes an IPv6 database with 24-bit records holding networks.
+// IPv4 networks are stored under ::/96. Networks must not nest.
+func WriteFile(path, databaseType string, networks ...Network) error {
+	buf, err := Build(databaseType, networks...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf, 0o644)
+}
+
+type child struct {
+	kind  int // childEmpty, childNode, or childData
+	index int
+}
+
+const (
+	childEmpty = iota
+	childNode
+	childData
+)
+
+// Build returns the bytes WriteFile writes.
+func Build(databaseType string, networks ...Network) ([]byte, error) {
+	return BuildWithMetadata(databaseType, nil, networks...)
+}
+
+// BuildWithMetadata is Build with metadata fields replaced by those in
+// metadata, for databases whose header lies about their contents.
+func BuildWithMetadata(databaseType string, metadata map[string]any, networks ...Network) ([]byte, error) {
+	nodes := [][2]child{{}}
+	var data bytes.Buffer
+	for _, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		ones, bits := ipNet.Mask.Size()
+		addr := ipNet.IP.To16()
+		if bits == 32 {
+			addr = make(net.IP, net.IPv6len)
+			copy(addr[12:], ipNet.IP.To4())
+			ones += 96
+		}
+		offset := data.Len()
+		if err := encode(&data, network.Record); err != nil {
+			return nil, err
+		}
+		node := 0
+		for i := range ones {
+			bit := addr[i/8] >> (7 - uint(i%8)) & 1
+			if i == ones-1 {
+				nodes[node][bit] = child{kind: childData, index: offset}
+				break
+			}
+			next := nodes[node][bit]
+			switch next.kind {
+			case childData:
+				return nil, fmt.Errorf("network %s nests inside another", network.CIDR)
+			case childEmpty:
+				nodes = append(nodes, [2]child{})
+				next = child{kind: childNode, index: len(nodes) - 1}
+				nodes[node][bit] = next
+			}
+			node = next.index
+		}
+	}
+
+	var out bytes.Buffer
+	nodeCount := len(nodes)
+	for _, n := range nodes {
+		for _, c := range n {
+			value := nodeCount
+			switch c.kind {
+			case childNode:
+				value = c.index
+			case childData:
+				value = nodeCount + 16 + c.index
+			}
+			if value >= 1<<24 {
+				return nil, fmt.Errorf("database too large for 24-bit records")
+			}
+			out.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	fields := map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"database_type":               databaseType,
+		"description":                 map[string]any{"en": "test database"},
+		"ip_version":                  uint16(6),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+	}
+	maps.Copy(fields, metadata)
+	if err := encode(&out, fields); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case Raw:
+		buf.Write(v)
+	case string:
+		control(buf, 2, len(v))
+		buf.WriteString(v)
+	case float64:
+		control(buf, 3, 8)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+	case uint16:
+		writeUint(buf, 5, uint64(v))
+	case uint32:
+		writeUint(buf, 6, uint64(v))
+	case int:
+		writeUint(buf, 6, uint64(v))
+	case uint64:
+		writeUint(buf, 9, v)
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		control(buf, 14, size)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		control(buf, 7, len(v))
+		for _, key := range keys {
+			if err := encode(buf, key); err != nil {
+				return err
+			}
+			if err := encode(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		control(buf, 11, len(v))
+		for _, item := range v {
+			if err := encode(buf, item); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+	return nil
+}
+
+func writeUint(buf *bytes.Buffer, typ int, n uint64) {
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	control(buf, typ, len(b))
+	buf.Write(b)
+}
+
+func control(buf *bytes.Buffer, typ, size int) {
+	var extended []byte
+	if typ > 7 {
+		extended = []byte{byte(typ - 7)}
+		typ = 0
+	}
+	switch {
+	case size < 29:
+		buf.WriteByte(byte(typ<<5 | size))
+		buf.Write(extended)
+	case size < 285:
+		buf.WriteByte(byte(typ<<5 | 29))
+		buf.Write(extended)
+		buf.WriteByte(byte(size - 29))
+	case size < 65821:
+		buf.WriteByte(byte(typ<<5 | 30))
+		buf.Write(extended)
+		buf.Write([]byte{byte((size - 285) >> 8), byte(size - 285)})
+	default:
+		buf.WriteByte(byte(typ<<5 | 31))
+		buf.Write(extended)
+		size -= 65821
+		buf.Write([]byte{byte(size >> 16), byte(size >> 8), byte(size)})
+	}
+}
@@ -0,0 +1,361 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+)
+
+// metadataMarker precedes the metadata map at the end of every MaxMind DB
+// file.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// Metadata is scanned from the last 128 KiB, as the format specifies.
+const metadataSearchBytes = 128 * 1024
+
+// maxDecodeDepth bounds nested maps and arrays in malformed files.
+const maxDecodeDepth = 32
+
+// maxDecodeValues bounds the values one record may expand to. Pointers let a
+// malformed file reuse one structure many times over, so depth alone does
+// not bound the work.
+const maxDecodeValues = 4096
+
+// Data section types from the MaxMind DB format specification.
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+var errMalformed = errors.New("malformed MaxMind DB")
+
+// reader looks up records in one MaxMind DB file held in memory.
+type reader struct {
+	buf          []byte
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	databaseType string
+	treeSize     uint
+	data         []byte
+	// ipv4Start is the node where IPv4 addresses begin in an IPv6 tree.
+	ipv4Start uint
+}
+
+func newReader(buf []byte) (*reader, error) {
+	searchFrom := max(0, len(buf)-metadataSearchBytes)
+	idx := bytes.LastIndex(buf[searchFrom:], metadataMarker)
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: metadata marker not found", errMalformed)
+	}
+	metaStart := searchFrom + idx + len(metadataMarker)
+	meta, _, err := (&decoder{section: buf[metaStart:]}).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("metadata: %w", err)
+	}
+	fields, ok := meta.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", errMalformed)
+	}
+	r := &reader{buf: buf}
+	r.nodeCount, _ = asUint(fields["node_count"])
+	r.recordSize, _ = asUint(fields["record_size"])
+	r.ipVersion, _ = asUint(fields["ip_version"])
+	r.databaseType, _ = fields["database_type"].(string)
+	if major, _ := asUint(fields["binary_format_major_version"]); major != 2 {
+		return nil, fmt.Errorf("%w: unsupported format version %d", errMalformed, major)
+	}
+	switch r.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("%w: unsupported record size %d", errMalformed, r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported IP version %d", errMalformed, r.ipVersion)
+	}
+	// Bound nodeCount by the file size first so the tree size cannot overflow.
+	if r.nodeCount == 0 || r.nodeCount > uint(len(buf))*4/r.recordSize {
+		return nil, fmt.Errorf("%w: search tree exceeds file", errMalformed)
+	}
+	r.treeSize = r.nodeCount * r.recordSize / 4
+	if r.treeSize+16 > uint(searchFrom+idx) {
+		return nil, fmt.Errorf("%w: search tree exceeds file", errMalformed)
+	}
+	r.data = buf[r.treeSize+16 : searchFrom+idx]
+
+	if r.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.nodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of node.
+func (r *reader) record(node uint, bit byte) uint {
+	switch r.recordSize {
+	case 24:
+		off := node*6 + uint(bit)*3
+		b := r.buf[off : off+3]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.buf[node*7 : node*7+7]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		off := node*8 + uint(bit)*4
+		return uint(binary.BigEndian.Uint32(r.buf[off : off+4]))
+	}
+}
+
+// lookup returns the record for ip, or nil when the database has none.
+func (r *reader) lookup(ip net.IP) (map[string]any, error) {
+	var addr []byte
+	node := uint(0)
+	if v4 := ip.To4(); v4 != nil {
+		addr = v4
+		if r.ipVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else if r.ipVersion == 6 && len(ip) == net.IPv6len {
+		addr = ip
+	} else {
+		return nil, nil
+	}
+	for i := 0; i < len(addr)*8 && node < r.nodeCount; i++ {
+		node = r.record(node, addr[i/8]>>(7-uint(i%8))&1)
+	}
+	if node == r.nodeCount {
+		return nil, nil
+	}
+	if node < r.nodeCount {
+		return nil, fmt.Errorf("%w: address deeper than the search tree", errMalformed)
+	}
+	offset := node - r.nodeCount - 16
+	if offset >= uint(len(r.data)) {
+		return nil, fmt.Errorf("%w: data pointer out of range", errMalformed)
+	}
+	value, _, err := (&decoder{section: r.data}).decode(offset, 0)
+	if err != nil {
+		return nil, err
+	}
+	record, _ := value.(map[string]any)
+	return record, nil
+}
+
+// decoder reads values from a data section; pointers are relative to its
+// start.
+type decoder struct {
+	section []byte
+	// decoded counts values toward maxDecodeValues.
+	decoded int
+}
+
+func (d *decoder) decode(offset uint, depth int) (any, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("%w: nesting too deep", errMalformed)
+	}
+	if d.decoded++; d.decoded > maxDecodeValues {
+		return nil, 0, fmt.Errorf("%w: too many values", errMalformed)
+	}
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if typ == typePointer {
+		target, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		typ, size, targetOffset, err := d.control(target)
+		if err != nil {
+			return nil, 0, err
+		}
+		if typ == typePointer {
+			return nil, 0, fmt.Errorf("%w: pointer to pointer", errMalformed)
+		}
+		value, _, err := d.value(typ, size, targetOffset, depth)
+		return value, next, err
+	}
+	return d.value(typ, size, offset, depth)
+}
+
+// control reads a control byte and its extended type and size bytes. For
+// pointers, size holds the raw control byte.
+func (d *decoder) control(offset uint) (typ int, size uint, next uint, err error) {
+	if offset >= uint(len(d.section)) {
+		return 0, 0, 0, fmt.Errorf("%w: offset out of range", errMalformed)
+	}
+	ctrl := d.section[offset]
+	offset++
+	typ = int(ctrl >> 5)
+	if typ == typePointer {
+		return typ, uint(ctrl), offset, nil
+	}
+	if typ == typeExtended {
+		if offset >= uint(len(d.section)) {
+			return 0, 0, 0, fmt.Errorf("%w: truncated type", errMalformed)
+		}
+		typ = 7 + int(d.section[offset])
+		offset++
+	}
+	size = uint(ctrl & 0x1f)
+	if size >= 29 {
+		extra := size - 28
+		bytes, err := d.slice(offset, extra)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		offset += extra
+		var n uint
+		for _, b := range bytes {
+			n = n<<8 | uint(b)
+		}
+		switch size {
+		case 29:
+			size = 29 + n
+		case 30:
+			size = 285 + n
+		default:
+			size = 65821 + n
+		}
+	}
+	return typ, size, offset, nil
+}
+
+func (d *decoder) pointer(ctrl, offset uint) (target, next uint, err error) {
+	pointerSize := (ctrl>>3)&0x3 + 1
+	b, err := d.slice(offset, pointerSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	var n uint
+	for _, c := range b {
+		n = n<<8 | uint(c)
+	}
+	switch pointerSize {
+	case 1:
+		n |= (ctrl & 0x7) << 8
+	case 2:
+		n = (n | (ctrl&0x7)<<16) + 2048
+	case 3:
+		n = (n | (ctrl&0x7)<<24) + 526336
+	}
+	return n, offset + pointerSize, nil
+}
+
+func (d *decoder) slice(offset, size uint) ([]byte, error) {
+	end := offset + size
+	if end < offset || end > uint(len(d.section)) {
+		return nil, fmt.Errorf("%w: value exceeds data section", errMalformed)
+	}
+	return d.section[offset:end], nil
+}
+
+func (d *decoder) value(typ int, size, offset uint, depth int) (any, uint, error) {
+	switch typ {
+	case typeMap:
+		m := make(map[string]any, min(size, 64))
+		for range size {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: map key is not a string", errMalformed)
+			}
+			value, after, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[name], offset = value, after
+		}
+		return m, offset, nil
+	case typeArray:
+		items := make([]any, 0, min(size, 64))
+		for range size {
+			value, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items, offset = append(items, value), next
+		}
+		return items, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeEndMarker, typeContainer:
+		return nil, offset, nil
+	}
+
+	b, err := d.slice(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	next := offset + size
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return bytes.Clone(b), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("%w: double of %d bytes", errMalformed, size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("%w: float of %d bytes", errMalformed, size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64, typeInt32:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("%w: integer of %d bytes", errMalformed, size)
+		}
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		if typ == typeInt32 {
+			return int64(int32(uint32(n))), next, nil
+		}
+		return n, next, nil
+	case typeUint128:
+		// No field this package reads is a uint128; skip its value.
+		return nil, next, nil
+	}
+	return nil, 0, fmt.Errorf("%w: unknown type %d", errMalformed, typ)
+}
+
+func asUint(v any) (uint, bool) {
+	switch n := v.(type) {
+	case uint64:
+		return uint(n), true
+	case int64:
+		if n >= 0 {
+			return uint(n), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,301 @@
+// Package proxyproto reads HAProxy PROXY protocol headers (v1 text and v2
+// binary) so a server behind a layer 4 load balancer sees the client's
+// address instead of the balancer's.
+//
+// Only connections from trusted networks are inspected. A trusted peer may
+// still omit the header, which keeps local health checks working; its
+// connection then reports its own address.
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidHeader reports a PROXY protocol header that could not be parsed.
+var ErrInvalidHeader = errors.New("proxyproto: invalid header")
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+const (
+	// v1MaxLength is the longest v1 line including CRLF, per the spec.
+	v1MaxLength = 107
+	v2HeaderLen = 16
+
+	v2CommandLocal = 0x0
+	v2CommandProxy = 0x1
+	v2FamilyInet   = 0x1
+	v2FamilyInet6  = 0x2
+	v2TransStream  = 0x1
+)
+
+// Listener wraps connections from trusted networks in a Conn.
+type Listener struct {
+	net.Listener
+	trusted []*net.IPNet
+	timeout time.Duration
+}
+
+// NewListener reads PROXY headers on connections from trusted, giving up on
+// peers that do not send one within timeout.
+func NewListener(inner net.Listener, trusted []*net.IPNet, timeout time.Duration) *Listener {
+	return &Listener{Listener: inner, trusted: trusted, timeout: timeout}
+}
+
+// Accept returns the next connection. It does not wait for the header; the
+// first call to the connection's Read or RemoteAddr does.
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil || !l.isTrusted(conn.RemoteAddr()) {
+		return conn, err
+	}
+	return &Conn{Conn: conn, reader: bufio.NewReader(conn), timeout: l.timeout}, nil
+}
+
+func (l *Listener) isTrusted(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, network := range l.trusted {
+		if network.Contains(tcp.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn is a connection from a trusted peer whose RemoteAddr is the client
+// address named in its PROXY header.
+type Conn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+
+	once   sync.Once
+	remote net.Addr
+	err    error
+
+	mu           sync.Mutex
+	readDeadline time.Time
+}
+
+// Read reads past the PROXY header. A malformed header closes the
+// connection and fails every read.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address from the PROXY header, or the
+// peer's own address when it sent none, a LOCAL command, or an address
+// family other than TCP over IPv4 or IPv6.
+func (c *Conn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// SetDeadline also records the read deadline so reading the header does not
+// discard it.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline records the deadline so reading the header does not
+// discard it.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *Conn) readHeader() {
+	c.mu.Lock()
+	restore := c.readDeadline
+	c.mu.Unlock()
+	deadline := time.Now().Add(c.timeout)
+	if !restore.IsZero() && restore.Before(deadline) {
+		deadline = restore
+	}
+	if err := c.Conn.SetReadDeadline(deadline); err != nil {
+		c.err = err
+		return
+	}
+	c.remote, c.err = readHeader(c.reader)
+	if c.err != nil {
+		_ = c.Conn.Close()
+		return
+	}
+	if err := c.Conn.SetReadDeadline(restore); err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+// readHeader consumes a PROXY header if r starts with one and returns the
+// client address it names, or nil when the connection's own address applies.
+func readHeader(r *bufio.Reader) (net.Addr, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	switch first[0] {
+	case v1Prefix[0]:
+		if ok, err := hasPrefix(r, v1Prefix); !ok || err != nil {
+			return nil, err
+		}
+		return readV1(r)
+	case v2Signature[0]:
+		if ok, err := hasPrefix(r, v2Signature); !ok || err != nil {
+			return nil, err
+		}
+		return readV2(r)
+	}
+	return nil, nil
+}
+
+// hasPrefix reports whether r starts with prefix. Input that ends before the
+// whole prefix arrives is not a header.
+func hasPrefix(r *bufio.Reader, prefix []byte) (bool, error) {
+	peek, err := r.Peek(len(prefix))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	return bytes.Equal(peek, prefix), nil
+}
+
+// readV1 parses "PROXY TCP4 src dst sport dport\r\n" and its TCP6 and
+// UNKNOWN variants.
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < v1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	text, ok := strings.CutSuffix(string(line), "\r\n")
+	if !ok {
+		return nil, ErrInvalidHeader
+	}
+	fields := strings.Split(text, " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 {
+		return nil, ErrInvalidHeader
+	}
+	src, srcErr := netip.ParseAddr(fields[2])
+	dst, dstErr := netip.ParseAddr(fields[3])
+	if srcErr != nil || dstErr != nil || src.Zone() != "" || dst.Zone() != "" {
+		return nil, ErrInvalidHeader
+	}
+	switch fields[1] {
+	case "TCP4":
+		if !src.Is4() || !dst.Is4() {
+			return nil, ErrInvalidHeader
+		}
+	case "TCP6":
+		if !src.Is6() || !dst.Is6() {
+			return nil, ErrInvalidHeader
+		}
+	default:
+		return nil, ErrInvalidHeader
+	}
+	port, err := parsePort(fields[4])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parsePort(fields[5]); err != nil {
+		return nil, err
+	}
+	return &net.TCPAddr{IP: net.IP(src.AsSlice()), Port: port}, nil
+}
+
+func parsePort(s string) (int, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, ErrInvalidHeader
+	}
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, ErrInvalidHeader
+	}
+	return int(port), nil
+}
+
+// readV2 parses the binary header: the signature, version and command,
+// family and transport, a length, then the addresses and optional TLVs,
+// which are skipped.
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, v2HeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	versionCommand, family := header[12], header[13]
+	body := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	if versionCommand>>4 != 2 {
+		return nil, ErrInvalidHeader
+	}
+	switch versionCommand & 0x0f {
+	case v2CommandLocal:
+		return nil, nil
+	case v2CommandProxy:
+	default:
+		return nil, ErrInvalidHeader
+	}
+	if family&0x0f != v2TransStream {
+		return nil, nil
+	}
+	switch family >> 4 {
+	case v2FamilyInet:
+		if len(body) < 12 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(bytes.Clone(body[0:4])),
+			Port: int(binary.BigEndian.Uint16(body[8:10])),
+		}, nil
+	case v2FamilyInet6:
+		if len(body) < 36 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(bytes.Clone(body[0:16])),
+			Port: int(binary.BigEndian.Uint16(body[32:34])),
+		}, nil
+	}
+	return nil, nil
+}
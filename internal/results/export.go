@@ -0,0 +1,281 @@
+package results
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is an encoding for exported results.
+type ExportFormat string
+
+const (
+	// FormatNDJSON writes one JSON result per line, as the API returns it.
+	FormatNDJSON ExportFormat = "ndjson"
+	// FormatCSV writes a header row followed by one row per result.
+	FormatCSV ExportFormat = "csv"
+)
+
+// ErrInvalidExportFormat reports an unknown export format.
+var ErrInvalidExportFormat = errors.New("export format must be ndjson or csv")
+
+// ParseExportFormat accepts "ndjson" or "csv".
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch f := ExportFormat(s); f {
+	case FormatNDJSON, FormatCSV:
+		return f, nil
+	}
+	return "", ErrInvalidExportFormat
+}
+
+// csvColumns is the CSV header, in column order.
+var csvColumns = []string{
+	"id", "created_at", "expires_at", "download_mbps", "upload_mbps", "latency_ms", "jitter_ms",
+	"loaded_latency_ms", "bufferbloat_grade", "ipv4", "ipv6", "server_name", "plausibility",
+	"asn", "as_org", "country",
+}
+
+// Export streams every live result created in [q.From, q.To) to w, newest
+// first, and returns how many it wrote. Other Query fields filter as in List.
+func Export(ctx context.Context, store Storage, w io.Writer, format ExportFormat, q Query) (int, error) {
+	var write func(Result) error
+	var flush func() error
+	switch format {
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		write, flush = func(r Result) error { return enc.Encode(r) }, bw.Flush
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvColumns); err != nil {
+			return 0, err
+		}
+		write = func(r Result) error { return cw.Write(csvRecord(r)) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+	default:
+		return 0, ErrInvalidExportFormat
+	}
+
+	q.Limit, q.Cursor = MaxListLimit, ""
+	n := 0
+	for {
+		page, err := store.List(ctx, q)
+		if err != nil {
+			return n, err
+		}
+		for _, r := range page.Results {
+			if err := write(r); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if page.NextCursor == "" {
+			return n, flush()
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// WriteCSV writes the export CSV header followed by one row per result.
+func WriteCSV(w io.Writer, rs ...Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, r := range rs {
+		if err := cw.Write(csvRecord(r)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvRecord(r Result) []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	asn := ""
+	if r.ASN != 0 {
+		asn = strconv.FormatUint(uint64(r.ASN), 10)
+	}
+	return []string{
+		r.ID, r.CreatedAt.UTC().Format(time.RFC3339Nano), r.ExpiresAt.UTC().Format(time.RFC3339Nano),
+		f(r.DownloadMbps), f(r.UploadMbps), f(r.LatencyMs), f(r.JitterMs), f(r.LoadedLatencyMs),
+		csvText(r.BufferbloatGrade), csvText(r.IPv4), csvText(r.IPv6), csvText(r.ServerName), csvText(r.Plausibility),
+		asn, csvText(r.ASOrganization), csvText(r.Country),
+	}
+}
+
+// csvEscaped lists the first characters csvText escapes: those that start a
+// spreadsheet formula, and the apostrophe that escapes them.
+const csvEscaped = "=+-@\t\r'"
+
+// csvText quotes a text cell that a spreadsheet would run as a formula by
+// prefixing an apostrophe. Cells that already start with one are
+// prefixed too, so csvUntext restores every value exactly.
+func csvText(s string) string {
+	if s != "" && strings.IndexByte(csvEscaped, s[0]) >= 0 {
+		return "'" + s
+	}
+	return s
+}
+
+// csvUntext reverses csvText.
+func csvUntext(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.IndexByte(csvEscaped, s[1]) >= 0 {
+		return s[1:]
+	}
+	return s
+}
+
+// ImportReport summarizes an Import run.
+type ImportReport struct {
+	Imported int
+	// Collisions lists IDs that were already stored; those results were
+	// skipped and the stored ones left alone.
+	Collisions []string
+	// Expired counts results skipped because they had already expired.
+	Expired int
+}
+
+// Import reads results written by Export from r and stores them with their
+// IDs and timestamps. Results whose ID is taken or that have expired are
+// skipped and reported; a malformed record stops the import with its line,
+// and running it again skips what was already imported.
+func Import(ctx context.Context, store Storage, r io.Reader, format ExportFormat) (ImportReport, error) {
+	var next func() (Result, int, error)
+	switch format {
+	case FormatNDJSON:
+		next = ndjsonResults(r)
+	case FormatCSV:
+		var err error
+		if next, err = csvResults(r); err != nil {
+			return ImportReport{}, err
+		}
+	default:
+		return ImportReport{}, ErrInvalidExportFormat
+	}
+
+	var report ImportReport
+	for {
+		res, line, err := next()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		if err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch err := store.Import(ctx, res); {
+		case err == nil:
+			report.Imported++
+		case errors.Is(err, ErrIDExists):
+			report.Collisions = append(report.Collisions, res.ID)
+		case errors.Is(err, ErrResultExpired):
+			report.Expired++
+		default:
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+func ndjsonResults(r io.Reader) func() (Result, int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxJournalLine)
+	line := 0
+	return func() (Result, int, error) {
+		for scanner.Scan() {
+			line++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var res Result
+			err := json.Unmarshal(scanner.Bytes(), &res)
+			return res, line, err
+		}
+		if err := scanner.Err(); err != nil {
+			return Result{}, line, err
+		}
+		return Result{}, line, io.EOF
+	}
+}
+
+// csvResults maps columns by the header row, so columns may be reordered
+// or left out; only id is required.
+func csvResults(r io.Reader) (func() (Result, int, error), error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+	if _, ok := index["id"]; !ok {
+		return nil, errors.New("CSV header lacks an id column")
+	}
+	line := 1
+	return func() (Result, int, error) {
+		record, err := cr.Read()
+		if err != nil {
+			return Result{}, line + 1, err
+		}
+		line, _ = cr.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := index[name]; ok {
+				return record[i]
+			}
+			return ""
+		}
+		res := Result{
+			ID:               field("id"),
+			BufferbloatGrade: csvUntext(field("bufferbloat_grade")),
+			IPv4:             csvUntext(field("ipv4")),
+			IPv6:             csvUntext(field("ipv6")),
+			ServerName:       csvUntext(field("server_name")),
+			Plausibility:     csvUntext(field("plausibility")),
+			ASOrganization:   csvUntext(field("as_org")),
+			Country:          csvUntext(field("country")),
+		}
+		if v := field("asn"); v != "" {
+			asn, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return Result{}, line, fmt.Errorf("asn: %w", err)
+			}
+			res.ASN = uint32(asn)
+		}
+		for _, t := range []struct {
+			name string
+			dst  *time.Time
+		}{{"created_at", &res.CreatedAt}, {"expires_at", &res.ExpiresAt}} {
+			if v := field(t.name); v != "" {
+				if *t.dst, err = time.Parse(time.RFC3339Nano, v); err != nil {
+					return Result{}, line, fmt.Errorf("%s: %w", t.name, err)
+				}
+			}
+		}
+		for _, m := range []struct {
+			name string
+			dst  *float64
+		}{
+			{"download_mbps", &res.DownloadMbps},
+			{"upload_mbps", &res.UploadMbps},
+			{"latency_ms", &res.LatencyMs},
+			{"jitter_ms", &res.JitterMs},
+			{"loaded_latency_ms", &res.LoadedLatencyMs},
+		} {
+			if v := field(m.name); v != "" {
+				if *m.dst, err = strconv.ParseFloat(v, 64); err != nil {
+					return Result{}, line, fmt.Errorf("%s: %w", m.name, err)
+				}
+			}
+		}
+		return res, line, nil
+	}, nil
+}
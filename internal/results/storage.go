@@ -0,0 +1,30 @@
+package results
+
+import (
+	"context"
+	"time"
+)
+
+// Storage persists shared results for the API. *Store, backed by SQLite, is
+// the default; MemoryStore keeps results in memory, optionally journaled to
+// an NDJSON file. Every backend applies the same Options.
+type Storage interface {
+	Save(ctx context.Context, r Result) (string, error)
+	SaveWithDeleteToken(ctx context.Context, r Result) (Saved, error)
+	// Get returns nil without an error when the result does not exist or has
+	// expired.
+	Get(ctx context.Context, id string) (*Result, error)
+	Delete(ctx context.Context, id, deleteToken string) error
+	// Import stores a result exported elsewhere under its own ID and
+	// timestamps.
+	Import(ctx context.Context, r Result) error
+	List(ctx context.Context, q Query) (Page, error)
+	Stats(ctx context.Context, q StatsQuery) ([]StatsBucket, error)
+	Retention() (defaultRetention, maxRetention time.Duration)
+	Close()
+}
+
+var (
+	_ Storage = (*Store)(nil)
+	_ Storage = (*MemoryStore)(nil)
+)
@@ -0,0 +1,495 @@
+package results
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryEntry is one stored result with its delete token hash.
+type memoryEntry struct {
+	Result          Result
+	DeleteTokenHash []byte
+}
+
+// journal records MemoryStore changes outside the process.
+type journal interface {
+	appendSave(e memoryEntry) error
+	appendDelete(id string) error
+	// compact replaces the journal with entries, oldest first.
+	compact(entries []memoryEntry) error
+	close() error
+}
+
+// MemoryStore keeps results in memory. Without a journal nothing survives a
+// restart, which suits ephemeral deployments and tests; OpenNDJSON adds an
+// append-only file journal.
+type MemoryStore struct {
+	settings
+	maxResults int
+	journal    journal
+
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	// order holds entries oldest first, ordered like SQLite's
+	// (created_at, id) keyset.
+	order []*memoryEntry
+	// dead counts journal records of removed results awaiting compaction.
+	dead int
+
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewMemory returns an in-memory Storage holding at most maxResults results.
+func NewMemory(maxResults int, opts ...Option) *MemoryStore {
+	return startMemory(newMemory(maxResults, opts), nil)
+}
+
+func newMemory(maxResults int, opts []Option) *MemoryStore {
+	return &MemoryStore{
+		settings:   newSettings(opts),
+		maxResults: maxResults,
+		entries:    make(map[string]*memoryEntry),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+func startMemory(m *MemoryStore, j journal) *MemoryStore {
+	m.journal = j
+	m.cleanup()
+	m.wg.Add(1)
+	go m.cleanupLoop()
+	return m
+}
+
+func (m *MemoryStore) Close() {
+	m.closeOnce.Do(func() {
+		close(m.stopCh)
+		m.wg.Wait()
+		if m.journal != nil {
+			if err := m.journal.close(); err != nil {
+				slog.Warn("results store: close journal failed", "error", err)
+			}
+		}
+	})
+}
+
+func (m *MemoryStore) Save(ctx context.Context, r Result) (string, error) {
+	saved, err := m.save(ctx, r, nil)
+	return saved.ID, err
+}
+
+// SaveWithDeleteToken stores r like Save and also returns a secret token
+// that later authorizes Delete.
+func (m *MemoryStore) SaveWithDeleteToken(ctx context.Context, r Result) (Saved, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Saved{}, fmt.Errorf("generate delete token: %w", err)
+	}
+	deleteToken := deleteTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	saved, err := m.save(ctx, r, hashDeleteToken(deleteToken))
+	if err != nil {
+		return Saved{}, err
+	}
+	saved.DeleteToken = deleteToken
+	return saved, nil
+}
+
+func (m *MemoryStore) save(ctx context.Context, r Result, deleteTokenHash []byte) (Saved, error) {
+	if err := ctx.Err(); err != nil {
+		return Saved{}, err
+	}
+	now := time.Now().UTC()
+	r.CreatedAt = now
+	r.ExpiresAt = m.expiresAt(r.ExpiresAt, now)
+	r.IPv4, r.IPv6 = m.ipPolicy.apply(r.IPv4), m.ipPolicy.apply(r.IPv6)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for range maxIDRetries {
+		id, err := generateID()
+		if err != nil {
+			return Saved{}, fmt.Errorf("generate id: %w", err)
+		}
+		if _, taken := m.entries[id]; taken {
+			continue
+		}
+		r.ID = id
+		e := &memoryEntry{Result: r, DeleteTokenHash: deleteTokenHash}
+		if m.journal != nil {
+			if err := m.journal.appendSave(*e); err != nil {
+				return Saved{}, fmt.Errorf("insert result: %w", err)
+			}
+		}
+		m.addLocked(e)
+		m.trimLocked()
+		return Saved{ID: id, ExpiresAt: r.ExpiresAt}, nil
+	}
+	return Saved{}, fmt.Errorf("failed to generate unique ID after %d attempts", maxIDRetries)
+}
+
+// trimLocked drops the oldest results beyond maxResults. Unlike SQLite, the
+// memory backend trims on every save so it never holds more than the limit.
+func (m *MemoryStore) trimLocked() {
+	if m.maxResults <= 0 {
+		return
+	}
+	for len(m.entries) > m.maxResults {
+		m.deleteLocked(m.order[0].Result.ID)
+	}
+}
+
+// deleteLocked removes id from memory and the journal. A journal failure is
+// logged; the next compaction drops the result from the file.
+func (m *MemoryStore) deleteLocked(id string) {
+	m.removeLocked(id)
+	if m.journal != nil {
+		m.dead++
+		if err := m.journal.appendDelete(id); err != nil {
+			slog.Warn("results journal: delete failed", "id", id, "error", err)
+		}
+	}
+}
+
+// addLocked stores e, replacing any entry with the same ID. Saves arrive
+// in creation order, so e usually goes at the end.
+func (m *MemoryStore) addLocked(e *memoryEntry) {
+	m.removeLocked(e.Result.ID)
+	m.entries[e.Result.ID] = e
+	if n := len(m.order); n == 0 || compareEntries(m.order[n-1], e) < 0 {
+		m.order = append(m.order, e)
+		return
+	}
+	i, _ := slices.BinarySearchFunc(m.order, e, compareEntries)
+	m.order = slices.Insert(m.order, i, e)
+}
+
+// removeLocked removes id from memory only.
+func (m *MemoryStore) removeLocked(id string) {
+	e, ok := m.entries[id]
+	if !ok {
+		return
+	}
+	delete(m.entries, id)
+	i, found := slices.BinarySearchFunc(m.order, e, compareEntries)
+	switch {
+	case !found:
+	case i == 0:
+		// Trimming removes the oldest entry on every save at capacity.
+		m.order[0] = nil
+		m.order = m.order[1:]
+	default:
+		m.order = slices.Delete(m.order, i, i+1)
+	}
+}
+
+func compareEntries(a, b *memoryEntry) int {
+	if c := a.Result.CreatedAt.Compare(b.Result.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Result.ID, b.Result.ID)
+}
+
+// live mirrors the SQLite liveCondition: r has neither expired nor outlived
+// the maximum retention.
+func (s *settings) live(r Result, now time.Time) bool {
+	return r.ExpiresAt.After(now) && !r.CreatedAt.Before(now.Add(-s.maxRetention))
+}
+
+// visible reports whether r is still served and returns the copy a reader
+// sees, with the expiry capped and old addresses hidden.
+func (s *settings) visible(r Result, now time.Time) (Result, bool) {
+	if !s.live(r, now) {
+		return Result{}, false
+	}
+	if latest := r.CreatedAt.Add(s.maxRetention); latest.Before(r.ExpiresAt) {
+		r.ExpiresAt = latest
+	}
+	s.anonymizeResult(&r, now)
+	return r, true
+}
+
+func (m *MemoryStore) Get(ctx context.Context, id string) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok {
+		return nil, nil
+	}
+	r, ok := m.visible(e.Result, time.Now().UTC())
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+// Delete removes the result when deleteToken matches the one issued by
+// SaveWithDeleteToken.
+func (m *MemoryStore) Delete(ctx context.Context, id, deleteToken string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if !strings.HasPrefix(deleteToken, deleteTokenPrefix) || e.DeleteTokenHash == nil ||
+		!bytes.Equal(e.DeleteTokenHash, hashDeleteToken(deleteToken)) {
+		return ErrInvalidDeleteToken
+	}
+	m.deleteLocked(id)
+	// Owners expect their data gone, so rewrite the journal right away
+	// rather than at the next cleanup.
+	if m.journal != nil {
+		if err := m.compactLocked(); err != nil {
+			slog.Warn("results journal: compaction failed", "error", err)
+		}
+	}
+	return nil
+}
+
+// List returns results matching q, newest first, with the same cursors as
+// the SQLite store.
+func (m *MemoryStore) List(ctx context.Context, q Query) (Page, error) {
+	if err := ctx.Err(); err != nil {
+		return Page{}, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+	var (
+		cursorAt time.Time
+		cursorID string
+	)
+	if q.Cursor != "" {
+		var err error
+		if cursorAt, cursorID, err = decodeCursor(q.Cursor); err != nil {
+			return Page{}, err
+		}
+	}
+
+	now := time.Now().UTC()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	page := Page{Results: make([]Result, 0, min(limit, DefaultListLimit))}
+	for _, e := range slices.Backward(m.order) {
+		r, ok := m.visible(e.Result, now)
+		if !ok || !q.matches(r) {
+			continue
+		}
+		if q.Cursor != "" && !r.CreatedAt.Before(cursorAt) && (!r.CreatedAt.Equal(cursorAt) || r.ID >= cursorID) {
+			continue
+		}
+		if q.IPPrefix.IsValid() && !resultInPrefix(r, q.IPPrefix) {
+			continue
+		}
+		if len(page.Results) == limit {
+			last := page.Results[limit-1]
+			page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+			break
+		}
+		page.Results = append(page.Results, r)
+	}
+	return page, nil
+}
+
+// matches applies the column filters of Query.where.
+func (q Query) matches(r Result) bool {
+	switch {
+	case !q.From.IsZero() && r.CreatedAt.Before(q.From),
+		!q.To.IsZero() && !r.CreatedAt.Before(q.To),
+		q.ServerName != "" && r.ServerName != q.ServerName,
+		!q.Download.contains(r.DownloadMbps),
+		!q.Upload.contains(r.UploadMbps),
+		len(q.Grades) > 0 && !slices.Contains(q.Grades, r.BufferbloatGrade):
+		return false
+	}
+	return true
+}
+
+func (r SpeedRange) contains(v float64) bool {
+	return (r.Min <= 0 || v >= r.Min) && (r.Max <= 0 || v <= r.Max)
+}
+
+// Stats aggregates like the SQLite store, including its nearest-rank
+// percentiles, over live results.
+func (m *MemoryStore) Stats(ctx context.Context, q StatsQuery) ([]StatsBucket, error) {
+	if _, ok := q.Period.bucketExpr(); !ok {
+		return nil, ErrInvalidStatsPeriod
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type groupKey struct {
+		start  time.Time
+		server string
+	}
+	groups := map[groupKey]*[3][]float64{}
+	now := time.Now().UTC()
+	m.mu.Lock()
+	for _, e := range m.entries {
+		r := e.Result
+		if !m.live(r, now) {
+			continue
+		}
+		if (!q.From.IsZero() && r.CreatedAt.Before(q.From)) || (!q.To.IsZero() && !r.CreatedAt.Before(q.To)) ||
+			(q.ServerName != "" && r.ServerName != q.ServerName) {
+			continue
+		}
+		key := groupKey{start: q.Period.bucketStart(r.CreatedAt)}
+		if q.ByServer {
+			key.server = r.ServerName
+		}
+		g := groups[key]
+		if g == nil {
+			g = new([3][]float64)
+			groups[key] = g
+		}
+		g[0] = append(g[0], r.DownloadMbps)
+		g[1] = append(g[1], r.UploadMbps)
+		g[2] = append(g[2], r.LatencyMs)
+	}
+	m.mu.Unlock()
+
+	buckets := make([]StatsBucket, 0, len(groups))
+	for key, g := range groups {
+		buckets = append(buckets, StatsBucket{
+			Start:        key.start,
+			ServerName:   key.server,
+			Count:        len(g[0]),
+			DownloadMbps: metricStats(g[0]),
+			UploadMbps:   metricStats(g[1]),
+			LatencyMs:    metricStats(g[2]),
+		})
+	}
+	slices.SortFunc(buckets, func(a, b StatsBucket) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ServerName, b.ServerName)
+	})
+	return buckets, nil
+}
+
+// bucketStart mirrors bucketExpr for results held in memory.
+func (p StatsPeriod) bucketStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case StatsPeriodHour:
+		return t.Truncate(time.Hour)
+	case StatsPeriodDay:
+		return day
+	case StatsPeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	default:
+		return time.Time{}
+	}
+}
+
+// metricStats sorts values and picks the smallest rank at or above each
+// percentile, matching the SQL query.
+func metricStats(values []float64) MetricStats {
+	slices.Sort(values)
+	n := float64(len(values))
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	rank := func(p float64) float64 {
+		for i, v := range values {
+			if float64(i+1) >= p*n {
+				return v
+			}
+		}
+		return 0
+	}
+	return MetricStats{Avg: sum / n, P10: rank(0.1), P50: rank(0.5), P90: rank(0.9)}
+}
+
+// errNothingAtRest reports Reencrypt on a MemoryStore without a journal.
+var errNothingAtRest = errors.New("in-memory results have nothing at rest to re-encrypt")
+
+// Reencrypt rewrites the journal under the primary field key and returns
+// the number of results it holds.
+func (m *MemoryStore) Reencrypt(context.Context) (int, error) {
+	if m.fields == nil {
+		return 0, ErrNoFieldEncryption
+	}
+	if m.journal == nil {
+		return 0, errNothingAtRest
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries), m.compactLocked()
+}
+
+func (m *MemoryStore) compactLocked() error {
+	entries := make([]memoryEntry, 0, len(m.order))
+	for _, e := range m.order {
+		entries = append(entries, *e)
+	}
+	if err := m.journal.compact(entries); err != nil {
+		return err
+	}
+	m.dead = 0
+	return nil
+}
+
+// cleanup mirrors the SQLite cleanup: expired results go, old addresses are
+// dropped, and the journal is compacted so removed data leaves the file.
+func (m *MemoryStore) cleanup() {
+	now := time.Now().UTC()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changed := false
+	for id, e := range m.entries {
+		if _, ok := m.visible(e.Result, now); !ok {
+			m.removeLocked(id)
+			changed = true
+			continue
+		}
+		if before := e.Result; before.IPv4 != "" || before.IPv6 != "" {
+			m.anonymizeResult(&e.Result, now)
+			changed = changed || e.Result.IPv4 != before.IPv4 || e.Result.IPv6 != before.IPv6
+		}
+	}
+	if m.journal != nil && (changed || m.dead > 0) {
+		if err := m.compactLocked(); err != nil {
+			slog.Warn("results journal: compaction failed", "error", err)
+		}
+	}
+}
+
+func (m *MemoryStore) cleanupLoop() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.cleanup()
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// maxJournalLine bounds one NDJSON record; saved results are far smaller.
+const maxJournalLine = 64 * 1024
+
+// rejectedSuffix names the file, next to the journal, that keeps records
+// replay could not load.
+const rejectedSuffix = ".rejected"
+
+// journalRecord is one NDJSON line. Personal fields are sealed with the
+// field encryption keys when configured.
+type journalRecord struct {
+	Op              string  `json:"op"`
+	Result          *Result `json:"result,omitempty"`
+	DeleteTokenHash []byte  `json:"delete_token_hash,omitempty"`
+	ID              string  `json:"id,omitempty"`
+}
+
+const (
+	journalSave   = "save"
+	journalDelete = "delete"
+)
+
+// ndjsonJournal appends records to a file and rewrites it on compaction.
+type ndjsonJournal struct {
+	path   string
+	file   *os.File
+	fields *settings
+}
+
+// OpenNDJSON returns a MemoryStore journaled to the append-only NDJSON file
+// at path. The file is replayed on open and compacted when results are
+// removed, so deleted and expired results leave the disk too.
+func OpenNDJSON(path string, maxResults int, opts ...Option) (*MemoryStore, error) {
+	m := newMemory(maxResults, opts)
+	j := &ndjsonJournal{path: path, fields: &m.settings}
+	if err := j.replay(m); err != nil {
+		return nil, err
+	}
+	m.journal = j
+	m.mu.Lock()
+	err := m.compactLocked()
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return startMemory(m, j), nil
+}
+
+// replay loads path into m. A torn final line, left by a crash mid-append,
+// is skipped; damage anywhere else is an error. A save whose personal fields
+// cannot be opened, for example under a removed key, is moved to the
+// rejected file so one such record does not keep the server from starting.
+func (j *ndjsonJournal) replay(m *MemoryStore) error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open results journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 4096), maxJournalLine)
+	var pending error
+	var rejected []byte
+	for line := 1; scanner.Scan(); line++ {
+		if pending != nil {
+			return pending
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			pending = fmt.Errorf("results journal line %d: %w", line, err)
+			continue
+		}
+		switch {
+		case rec.Op == journalSave && rec.Result != nil && rec.Result.ID != "":
+			r := *rec.Result
+			if err := j.fields.openFields(&r); err != nil {
+				slog.Warn("results journal: rejected unreadable record", "line", line, "id", r.ID, "error", err)
+				rejected = append(append(rejected, scanner.Bytes()...), '\n')
+				continue
+			}
+			m.addLocked(&memoryEntry{Result: r, DeleteTokenHash: rec.DeleteTokenHash})
+		case rec.Op == journalDelete:
+			m.removeLocked(rec.ID)
+		default:
+			pending = fmt.Errorf("results journal line %d: unknown record", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read results journal: %w", err)
+	}
+	if pending != nil {
+		slog.Warn("results journal: skipped torn final record", "error", pending)
+	}
+	if rejected != nil {
+		// Keep the records before compaction drops them from the journal.
+		return j.reject(rejected)
+	}
+	return nil
+}
+
+// reject appends records to the rejected file.
+func (j *ndjsonJournal) reject(records []byte) error {
+	f, err := os.OpenFile(j.path+rejectedSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("keep rejected results: %w", err)
+	}
+	if _, err := f.Write(records); err != nil {
+		f.Close()
+		return fmt.Errorf("keep rejected results: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("keep rejected results: %w", err)
+	}
+	return f.Close()
+}
+
+func (j *ndjsonJournal) encode(w io.Writer, rec journalRecord) error {
+	if rec.Result != nil {
+		sealed := *rec.Result
+		var err error
+		if sealed.IPv4, sealed.IPv6, err = j.fields.sealFields(sealed.ID, sealed); err != nil {
+			return err
+		}
+		rec.Result = &sealed
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+func (j *ndjsonJournal) append(rec journalRecord) error {
+	if err := j.encode(j.file, rec); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+func (j *ndjsonJournal) appendSave(e memoryEntry) error {
+	return j.append(journalRecord{Op: journalSave, Result: &e.Result, DeleteTokenHash: e.DeleteTokenHash})
+}
+
+func (j *ndjsonJournal) appendDelete(id string) error {
+	return j.append(journalRecord{Op: journalDelete, ID: id})
+}
+
+// compact writes entries to a temporary file and renames it over the
+// journal, so a crash leaves either the old or the new file.
+func (j *ndjsonJournal) compact(entries []memoryEntry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("compact results journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for _, e := range entries {
+		if err := j.encode(w, journalRecord{Op: journalSave, Result: &e.Result, DeleteTokenHash: e.DeleteTokenHash}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("compact results journal: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact results journal: %w", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact results journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact results journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("compact results journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("compact results journal: %w", err)
+	}
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("reopen results journal: %w", err)
+	}
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file = file
+	return nil
+}
+
+func (j *ndjsonJournal) close() error {
+	if j.file == nil {
+		return nil
+	}
+	return j.file.Close()
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "modernc.org/sqlite" // Registers sqlite driver used by sql.Open("sqlite", ...).
@@ -13,6 +14,9 @@ import (
 
 var ErrStoreRetryable = errors.New("results store retryable")
 
+// memoryPath opens a private in-memory database, mainly for tests.
+const memoryPath = ":memory:"
+
 type Result struct {
 	ID               string    `json:"id"`
 	DownloadMbps     float64   `json:"download_mbps"`
@@ -25,31 +29,100 @@ type Result struct {
 	IPv6             string    `json:"ipv6"`
 	ServerName       string    `json:"server_name"`
 	CreatedAt        time.Time `json:"created_at"`
+	// ExpiresAt is when the result stops being served. A zero value on Save
+	// applies the store's default retention.
+	ExpiresAt time.Time `json:"expires_at"`
+	// Plausibility is how the server judged the result when it was saved;
+	// empty for results saved before the check existed.
+	Plausibility string `json:"plausibility,omitempty"`
+	// ASN, ASOrganization, and Country describe the saving client's network
+	// when the server has GeoIP databases; they are empty otherwise.
+	ASN            uint32 `json:"asn,omitempty"`
+	ASOrganization string `json:"as_org,omitempty"`
+	Country        string `json:"country,omitempty"`
 }
 
+// Plausibility statuses of saved results.
+const (
+	// PlausibilityPlausible results match what the server observed.
+	PlausibilityPlausible = "plausible"
+	// PlausibilityUnverified results are within the server's capacity but
+	// the server saw no matching transfers to check them against.
+	PlausibilityUnverified = "unverified"
+	// PlausibilityImplausible results exceed the server's capacity or what
+	// it observed.
+	PlausibilityImplausible = "implausible"
+)
+
+// DefaultRetention is the lifetime of results when no retention is configured.
+const DefaultRetention = 90 * 24 * time.Hour
+
+// Option configures the storage policies shared by every backend.
+type Option func(*settings)
+
+// settings are the retention, IP, and encryption policies every backend
+// applies.
+type settings struct {
+	defaultRetention time.Duration
+	maxRetention     time.Duration
+	ipPolicy         IPPolicy
+	fields           *FieldEncryption
+}
+
+func newSettings(opts []Option) settings {
+	s := settings{defaultRetention: DefaultRetention, maxRetention: DefaultRetention}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// WithRetention sets the lifetime applied to results saved without an
+// expiry and the maximum lifetime of any result. A maximum below the default
+// is raised to it.
+func WithRetention(defaultRetention, maxRetention time.Duration) Option {
+	return func(s *settings) {
+		if defaultRetention > 0 {
+			s.defaultRetention = defaultRetention
+		}
+		s.maxRetention = max(maxRetention, s.defaultRetention)
+	}
+}
+
+// Store keeps results in SQLite. It is the default Storage.
 type Store struct {
+	settings
 	db         *sql.DB
 	maxResults int
 	stopCh     chan struct{}
 	wg         sync.WaitGroup
 	closeOnce  sync.Once
+	backupMu   sync.Mutex
+	// replica is set once Replicate starts shipping changes.
+	replica atomic.Pointer[replicator]
 }
 
-func New(dbPath string, maxResults int) (*Store, error) {
+func New(dbPath string, maxResults int, opts ...Option) (*Store, error) {
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite: %w", err)
 	}
 
-	db.SetMaxOpenConns(sqliteMaxOpenConns)
-	db.SetMaxIdleConns(sqliteMaxOpenConns)
+	// Every connection to ":memory:" opens a separate database, so an
+	// in-memory store must stay on a single connection.
+	conns := sqliteMaxOpenConns
+	if dbPath == memoryPath {
+		conns = 1
+	}
+	db.SetMaxOpenConns(conns)
+	db.SetMaxIdleConns(conns)
 
 	if err := db.Ping(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("ping sqlite: %w", err)
 	}
 
-	if err := configureSQLitePool(db, sqliteMaxOpenConns); err != nil {
+	if err := configureSQLitePool(db, conns); err != nil {
 		db.Close()
 		return nil, err
 	}
@@ -60,6 +133,7 @@ func New(dbPath string, maxResults int) (*Store, error) {
 	}
 
 	s := &Store{
+		settings:   newSettings(opts),
 		db:         db,
 		maxResults: maxResults,
 		stopCh:     make(chan struct{}),
@@ -73,10 +147,17 @@ func New(dbPath string, maxResults int) (*Store, error) {
 	return s, nil
 }
 
+// Retention reports the lifetime of results saved without an expiry and the
+// longest lifetime any result may have.
+func (s *settings) Retention() (defaultRetention, maxRetention time.Duration) {
+	return s.defaultRetention, s.maxRetention
+}
+
 func (s *Store) Close() {
 	s.closeOnce.Do(func() {
 		close(s.stopCh)
 		s.wg.Wait()
+		s.closeReplica()
 		if err := s.db.Close(); err != nil {
 			slog.Warn("results store: close failed", "error", err)
 		}
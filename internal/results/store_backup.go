@@ -0,0 +1,264 @@
+package results
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSnapshot reports a snapshot that is not an intact results
+// database.
+var ErrInvalidSnapshot = errors.New("invalid results snapshot")
+
+const (
+	snapshotPrefix     = "results-"
+	snapshotSuffix     = ".db"
+	snapshotTimeLayout = "20060102T150405.000Z"
+)
+
+// snapshotColumns are the columns every results database has had; columns
+// added later are migrated in when a restored snapshot is opened.
+var snapshotColumns = []string{
+	"id", "download_mbps", "upload_mbps", "latency_ms", "jitter_ms", "loaded_latency_ms",
+	"bufferbloat_grade", "ipv4", "ipv6", "server_name", "created_at",
+}
+
+// Snapshot describes one backup of the results database.
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	SizeBytes int64     `json:"size_bytes"`
+}
+
+// Backup writes a consistent copy of the database into dir with VACUUM INTO
+// and then removes all but the newest keep snapshots there, never the new
+// one. It runs next to live traffic; concurrent calls are serialized.
+func (s *Store) Backup(ctx context.Context, dir string, keep int) (Snapshot, error) {
+	keep = max(keep, 1)
+	s.backupMu.Lock()
+	defer s.backupMu.Unlock()
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Snapshot{}, fmt.Errorf("create backup directory: %w", err)
+	}
+	now := time.Now().UTC()
+	snap := Snapshot{Name: snapshotPrefix + now.Format(snapshotTimeLayout) + snapshotSuffix, CreatedAt: now}
+	snap.Path = filepath.Join(dir, snap.Name)
+	if _, err := os.Lstat(snap.Path); err == nil {
+		return Snapshot{}, fmt.Errorf("snapshot %s already exists", snap.Name)
+	}
+	if _, err := execWithBusyRetry(ctx, s.db, `VACUUM INTO ?`, snap.Path); err != nil {
+		os.Remove(snap.Path)
+		return Snapshot{}, fmt.Errorf("snapshot results: %w", err)
+	}
+	if err := os.Chmod(snap.Path, 0o600); err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot results: %w", err)
+	}
+	info, err := os.Stat(snap.Path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot results: %w", err)
+	}
+	snap.SizeBytes = info.Size()
+	if err := pruneSnapshots(dir, keep); err != nil {
+		slog.Warn("results backup: prune failed", "error", err)
+	}
+	return snap, nil
+}
+
+// pruneSnapshots removes the oldest snapshots in dir beyond keep. Names sort
+// by creation time, and files not written by Backup are left alone.
+func pruneSnapshots(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, snapshotPrefix) && strings.HasSuffix(name, snapshotSuffix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) <= keep {
+		return nil
+	}
+	slices.Sort(names)
+	var errs []error
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateSnapshot opens the snapshot at path read-only, runs SQLite's
+// integrity check, and confirms it holds a results table. It returns the
+// number of stored results.
+func ValidateSnapshot(ctx context.Context, path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("%w: %s is not a regular file", ErrInvalidSnapshot, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return 0, err
+	}
+	uri := url.URL{Scheme: "file", Path: abs, RawQuery: "mode=ro"}
+	db, err := sql.Open("sqlite", uri.String())
+	if err != nil {
+		return 0, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	var check string
+	if err := db.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&check); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if check != "ok" {
+		return 0, fmt.Errorf("%w: integrity check: %s", ErrInvalidSnapshot, check)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info('results')`)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		columns = append(columns, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	for _, want := range snapshotColumns {
+		if !slices.Contains(columns, want) {
+			return 0, fmt.Errorf("%w: results table lacks column %s", ErrInvalidSnapshot, want)
+		}
+	}
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM results`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	return count, nil
+}
+
+// RestoreSnapshot validates the snapshot and then replaces the database at
+// dbPath with a copy of it, keeping the replaced database as
+// dbPath+".pre-restore". No server may have dbPath open while it runs.
+func RestoreSnapshot(ctx context.Context, snapshotPath, dbPath string) (int, error) {
+	count, err := ValidateSnapshot(ctx, snapshotPath)
+	if err != nil {
+		return 0, err
+	}
+	if same, err := sameFile(snapshotPath, dbPath); err != nil {
+		return 0, err
+	} else if same {
+		return 0, fmt.Errorf("snapshot %s is the live database", snapshotPath)
+	}
+
+	tmp, err := copyToTemp(snapshotPath, dbPath)
+	if err != nil {
+		return 0, fmt.Errorf("restore snapshot: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	if _, err := os.Stat(dbPath); err == nil {
+		// Fold the write-ahead log into the old database so the kept copy is
+		// complete on its own.
+		if err := checkpoint(ctx, dbPath); err != nil {
+			return 0, fmt.Errorf("checkpoint live database: %w", err)
+		}
+		if err := os.Rename(dbPath, dbPath+".pre-restore"); err != nil {
+			return 0, fmt.Errorf("restore snapshot: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("restore snapshot: %w", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("restore snapshot: %w", err)
+		}
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return 0, fmt.Errorf("restore snapshot: %w", err)
+	}
+	return count, nil
+}
+
+func sameFile(a, b string) (bool, error) {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bi, err := os.Stat(b)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(ai, bi), nil
+}
+
+// copyToTemp copies src next to dst and syncs it, so the final rename is
+// atomic and on the same filesystem.
+func copyToTemp(src, dst string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*.restore")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Chmod(0o600); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+func checkpoint(ctx context.Context, dbPath string) error {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`)
+	return err
+}
@@ -8,20 +8,22 @@ import (
 	"time"
 )
 
-const (
-	retentionDays   = 90
-	cleanupInterval = 1 * time.Hour
-)
+const cleanupInterval = 1 * time.Hour
 
 func (s *Store) cleanup() {
 	ctx := context.Background()
-	cutoff := time.Now().UTC().Add(-retentionDays * 24 * time.Hour)
-	res, err := execWithBusyRetry(ctx, s.db, `DELETE FROM results WHERE created_at < ?`, cutoff)
+	now := time.Now().UTC()
+	// Rows past their own expiry or older than the maximum retention go; the
+	// second bound also covers rows saved before expires_at existed.
+	res, err := execWithBusyRetry(ctx, s.db,
+		`DELETE FROM results WHERE expires_at <= ? OR created_at < ?`,
+		now, now.Add(-s.maxRetention))
 	if err != nil {
 		slog.Warn("results cleanup (age) failed", "error", err)
 	} else {
 		s.logCleanupCount("results cleanup: removed expired", "count", res)
 	}
+	s.anonymizeOld(ctx, now)
 
 	// Trim to max count, keeping newest
 	if s.maxResults > 0 {
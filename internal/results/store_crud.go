@@ -22,48 +22,63 @@ const (
 var errBusyRetryBudget = errors.New("busy retry budget exhausted")
 
 func (s *Store) Save(ctx context.Context, r Result) (string, error) {
+	saved, err := s.save(ctx, r, nil)
+	return saved.ID, err
+}
+
+func (s *Store) save(ctx context.Context, r Result, deleteTokenHash []byte) (Saved, error) {
 	now := time.Now().UTC()
+	expiresAt := s.expiresAt(r.ExpiresAt, now)
+	r.IPv4, r.IPv6 = s.ipPolicy.apply(r.IPv4), s.ipPolicy.apply(r.IPv6)
 	busyDeadline := time.Now().Add(busyRetryBudget)
 	for range maxIDRetries {
 		if err := ctx.Err(); err != nil {
-			return "", err
+			return Saved{}, err
 		}
 
 		id, err := generateID()
 		if err != nil {
-			return "", fmt.Errorf("generate id: %w", err)
+			return Saved{}, fmt.Errorf("generate id: %w", err)
 		}
 
-		uniqueConflict, insertErr := s.insertResultWithRetry(ctx, id, r, now, busyDeadline)
+		stored := r
+		if stored.IPv4, stored.IPv6, err = s.sealFields(id, r); err != nil {
+			return Saved{}, err
+		}
+		uniqueConflict, insertErr := s.insertResultWithRetry(ctx, id, stored, deleteTokenHash, now, expiresAt, busyDeadline)
 		if insertErr == nil {
-			return id, nil
+			return Saved{ID: id, ExpiresAt: expiresAt}, nil
 		}
 		if uniqueConflict {
 			continue
 		}
-		return "", insertErr
+		return Saved{}, insertErr
 	}
-	return "", fmt.Errorf("failed to generate unique ID after %d attempts", maxIDRetries)
+	return Saved{}, fmt.Errorf("failed to generate unique ID after %d attempts", maxIDRetries)
 }
 
 func (s *Store) insertResultWithRetry(
 	ctx context.Context,
 	id string,
 	r Result,
-	now time.Time,
+	deleteTokenHash []byte,
+	now, expiresAt time.Time,
 	busyDeadline time.Time,
 ) (uniqueConflict bool, err error) {
 	for busyAttempt := 0; ; busyAttempt++ {
 		_, err = s.db.ExecContext(
 			ctx,
 			`INSERT INTO results (id, download_mbps, upload_mbps, latency_ms, jitter_ms,
-				loaded_latency_ms, bufferbloat_grade, ipv4, ipv6, server_name, created_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				loaded_latency_ms, bufferbloat_grade, ipv4, ipv6, server_name, created_at,
+				expires_at, delete_token_hash, plausibility, asn, as_org, country)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			id, r.DownloadMbps, r.UploadMbps, r.LatencyMs, r.JitterMs,
 			r.LoadedLatencyMs, r.BufferbloatGrade, r.IPv4, r.IPv6, r.ServerName,
-			now,
+			now, expiresAt, deleteTokenHash, r.Plausibility, r.ASN, r.ASOrganization, r.Country,
 		)
 		if err == nil {
+			r.ID, r.CreatedAt, r.ExpiresAt = id, now, expiresAt
+			s.replicate(journalRecord{Op: journalSave, Result: &r, DeleteTokenHash: deleteTokenHash})
 			return false, nil
 		}
 		if isUniqueViolation(err) {
@@ -85,7 +100,9 @@ func (s *Store) insertResultWithRetry(
 func isUniqueViolation(err error) bool {
 	var sqliteErr *sqlite.Error
 	if errors.As(err, &sqliteErr) {
-		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+		// The ID is the primary key, which SQLite reports with its own code.
+		code := sqliteErr.Code()
+		return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
 	}
 	return strings.Contains(err.Error(), "UNIQUE constraint")
 }
@@ -100,18 +117,15 @@ func isBusyError(err error) bool {
 	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "sqlite_busy")
 }
 
+// Get returns the result with id, or nil when it does not exist or has
+// expired but not yet been cleaned up.
 func (s *Store) Get(ctx context.Context, id string) (*Result, error) {
+	live, liveArgs := s.liveCondition(time.Now().UTC())
+	query := `SELECT ` + resultColumns + ` FROM results WHERE id = ? AND ` + live
+	args := append([]any{id}, liveArgs...)
 	busyDeadline := time.Now().Add(busyRetryBudget)
 	for busyAttempt := 0; ; busyAttempt++ {
-		var r Result
-		err := s.db.QueryRowContext(
-			ctx,
-			`SELECT id, download_mbps, upload_mbps, latency_ms, jitter_ms,
-				loaded_latency_ms, bufferbloat_grade, ipv4, ipv6, server_name, created_at
-			FROM results WHERE id = ?`, id,
-		).Scan(&r.ID, &r.DownloadMbps, &r.UploadMbps, &r.LatencyMs, &r.JitterMs,
-			&r.LoadedLatencyMs, &r.BufferbloatGrade, &r.IPv4, &r.IPv6, &r.ServerName,
-			&r.CreatedAt)
+		r, err := s.scanResult(s.db.QueryRowContext(ctx, query, args...))
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
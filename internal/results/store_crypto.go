@@ -0,0 +1,242 @@
+package results
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix marks a field value sealed by FieldEncryption. Stored
+// values look like "enc1:<key id>:<base64 nonce||ciphertext>".
+const encryptedPrefix = "enc1:"
+
+// FieldKeySize is the AES-256 key length.
+const FieldKeySize = 32
+
+const reencryptBatch = 500
+
+var (
+	// ErrUnknownFieldKey reports a stored value sealed with a key that is not
+	// configured.
+	ErrUnknownFieldKey = errors.New("results field encrypted with unknown key")
+	// ErrNoFieldEncryption reports Reencrypt on a store without keys.
+	ErrNoFieldEncryption = errors.New("results field encryption not configured")
+)
+
+// FieldKey is one AES-256 key for personal fields at rest.
+type FieldKey struct {
+	ID  string
+	Key []byte
+}
+
+// FieldEncryption seals personal result fields (currently ipv4 and ipv6)
+// with AES-GCM. The first key encrypts; every key decrypts, so operators
+// rotate by prepending a new key, running Reencrypt, and then dropping the
+// old one.
+type FieldEncryption struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewFieldEncryption builds AES-GCM ciphers for keys, primary first.
+func NewFieldEncryption(keys []FieldKey) (*FieldEncryption, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("field encryption needs at least one key")
+	}
+	e := &FieldEncryption{primary: keys[0].ID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return nil, fmt.Errorf("invalid field key ID %q", key.ID)
+		}
+		if _, dup := e.aeads[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate field key ID %q", key.ID)
+		}
+		if len(key.Key) != FieldKeySize {
+			return nil, fmt.Errorf("field key %q must be %d bytes", key.ID, FieldKeySize)
+		}
+		block, err := aes.NewCipher(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("field key %q: %w", key.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("field key %q: %w", key.ID, err)
+		}
+		e.aeads[key.ID] = aead
+	}
+	return e, nil
+}
+
+// WithFieldEncryption encrypts personal fields on Save and decrypts them on
+// reads. Rows stored in plaintext stay readable.
+func WithFieldEncryption(e *FieldEncryption) Option {
+	return func(s *settings) {
+		s.fields = e
+	}
+}
+
+// fieldAAD binds a sealed value to its row and column so values cannot be
+// moved between results or fields.
+func fieldAAD(id, column string) []byte {
+	return []byte("results." + column + "|" + id)
+}
+
+func (e *FieldEncryption) seal(id, column, plaintext string) (string, error) {
+	if e == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	aead := e.aeads[e.primary]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("field nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), fieldAAD(id, column))
+	return encryptedPrefix + e.primary + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// open returns the plaintext of a stored value and the key that sealed it,
+// which is empty for plaintext values.
+func (e *FieldEncryption) open(id, column, stored string) (plaintext, keyID string, err error) {
+	rest, ok := strings.CutPrefix(stored, encryptedPrefix)
+	if !ok {
+		return stored, "", nil
+	}
+	keyID, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", "", fmt.Errorf("malformed encrypted %s", column)
+	}
+	var aead cipher.AEAD
+	if e != nil {
+		aead = e.aeads[keyID]
+	}
+	if aead == nil {
+		return "", keyID, fmt.Errorf("%w %q", ErrUnknownFieldKey, keyID)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", keyID, fmt.Errorf("malformed encrypted %s", column)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	out, err := aead.Open(nil, nonce, ciphertext, fieldAAD(id, column))
+	if err != nil {
+		return "", keyID, fmt.Errorf("decrypt %s: %w", column, err)
+	}
+	return string(out), keyID, nil
+}
+
+// sealFields encrypts r's personal fields for storage under id.
+func (s *settings) sealFields(id string, r Result) (ipv4, ipv6 string, err error) {
+	if ipv4, err = s.fields.seal(id, "ipv4", r.IPv4); err != nil {
+		return "", "", err
+	}
+	if ipv6, err = s.fields.seal(id, "ipv6", r.IPv6); err != nil {
+		return "", "", err
+	}
+	return ipv4, ipv6, nil
+}
+
+// openFields decrypts r's personal fields in place.
+func (s *settings) openFields(r *Result) (err error) {
+	if r.IPv4, _, err = s.fields.open(r.ID, "ipv4", r.IPv4); err != nil {
+		return err
+	}
+	r.IPv6, _, err = s.fields.open(r.ID, "ipv6", r.IPv6)
+	return err
+}
+
+// Reencrypt rewrites every stored personal field under the primary key,
+// encrypting plaintext rows and rows sealed with older keys. It returns the
+// number of results rewritten.
+func (s *Store) Reencrypt(ctx context.Context) (int, error) {
+	if s.fields == nil {
+		return 0, ErrNoFieldEncryption
+	}
+	rewritten := 0
+	// The replica log carries no rewrites; take a fresh replica snapshot.
+	defer func() {
+		if rewritten > 0 {
+			s.markReplicaStale()
+		}
+	}()
+	after := ""
+	for {
+		batch, err := s.personalFieldBatch(ctx, after)
+		if err != nil {
+			return rewritten, err
+		}
+		if len(batch) == 0 {
+			return rewritten, nil
+		}
+		for _, row := range batch {
+			n, err := s.reencryptRow(ctx, row)
+			if err != nil {
+				return rewritten, fmt.Errorf("reencrypt %s: %w", row.ID, err)
+			}
+			rewritten += n
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
+
+// personalFieldBatch loads stored (still sealed) fields so the batch is
+// fully read before any update runs on the same connection.
+func (s *Store) personalFieldBatch(ctx context.Context, after string) ([]Result, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, ipv4, ipv6 FROM results WHERE id > ? ORDER BY id LIMIT ?`, after, reencryptBatch)
+	if err != nil {
+		return nil, fmt.Errorf("load fields: %w", err)
+	}
+	defer rows.Close()
+	var batch []Result
+	for rows.Next() {
+		var r Result
+		if err := rows.Scan(&r.ID, &r.IPv4, &r.IPv6); err != nil {
+			return nil, fmt.Errorf("scan fields: %w", err)
+		}
+		batch = append(batch, r)
+	}
+	return batch, rows.Err()
+}
+
+func (s *Store) reencryptRow(ctx context.Context, stored Result) (int, error) {
+	plain := Result{ID: stored.ID}
+	current := true
+	for _, f := range []struct {
+		column string
+		stored string
+		plain  *string
+	}{
+		{"ipv4", stored.IPv4, &plain.IPv4},
+		{"ipv6", stored.IPv6, &plain.IPv6},
+	} {
+		value, keyID, err := s.fields.open(stored.ID, f.column, f.stored)
+		if err != nil {
+			return 0, err
+		}
+		*f.plain = value
+		if value != "" && keyID != s.fields.primary {
+			current = false
+		}
+	}
+	if current {
+		return 0, nil
+	}
+	ipv4, ipv6, err := s.sealFields(stored.ID, plain)
+	if err != nil {
+		return 0, err
+	}
+	// Match the loaded values so a concurrent anonymization is not undone.
+	res, err := execWithBusyRetry(ctx, s.db,
+		`UPDATE results SET ipv4 = ?, ipv6 = ? WHERE id = ? AND ipv4 = ? AND ipv6 = ?`,
+		ipv4, ipv6, stored.ID, stored.IPv4, stored.IPv6)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
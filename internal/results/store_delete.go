@@ -0,0 +1,110 @@
+package results
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// deleteTokenPrefix marks owner delete tokens so they are never mistaken for
+// API keys in an Authorization header.
+const deleteTokenPrefix = "obd_"
+
+var (
+	// ErrNotFound reports a result that does not exist.
+	ErrNotFound = errors.New("result not found")
+	// ErrInvalidDeleteToken reports a delete token that does not match the
+	// result, including results saved without one.
+	ErrInvalidDeleteToken = errors.New("invalid delete token")
+)
+
+// Saved describes a result stored by SaveWithDeleteToken.
+type Saved struct {
+	ID string
+	// DeleteToken authorizes Delete. Only its SHA-256 hash is stored.
+	DeleteToken string
+	ExpiresAt   time.Time
+}
+
+// SaveWithDeleteToken stores r like Save and also returns a secret token
+// that later authorizes Delete.
+func (s *Store) SaveWithDeleteToken(ctx context.Context, r Result) (Saved, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Saved{}, fmt.Errorf("generate delete token: %w", err)
+	}
+	deleteToken := deleteTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	saved, err := s.save(ctx, r, hashDeleteToken(deleteToken))
+	if err != nil {
+		return Saved{}, err
+	}
+	saved.DeleteToken = deleteToken
+	return saved, nil
+}
+
+// Delete removes the result when deleteToken matches the one issued by
+// SaveWithDeleteToken.
+func (s *Store) Delete(ctx context.Context, id, deleteToken string) error {
+	if !strings.HasPrefix(deleteToken, deleteTokenPrefix) {
+		return s.deleteMiss(ctx, id)
+	}
+	busyDeadline := time.Now().Add(busyRetryBudget)
+	for busyAttempt := 0; ; busyAttempt++ {
+		res, err := s.db.ExecContext(ctx,
+			`DELETE FROM results WHERE id = ? AND delete_token_hash = ?`,
+			id, hashDeleteToken(deleteToken))
+		if err == nil {
+			if n, err := res.RowsAffected(); err == nil && n > 0 {
+				s.replicate(journalRecord{Op: journalDelete, ID: id})
+				return nil
+			}
+			return s.deleteMiss(ctx, id)
+		}
+		if isBusyError(err) {
+			if waitErr := waitForBusyRetry(ctx, busyDeadline, busyAttempt); waitErr != nil {
+				if errors.Is(waitErr, errBusyRetryBudget) {
+					return fmt.Errorf("%w: delete result: %w", ErrStoreRetryable, err)
+				}
+				return fmt.Errorf("delete result: %w", waitErr)
+			}
+			continue
+		}
+		return fmt.Errorf("delete result: %w", err)
+	}
+}
+
+// deleteMiss tells a missing result apart from a wrong token.
+func (s *Store) deleteMiss(ctx context.Context, id string) error {
+	busyDeadline := time.Now().Add(busyRetryBudget)
+	for busyAttempt := 0; ; busyAttempt++ {
+		var exists int
+		err := s.db.QueryRowContext(ctx, `SELECT 1 FROM results WHERE id = ?`, id).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err == nil {
+			return ErrInvalidDeleteToken
+		}
+		if isBusyError(err) {
+			if waitErr := waitForBusyRetry(ctx, busyDeadline, busyAttempt); waitErr != nil {
+				if errors.Is(waitErr, errBusyRetryBudget) {
+					return fmt.Errorf("%w: query result: %w", ErrStoreRetryable, err)
+				}
+				return fmt.Errorf("query result: %w", waitErr)
+			}
+			continue
+		}
+		return fmt.Errorf("query result: %w", err)
+	}
+}
+
+func hashDeleteToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,60 @@
+package results
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// resultColumns are the columns scanResult reads, in order.
+const resultColumns = `id, download_mbps, upload_mbps, latency_ms, jitter_ms,
+	loaded_latency_ms, bufferbloat_grade, ipv4, ipv6, server_name, created_at, expires_at,
+	plausibility, asn, as_org, country`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanResult reads resultColumns. Rows saved before per-result expiry have
+// no expires_at and live until the maximum retention. Encrypted fields are
+// opened, and addresses past the IP policy's anonymization age are hidden.
+func (s *Store) scanResult(row rowScanner) (Result, error) {
+	var (
+		r         Result
+		expiresAt sql.NullTime
+	)
+	if err := row.Scan(&r.ID, &r.DownloadMbps, &r.UploadMbps, &r.LatencyMs, &r.JitterMs,
+		&r.LoadedLatencyMs, &r.BufferbloatGrade, &r.IPv4, &r.IPv6, &r.ServerName,
+		&r.CreatedAt, &expiresAt, &r.Plausibility, &r.ASN, &r.ASOrganization, &r.Country); err != nil {
+		return Result{}, err
+	}
+	r.ExpiresAt = r.CreatedAt.Add(s.maxRetention)
+	if expiresAt.Valid && expiresAt.Time.Before(r.ExpiresAt) {
+		r.ExpiresAt = expiresAt.Time
+	}
+	if err := s.openFields(&r); err != nil {
+		return Result{}, fmt.Errorf("result %s: %w", r.ID, err)
+	}
+	s.anonymizeResult(&r, time.Now())
+	return r, nil
+}
+
+// expiresAt resolves the stored expiry for a result saved at now: the
+// requested one, else the default retention, never beyond the maximum.
+func (s *settings) expiresAt(requested, now time.Time) time.Time {
+	latest := now.Add(s.maxRetention)
+	if requested.IsZero() {
+		return now.Add(s.defaultRetention)
+	}
+	if requested.After(latest) {
+		return latest
+	}
+	return requested.UTC()
+}
+
+// liveCondition matches results that have neither expired nor outlived the
+// maximum retention, which may have been lowered since they were saved.
+func (s *Store) liveCondition(now time.Time) (string, []any) {
+	return "(expires_at IS NULL OR expires_at > ?) AND created_at >= ?",
+		[]any{now, now.Add(-s.maxRetention)}
+}
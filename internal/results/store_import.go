@@ -0,0 +1,102 @@
+package results
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrIDExists reports an imported result whose ID is already stored.
+	ErrIDExists = errors.New("result ID already exists")
+	// ErrResultExpired reports an imported result that has already outlived
+	// its own expiry or this store's maximum retention.
+	ErrResultExpired = errors.New("result already expired")
+	// ErrInvalidID reports an imported result ID that this server would not
+	// have issued.
+	ErrInvalidID = errors.New("invalid result ID")
+)
+
+// ValidID reports whether id has the shape of a generated result ID.
+func ValidID(id string) bool {
+	if len(id) != idLength {
+		return false
+	}
+	for i := range len(id) {
+		c := id[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// importable checks an imported result like a saved one and resolves its
+// timestamps: a missing creation time is now, and the expiry follows the
+// same default and cap as a result saved at its creation time.
+func (s *settings) importable(r Result, now time.Time) (Result, error) {
+	if !ValidID(r.ID) {
+		return Result{}, fmt.Errorf("%w: %q", ErrInvalidID, r.ID)
+	}
+	if err := Validate(r); err != nil {
+		return Result{}, fmt.Errorf("result %s: %w", r.ID, err)
+	}
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = now
+	}
+	r.CreatedAt = r.CreatedAt.UTC()
+	r.ExpiresAt = s.expiresAt(r.ExpiresAt, r.CreatedAt)
+	if !r.ExpiresAt.After(now) {
+		return Result{}, fmt.Errorf("%w: %s", ErrResultExpired, r.ID)
+	}
+	r.IPv4, r.IPv6 = s.ipPolicy.apply(r.IPv4), s.ipPolicy.apply(r.IPv6)
+	return r, nil
+}
+
+// Import stores r under its own ID and timestamps, as exported from another
+// server, applying this store's retention and IP policy. It returns
+// ErrIDExists when the ID is taken. Imported results have no delete token.
+func (s *Store) Import(ctx context.Context, r Result) error {
+	r, err := s.importable(r, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	stored := r
+	if stored.IPv4, stored.IPv6, err = s.sealFields(r.ID, r); err != nil {
+		return err
+	}
+	conflict, err := s.insertResultWithRetry(ctx, r.ID, stored, nil, r.CreatedAt, r.ExpiresAt, time.Now().Add(busyRetryBudget))
+	if err != nil {
+		return err
+	}
+	if conflict {
+		return fmt.Errorf("%w: %s", ErrIDExists, r.ID)
+	}
+	return nil
+}
+
+// Import stores r under its own ID and timestamps like Store.Import.
+func (m *MemoryStore) Import(ctx context.Context, r Result) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r, err := m.importable(r, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, taken := m.entries[r.ID]; taken {
+		return fmt.Errorf("%w: %s", ErrIDExists, r.ID)
+	}
+	e := &memoryEntry{Result: r}
+	if m.journal != nil {
+		if err := m.journal.appendSave(*e); err != nil {
+			return fmt.Errorf("insert result: %w", err)
+		}
+	}
+	m.addLocked(e)
+	m.trimLocked()
+	return nil
+}
@@ -142,8 +142,9 @@ func TestMigrateRetriesBusyError(t *testing.T) {
 	if err := migrate(execer); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
-	if len(execer.queries) != 3 {
-		t.Fatalf("ExecContext calls = %d, want 3", len(execer.queries))
+	// The busy CREATE TABLE runs twice, then each column upgrade and index once.
+	if len(execer.queries) != 13 {
+		t.Fatalf("ExecContext calls = %d, want 13", len(execer.queries))
 	}
 	if execer.queries[0] != execer.queries[1] {
 		t.Fatal("migration did not retry the busy statement")
@@ -186,3 +187,228 @@ func (e *busyOnceExecer) ExecContext(_ context.Context, query string, _ ...any)
 	}
 	return nil, nil
 }
+
+func TestStatsBucketsStoredTimestamps(t *testing.T) {
+	store, err := New(memoryPath, 100)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	// Wednesday 2026-10-14 and Monday 2026-10-19 fall in different ISO weeks.
+	for i, createdAt := range []time.Time{
+		time.Date(2026, 10, 14, 9, 15, 0, 123456789, time.UTC),
+		time.Date(2026, 10, 14, 9, 45, 0, 0, time.UTC),
+		time.Date(2026, 10, 14, 23, 59, 59, 0, time.UTC),
+		time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+	} {
+		id := strings.Repeat(string(rune('a'+i)), idLength)
+		if _, err := store.insertResultWithRetry(ctx, id, Result{DownloadMbps: 10}, nil, createdAt, createdAt.Add(DefaultRetention), time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("insert %s: %v", id, err)
+		}
+	}
+
+	tests := []struct {
+		period StatsPeriod
+		starts []time.Time
+		counts []int
+	}{
+		{StatsPeriodHour, []time.Time{
+			time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC),
+			time.Date(2026, 10, 14, 23, 0, 0, 0, time.UTC),
+			time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		}, []int{2, 1, 1}},
+		{StatsPeriodDay, []time.Time{
+			time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		}, []int{3, 1}},
+		{StatsPeriodWeek, []time.Time{
+			time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		}, []int{3, 1}},
+	}
+	for _, tt := range tests {
+		buckets, err := store.Stats(ctx, StatsQuery{Period: tt.period})
+		if err != nil {
+			t.Fatalf("Stats(%s): %v", tt.period, err)
+		}
+		if len(buckets) != len(tt.starts) {
+			t.Fatalf("%s buckets = %+v, want %d", tt.period, buckets, len(tt.starts))
+		}
+		for i, b := range buckets {
+			if !b.Start.Equal(tt.starts[i]) || b.Count != tt.counts[i] {
+				t.Fatalf("%s bucket %d = %s x%d, want %s x%d", tt.period, i, b.Start, b.Count, tt.starts[i], tt.counts[i])
+			}
+		}
+	}
+}
+
+func TestMigrateAddsColumnsToExistingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE results (
+		id TEXT PRIMARY KEY,
+		download_mbps REAL NOT NULL,
+		upload_mbps REAL NOT NULL,
+		latency_ms REAL NOT NULL,
+		jitter_ms REAL NOT NULL,
+		loaded_latency_ms REAL NOT NULL DEFAULT 0,
+		bufferbloat_grade TEXT NOT NULL DEFAULT '',
+		ipv4 TEXT NOT NULL DEFAULT '',
+		ipv6 TEXT NOT NULL DEFAULT '',
+		server_name TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	db.Close()
+
+	store, err := New(path, 10)
+	if err != nil {
+		t.Fatalf("New on legacy database: %v", err)
+	}
+	defer store.Close()
+	saved, err := store.SaveWithDeleteToken(context.Background(), Result{DownloadMbps: 1})
+	if err != nil {
+		t.Fatalf("SaveWithDeleteToken: %v", err)
+	}
+	if err := store.Delete(context.Background(), saved.ID, saved.DeleteToken); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
+
+func TestExpiredResultsAreHiddenAndCleanedUp(t *testing.T) {
+	store, err := New(memoryPath, 100, WithRetention(7*24*time.Hour, 30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	insert := func(id string, createdAt, expiresAt time.Time) {
+		t.Helper()
+		if _, err := store.insertResultWithRetry(ctx, id, Result{}, nil, createdAt, expiresAt, time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("insert %s: %v", id, err)
+		}
+	}
+	insert("live0000", now, now.Add(time.Hour))
+	insert("expired0", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	insert("overmax0", now.Add(-31*24*time.Hour), now.Add(time.Hour))
+	// Rows saved before expires_at existed fall back to the maximum retention.
+	if _, err := store.db.Exec(`INSERT INTO results (id, download_mbps, upload_mbps, latency_ms, jitter_ms, created_at)
+		VALUES ('legacy00', 0, 0, 0, 0, ?), ('legacyol', 0, 0, 0, 0, ?)`,
+		now.Add(-24*time.Hour), now.Add(-40*24*time.Hour)); err != nil {
+		t.Fatalf("insert legacy rows: %v", err)
+	}
+
+	for id, wantLive := range map[string]bool{
+		"live0000": true, "expired0": false, "overmax0": false, "legacy00": true, "legacyol": false,
+	} {
+		got, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", id, err)
+		}
+		if (got != nil) != wantLive {
+			t.Fatalf("Get(%s) = %+v, want live %t", id, got, wantLive)
+		}
+	}
+	legacy, _ := store.Get(ctx, "legacy00")
+	if want := legacy.CreatedAt.Add(30 * 24 * time.Hour); !legacy.ExpiresAt.Equal(want) {
+		t.Fatalf("legacy expires_at = %s, want %s", legacy.ExpiresAt, want)
+	}
+
+	store.cleanup()
+	var remaining int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM results`).Scan(&remaining); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if remaining != 2 {
+		t.Fatalf("rows after cleanup = %d, want 2", remaining)
+	}
+}
+
+func TestSaveAppliesDefaultAndMaximumRetention(t *testing.T) {
+	store, err := New(memoryPath, 100, WithRetention(7*24*time.Hour, 30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now().UTC()
+	for _, tt := range []struct {
+		name      string
+		requested time.Time
+		want      time.Duration
+	}{
+		{"default", time.Time{}, 7 * 24 * time.Hour},
+		{"shorter", now.Add(24 * time.Hour), 24 * time.Hour},
+		{"capped", now.Add(365 * 24 * time.Hour), 30 * 24 * time.Hour},
+	} {
+		saved, err := store.SaveWithDeleteToken(context.Background(), Result{ExpiresAt: tt.requested})
+		if err != nil {
+			t.Fatalf("%s: save: %v", tt.name, err)
+		}
+		if got := saved.ExpiresAt.Sub(now); got < tt.want-time.Minute || got > tt.want+time.Minute {
+			t.Fatalf("%s: lifetime = %s, want about %s", tt.name, got, tt.want)
+		}
+		got, err := store.Get(context.Background(), saved.ID)
+		if err != nil || got == nil || !got.ExpiresAt.Equal(saved.ExpiresAt) {
+			t.Fatalf("%s: Get = %+v, %v; want expires_at %s", tt.name, got, err, saved.ExpiresAt)
+		}
+	}
+}
+
+func TestOldResultsAreAnonymized(t *testing.T) {
+	store, err := New(memoryPath, 100, WithIPPolicy(IPPolicy{AnonymizeAfter: 7 * 24 * time.Hour}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	r := Result{IPv4: "203.0.113.7", IPv6: "2001:db8::7"}
+	for id, createdAt := range map[string]time.Time{
+		"recent00": now.Add(-24 * time.Hour),
+		"old00000": now.Add(-8 * 24 * time.Hour),
+	} {
+		if _, err := store.insertResultWithRetry(ctx, id, r, nil, createdAt, now.Add(time.Hour), time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("insert %s: %v", id, err)
+		}
+	}
+
+	// Reads hide old addresses before cleanup rewrites the row.
+	old, err := store.Get(ctx, "old00000")
+	if err != nil || old == nil {
+		t.Fatalf("Get old: %v", err)
+	}
+	if old.IPv4 != "" || old.IPv6 != "" {
+		t.Fatalf("old result addresses = %q / %q, want hidden", old.IPv4, old.IPv6)
+	}
+
+	store.cleanup()
+	rows := map[string]string{}
+	dbRows, err := store.db.Query(`SELECT id, ipv4 || ipv6 FROM results`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer dbRows.Close()
+	for dbRows.Next() {
+		var id, ips string
+		if err := dbRows.Scan(&id, &ips); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		rows[id] = ips
+	}
+	if rows["old00000"] != "" {
+		t.Fatalf("old row still stores %q after cleanup", rows["old00000"])
+	}
+	if rows["recent00"] != "203.0.113.72001:db8::7" {
+		t.Fatalf("recent row stores %q, want its addresses", rows["recent00"])
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 )
 
 const (
@@ -28,17 +29,52 @@ func migrate(execer execContexter) error {
 		ipv4 TEXT NOT NULL DEFAULT '',
 		ipv6 TEXT NOT NULL DEFAULT '',
 		server_name TEXT NOT NULL DEFAULT '',
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		delete_token_hash BLOB,
+		expires_at TIMESTAMP,
+		plausibility TEXT NOT NULL DEFAULT '',
+		asn INTEGER NOT NULL DEFAULT 0,
+		as_org TEXT NOT NULL DEFAULT '',
+		country TEXT NOT NULL DEFAULT ''
 	)`)
 	if err != nil {
 		return err
 	}
-	_, err = execWithBusyRetry(
-		ctx,
-		execer,
+	// Databases created before a column existed gain it in place.
+	for _, column := range []string{
+		`delete_token_hash BLOB`,
+		`expires_at TIMESTAMP`,
+		`plausibility TEXT NOT NULL DEFAULT ''`,
+		`asn INTEGER NOT NULL DEFAULT 0`,
+		`as_org TEXT NOT NULL DEFAULT ''`,
+		`country TEXT NOT NULL DEFAULT ''`,
+	} {
+		if err := addColumn(ctx, execer, "results", column); err != nil {
+			return err
+		}
+	}
+	for _, index := range []string{
 		`CREATE INDEX IF NOT EXISTS idx_results_created_at ON results(created_at)`,
-	)
-	return err
+		`CREATE INDEX IF NOT EXISTS idx_results_expires_at ON results(expires_at)`,
+		// Listing pages newest first by (created_at, id) and narrows by server
+		// name or grade without scanning the whole table.
+		`CREATE INDEX IF NOT EXISTS idx_results_created_id ON results(created_at, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_results_server_created ON results(server_name, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_results_grade_created ON results(bufferbloat_grade, created_at)`,
+	} {
+		if _, err := execWithBusyRetry(ctx, execer, index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addColumn(ctx context.Context, execer execContexter, table, column string) error {
+	_, err := execWithBusyRetry(ctx, execer, "ALTER TABLE "+table+" ADD COLUMN "+column)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+	return nil
 }
 
 func configureSQLitePool(db *sql.DB, count int) error {
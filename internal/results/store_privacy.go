@@ -0,0 +1,153 @@
+package results
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"time"
+)
+
+// IPMode selects how Save stores the ipv4 and ipv6 fields.
+type IPMode string
+
+const (
+	// IPModeFull stores addresses as submitted.
+	IPModeFull IPMode = "full"
+	// IPModeTruncate stores the enclosing /24 (IPv4) or /48 (IPv6) prefix.
+	IPModeTruncate IPMode = "truncate"
+	// IPModeHash stores a keyed hash, so equal addresses stay comparable
+	// without being recoverable.
+	IPModeHash IPMode = "hash"
+	// IPModeDrop stores no addresses.
+	IPModeDrop IPMode = "drop"
+)
+
+const (
+	truncateBitsIPv4 = 24
+	truncateBitsIPv6 = 48
+	// ipHashBytes keeps hashes within the 45-byte field limit as hex.
+	ipHashBytes = 16
+)
+
+// ErrInvalidIPPolicy reports an unknown mode or a hash mode without a key.
+var ErrInvalidIPPolicy = errors.New("invalid IP policy")
+
+// IPPolicy controls how client addresses are kept. The zero value stores
+// them as submitted and never anonymizes them.
+type IPPolicy struct {
+	Mode IPMode
+	// HashKey is the HMAC key for IPModeHash.
+	HashKey []byte
+	// AnonymizeAfter drops addresses from results older than this. Zero
+	// keeps them for the result's whole lifetime.
+	AnonymizeAfter time.Duration
+}
+
+// Validate reports whether the policy can be applied.
+func (p IPPolicy) Validate() error {
+	switch p.Mode {
+	case "", IPModeFull, IPModeTruncate, IPModeDrop:
+	case IPModeHash:
+		if len(p.HashKey) == 0 {
+			return fmt.Errorf("%w: hash mode needs a key", ErrInvalidIPPolicy)
+		}
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidIPPolicy, p.Mode)
+	}
+	if p.AnonymizeAfter < 0 {
+		return fmt.Errorf("%w: negative anonymize age", ErrInvalidIPPolicy)
+	}
+	return nil
+}
+
+// WithIPPolicy applies p to every saved result. Callers validate p first;
+// an invalid policy drops addresses rather than storing them.
+func WithIPPolicy(p IPPolicy) Option {
+	return func(s *settings) {
+		if p.Validate() != nil {
+			p = IPPolicy{Mode: IPModeDrop, AnonymizeAfter: p.AnonymizeAfter}
+		}
+		s.ipPolicy = p
+	}
+}
+
+// apply transforms one submitted address for storage.
+func (p IPPolicy) apply(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	switch p.Mode {
+	case "", IPModeFull:
+		return raw
+	case IPModeTruncate:
+		// Imported results may already hold a truncated prefix.
+		addr, err := netip.ParseAddr(raw)
+		have := -1
+		if err != nil {
+			prefix, prefixErr := netip.ParsePrefix(raw)
+			if prefixErr != nil {
+				return ""
+			}
+			addr, have = prefix.Addr(), prefix.Bits()
+		}
+		addr = addr.Unmap().WithZone("")
+		bits := truncateBitsIPv6
+		if addr.Is4() {
+			bits = truncateBitsIPv4
+		}
+		if have >= 0 {
+			bits = min(bits, have)
+		}
+		return netip.PrefixFrom(addr, bits).Masked().String()
+	case IPModeHash:
+		// Hash the canonical form so spellings of one address agree.
+		if addr, err := netip.ParseAddr(raw); err == nil {
+			raw = addr.Unmap().WithZone("").String()
+		} else if isIPHash(raw) {
+			// Imported results may already hold a hash; no address looks
+			// like one.
+			return raw
+		}
+		mac := hmac.New(sha256.New, p.HashKey)
+		mac.Write([]byte(raw))
+		return hex.EncodeToString(mac.Sum(nil)[:ipHashBytes])
+	default:
+		return ""
+	}
+}
+
+// anonymizeResult hides addresses that outlived AnonymizeAfter, so reads
+// honor the policy before the cleanup pass has rewritten the row.
+func (s *settings) anonymizeResult(r *Result, now time.Time) {
+	if s.ipPolicy.AnonymizeAfter > 0 && !r.CreatedAt.After(now.Add(-s.ipPolicy.AnonymizeAfter)) {
+		r.IPv4, r.IPv6 = "", ""
+	}
+}
+
+// anonymizeOld clears addresses of results older than AnonymizeAfter.
+func (s *Store) anonymizeOld(ctx context.Context, now time.Time) {
+	if s.ipPolicy.AnonymizeAfter <= 0 {
+		return
+	}
+	res, err := execWithBusyRetry(ctx, s.db,
+		`UPDATE results SET ipv4 = '', ipv6 = '' WHERE created_at <= ? AND (ipv4 != '' OR ipv6 != '')`,
+		now.Add(-s.ipPolicy.AnonymizeAfter))
+	if err != nil {
+		slog.Warn("results cleanup (anonymize) failed", "error", err)
+		return
+	}
+	s.logCleanupCount("results cleanup: anonymized addresses", "count", res)
+}
+
+func isIPHash(s string) bool {
+	if len(s) != 2*ipHashBytes {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
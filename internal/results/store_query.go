@@ -0,0 +1,219 @@
+package results
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultListLimit and MaxListLimit bound one List page.
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+	// MaxPrefixScan bounds the rows one List call reads when filtering by
+	// IPPrefix, which is matched outside SQL.
+	MaxPrefixScan = 2000
+)
+
+// ErrInvalidCursor reports a List cursor that was not issued by this store.
+var ErrInvalidCursor = errors.New("invalid results cursor")
+
+// SpeedRange bounds a throughput column in Mbps. A zero Max is unbounded.
+type SpeedRange struct {
+	Min float64
+	Max float64
+}
+
+func (r SpeedRange) appendWhere(column string, where []string, args []any) ([]string, []any) {
+	if r.Min > 0 {
+		where = append(where, column+" >= ?")
+		args = append(args, r.Min)
+	}
+	if r.Max > 0 {
+		where = append(where, column+" <= ?")
+		args = append(args, r.Max)
+	}
+	return where, args
+}
+
+// Query selects results for List. Zero fields do not filter.
+type Query struct {
+	// From and To bound created_at as [From, To).
+	From time.Time
+	To   time.Time
+	// ServerName matches exactly.
+	ServerName string
+	Download   SpeedRange
+	Upload     SpeedRange
+	// Grades matches any of the listed bufferbloat grades.
+	Grades []string
+	// IPPrefix matches results whose IPv4 or IPv6 address lies in the prefix.
+	// A page that stops after MaxPrefixScan rows may hold fewer results
+	// than Limit, or none, and still have a NextCursor.
+	IPPrefix netip.Prefix
+	// Cursor continues from the NextCursor of a previous page.
+	Cursor string
+	// Limit defaults to DefaultListLimit and is capped at MaxListLimit.
+	Limit int
+}
+
+// Page is one List page, newest first. NextCursor is empty on the last page.
+type Page struct {
+	Results    []Result
+	NextCursor string
+}
+
+// List returns results matching q, newest first, using keyset pagination on
+// (created_at, id) so pages stay stable while new results arrive.
+func (s *Store) List(ctx context.Context, q Query) (Page, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	where, args, err := q.where()
+	if err != nil {
+		return Page{}, err
+	}
+	live, liveArgs := s.liveCondition(time.Now().UTC())
+	where = append(where, live)
+	args = append(args, liveArgs...)
+	query := `SELECT ` + resultColumns + ` FROM results WHERE ` + strings.Join(where, " AND ")
+	query += " ORDER BY created_at DESC, id DESC"
+	// Stored addresses are text, so prefix matching happens here and the
+	// scan continues until the page is full or MaxPrefixScan rows are read.
+	query += " LIMIT ?"
+	if q.IPPrefix.IsValid() {
+		args = append(args, MaxPrefixScan+1)
+	} else {
+		args = append(args, limit+1)
+	}
+
+	busyDeadline := time.Now().Add(busyRetryBudget)
+	for busyAttempt := 0; ; busyAttempt++ {
+		page, err := s.scanPage(ctx, query, args, q.IPPrefix, limit)
+		if err == nil {
+			return page, nil
+		}
+		if isBusyError(err) {
+			if waitErr := waitForBusyRetry(ctx, busyDeadline, busyAttempt); waitErr != nil {
+				if errors.Is(waitErr, errBusyRetryBudget) {
+					return Page{}, fmt.Errorf("%w: list results: %w", ErrStoreRetryable, err)
+				}
+				return Page{}, fmt.Errorf("list results: %w", waitErr)
+			}
+			continue
+		}
+		return Page{}, fmt.Errorf("list results: %w", err)
+	}
+}
+
+func (s *Store) scanPage(ctx context.Context, query string, args []any, prefix netip.Prefix, limit int) (Page, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return Page{}, err
+	}
+	defer rows.Close()
+
+	page := Page{Results: make([]Result, 0, min(limit, DefaultListLimit))}
+	var scanned int
+	var last Result
+	for rows.Next() {
+		if scanned == MaxPrefixScan {
+			// More rows remain; continue after the last one read.
+			page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+			break
+		}
+		r, err := s.scanResult(rows)
+		if err != nil {
+			return Page{}, err
+		}
+		scanned, last = scanned+1, r
+		if prefix.IsValid() && !resultInPrefix(r, prefix) {
+			continue
+		}
+		if len(page.Results) == limit {
+			last := page.Results[limit-1]
+			page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+			break
+		}
+		page.Results = append(page.Results, r)
+	}
+	return page, rows.Err()
+}
+
+func (q Query) where() ([]string, []any, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if !q.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, q.To.UTC())
+	}
+	if q.ServerName != "" {
+		where = append(where, "server_name = ?")
+		args = append(args, q.ServerName)
+	}
+	where, args = q.Download.appendWhere("download_mbps", where, args)
+	where, args = q.Upload.appendWhere("upload_mbps", where, args)
+	if len(q.Grades) > 0 {
+		where = append(where, "bufferbloat_grade IN (?"+strings.Repeat(", ?", len(q.Grades)-1)+")")
+		for _, grade := range q.Grades {
+			args = append(args, grade)
+		}
+	}
+	if q.Cursor != "" {
+		createdAt, id, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		where = append(where, "(created_at < ? OR (created_at = ? AND id < ?))")
+		args = append(args, createdAt, createdAt, id)
+	}
+	return where, args, nil
+}
+
+// resultInPrefix also matches addresses stored truncated to a prefix when
+// the two prefixes overlap.
+func resultInPrefix(r Result, prefix netip.Prefix) bool {
+	for _, raw := range []string{r.IPv4, r.IPv6} {
+		if addr, err := netip.ParseAddr(raw); err == nil && prefix.Contains(addr.Unmap()) {
+			return true
+		}
+		if stored, err := netip.ParsePrefix(raw); err == nil && prefix.Overlaps(stored) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeCursor packs the last row's sort key; it is opaque to clients.
+func encodeCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || len(id) != idLength {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return createdAt.UTC(), id, nil
+}
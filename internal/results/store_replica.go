@@ -0,0 +1,446 @@
+package results
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A replica directory holds a base snapshot and a log of the saves and
+// owner deletions committed since it was taken. While a new base is being
+// taken, changes go to a second log that is replayed after the first.
+// Expiry, trimming, and anonymization are not logged; the store reapplies
+// them when a restored database is opened.
+const (
+	replicaBase    = "base.db"
+	replicaLog     = "changes.ndjson"
+	replicaNextLog = "changes.next.ndjson"
+	// replicaRetryInterval is how often a replica that missed a change or
+	// failed to snapshot is brought back in sync.
+	replicaRetryInterval = time.Minute
+	// replicaCheckDelay is how long CheckReplica waits before looking again
+	// for results the replica lacks; see CheckReplica.
+	replicaCheckDelay = 500 * time.Millisecond
+)
+
+// ErrNoReplica reports a directory without a replica base snapshot.
+var ErrNoReplica = errors.New("no results replica")
+
+// replicator appends committed changes to the replica log. After a failed
+// append the log has a gap, so it stops appending until the next rebase.
+type replicator struct {
+	dir      string
+	mu       sync.Mutex
+	log      *os.File
+	stale    bool
+	retry    bool
+	rebased  time.Time
+	interval time.Duration
+}
+
+// Replicate ships every save and owner deletion to dir as it commits and
+// refreshes the replica's base snapshot every interval, which also drops the
+// accumulated log. It starts with a fresh base snapshot.
+func (s *Store) Replicate(ctx context.Context, dir string, interval time.Duration) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create replica directory: %w", err)
+	}
+	r := &replicator{dir: dir, interval: interval}
+	if err := s.rebase(ctx, r); err != nil {
+		return err
+	}
+	s.replica.Store(r)
+	s.wg.Add(1)
+	go s.replicaLoop(r)
+	return nil
+}
+
+func (s *Store) replicaLoop(r *replicator) {
+	defer s.wg.Done()
+	// Closing the store interrupts a snapshot in progress.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	ticker := time.NewTicker(min(r.interval, replicaRetryInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			due := r.stale || r.retry || time.Since(r.rebased) >= r.interval
+			r.mu.Unlock()
+			if !due {
+				continue
+			}
+			if err := s.rebase(ctx, r); err != nil && ctx.Err() == nil {
+				slog.Error("results replica: snapshot failed", "dir", r.dir, "error", err)
+			}
+		}
+	}
+}
+
+// rebase replaces the replica's base snapshot. It first moves appends to the
+// next log, then snapshots without blocking them, so saves keep committing
+// during the VACUUM. Every change is then in the new base or the next log,
+// and until the next log replaces the old one a restore replays both;
+// replaying a change the base already has is harmless. Only one rebase runs
+// at a time.
+func (s *Store) rebase(ctx context.Context, r *replicator) error {
+	next := filepath.Join(r.dir, replicaNextLog)
+	// A next log left by a failed rebase holds changes the base lacks, so
+	// it is appended to rather than truncated.
+	log, err := os.OpenFile(next, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return r.failed(fmt.Errorf("replica log: %w", err))
+	}
+	r.mu.Lock()
+	if r.log != nil {
+		r.log.Close()
+	}
+	r.log, r.stale = log, false
+	r.mu.Unlock()
+
+	tmp := filepath.Join(r.dir, replicaBase+".tmp")
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return r.failed(fmt.Errorf("replica snapshot: %w", err))
+	}
+	if _, err := execWithBusyRetry(ctx, s.db, `VACUUM INTO ?`, tmp); err != nil {
+		os.Remove(tmp)
+		return r.failed(fmt.Errorf("replica snapshot: %w", err))
+	}
+	if err := syncFile(tmp); err != nil {
+		os.Remove(tmp)
+		return r.failed(fmt.Errorf("replica snapshot: %w", err))
+	}
+	if err := os.Rename(tmp, filepath.Join(r.dir, replicaBase)); err != nil {
+		return r.failed(fmt.Errorf("replica snapshot: %w", err))
+	}
+	// Appends continue through the open file while it is renamed.
+	if err := os.Rename(next, filepath.Join(r.dir, replicaLog)); err != nil {
+		return r.failed(fmt.Errorf("replica log: %w", err))
+	}
+	r.mu.Lock()
+	r.retry = false
+	r.rebased = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// failed schedules another rebase after err.
+func (r *replicator) failed(err error) error {
+	r.mu.Lock()
+	r.retry = true
+	r.mu.Unlock()
+	return err
+}
+
+// syncFile flushes a file written by SQLite and restricts it to the owner.
+func syncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replicate appends rec to the replica log, if replication is on. A failure
+// does not fail the change; the replica is re-based instead.
+func (s *Store) replicate(rec journalRecord) {
+	r := s.replica.Load()
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stale || r.log == nil {
+		return
+	}
+	line, err := json.Marshal(rec)
+	if err == nil {
+		_, err = r.log.Write(append(line, '\n'))
+	}
+	if err == nil {
+		err = r.log.Sync()
+	}
+	if err != nil {
+		r.stale = true
+		slog.Error("results replica: append failed; re-snapshotting", "dir", r.dir, "error", err)
+	}
+}
+
+// markReplicaStale forces a fresh base snapshot after changes the log does
+// not carry, such as re-encryption.
+func (s *Store) markReplicaStale() {
+	if r := s.replica.Load(); r != nil {
+		r.mu.Lock()
+		r.stale = true
+		r.mu.Unlock()
+	}
+}
+
+func (s *Store) closeReplica() {
+	if r := s.replica.Load(); r != nil && r.log != nil {
+		r.log.Close()
+	}
+}
+
+// materializeReplica writes the base snapshot of dir with its log replayed
+// to out and returns the number of results. A torn final log line, left by
+// a crash mid-append, is skipped.
+func materializeReplica(ctx context.Context, dir, out string) (int, error) {
+	base := filepath.Join(dir, replicaBase)
+	if _, err := os.Stat(base); errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("%w in %s", ErrNoReplica, dir)
+	}
+	if _, err := ValidateSnapshot(ctx, base); err != nil {
+		return 0, err
+	}
+	tmp, err := copyToTemp(base, out)
+	if err != nil {
+		return 0, fmt.Errorf("copy replica base: %w", err)
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("copy replica base: %w", err)
+	}
+	db, err := sql.Open("sqlite", out)
+	if err != nil {
+		return 0, fmt.Errorf("open replica: %w", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	// Bases taken before a column existed gain it like a live database.
+	if err := migrate(db); err != nil {
+		return 0, fmt.Errorf("migrate replica: %w", err)
+	}
+	for _, name := range []string{replicaLog, replicaNextLog} {
+		if err := replayReplicaLog(ctx, db, filepath.Join(dir, name)); err != nil {
+			return 0, err
+		}
+	}
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM results`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count replica: %w", err)
+	}
+	return count, nil
+}
+
+func replayReplicaLog(ctx context.Context, db *sql.DB, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open replica log: %w", err)
+	}
+	defer f.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 4096), maxJournalLine)
+	var pending error
+	for line := 1; scanner.Scan(); line++ {
+		if pending != nil {
+			return pending
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			pending = fmt.Errorf("replica log line %d: %w", line, err)
+			continue
+		}
+		switch {
+		case rec.Op == journalSave && rec.Result != nil && rec.Result.ID != "":
+			r := rec.Result
+			_, err = tx.ExecContext(ctx,
+				`INSERT OR REPLACE INTO results (id, download_mbps, upload_mbps, latency_ms, jitter_ms,
+					loaded_latency_ms, bufferbloat_grade, ipv4, ipv6, server_name, created_at,
+					expires_at, delete_token_hash, plausibility, asn, as_org, country)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				r.ID, r.DownloadMbps, r.UploadMbps, r.LatencyMs, r.JitterMs,
+				r.LoadedLatencyMs, r.BufferbloatGrade, r.IPv4, r.IPv6, r.ServerName,
+				r.CreatedAt.UTC(), r.ExpiresAt.UTC(), rec.DeleteTokenHash, r.Plausibility,
+				r.ASN, r.ASOrganization, r.Country)
+		case rec.Op == journalDelete:
+			_, err = tx.ExecContext(ctx, `DELETE FROM results WHERE id = ?`, rec.ID)
+		default:
+			pending = fmt.Errorf("replica log line %d: unknown record", line)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("replica log line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read replica log: %w", err)
+	}
+	if pending != nil {
+		slog.Warn("results replica: skipped torn final record", "error", pending)
+	}
+	return tx.Commit()
+}
+
+// RestoreReplica rebuilds the database at dbPath from the replica in dir,
+// keeping the replaced database as dbPath+".pre-restore" like
+// RestoreSnapshot. No server may have dbPath open while it runs.
+func RestoreReplica(ctx context.Context, dir, dbPath string) (int, error) {
+	tmp := dbPath + ".replica"
+	defer os.Remove(tmp)
+	if _, err := materializeReplica(ctx, dir, tmp); err != nil {
+		return 0, err
+	}
+	return RestoreSnapshot(ctx, tmp, dbPath)
+}
+
+// ReplicaReport compares a replica with the database it follows.
+type ReplicaReport struct {
+	// Results is the number of results the replica would restore.
+	Results int
+	// Missing lists results in the database that the replica lacks, and
+	// Mismatched those whose stored values differ.
+	Missing    []string
+	Mismatched []string
+	// Pending counts results only the replica still has, such as expired
+	// ones cleaned up since its last snapshot. Restoring cleans them up too.
+	Pending int
+}
+
+// Consistent reports whether restoring the replica loses or alters nothing.
+func (r ReplicaReport) Consistent() bool {
+	return len(r.Missing) == 0 && len(r.Mismatched) == 0
+}
+
+// CheckReplica rebuilds the replica in dir in a temporary file and compares
+// it with the database at dbPath. It may run next to a live server. A save
+// is logged just after it commits, so a result saved during the check can
+// briefly be missing from the replica; CheckReplica looks again after a
+// short delay and reports only results missing both times.
+func CheckReplica(ctx context.Context, dir, dbPath string) (ReplicaReport, error) {
+	report, err := checkReplica(ctx, dir, dbPath)
+	if err != nil || len(report.Missing) == 0 {
+		return report, err
+	}
+	select {
+	case <-time.After(replicaCheckDelay):
+	case <-ctx.Done():
+		return ReplicaReport{}, ctx.Err()
+	}
+	again, err := checkReplica(ctx, dir, dbPath)
+	if err != nil {
+		return ReplicaReport{}, err
+	}
+	again.Missing = slices.DeleteFunc(again.Missing, func(id string) bool {
+		_, missedBefore := slices.BinarySearch(report.Missing, id)
+		return !missedBefore
+	})
+	return again, nil
+}
+
+func checkReplica(ctx context.Context, dir, dbPath string) (ReplicaReport, error) {
+	live, err := storedFingerprints(ctx, dbPath)
+	if err != nil {
+		return ReplicaReport{}, fmt.Errorf("read database: %w", err)
+	}
+	tmpDir, err := os.MkdirTemp("", "openbyte-replica-*")
+	if err != nil {
+		return ReplicaReport{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+	rebuilt := filepath.Join(tmpDir, replicaBase)
+	count, err := materializeReplica(ctx, dir, rebuilt)
+	if err != nil {
+		return ReplicaReport{}, err
+	}
+	replica, err := storedFingerprints(ctx, rebuilt)
+	if err != nil {
+		return ReplicaReport{}, fmt.Errorf("read replica: %w", err)
+	}
+
+	report := ReplicaReport{Results: count}
+	for id, want := range live {
+		got, ok := replica[id]
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, id)
+		case got != want:
+			report.Mismatched = append(report.Mismatched, id)
+		}
+	}
+	for id := range replica {
+		if _, ok := live[id]; !ok {
+			report.Pending++
+		}
+	}
+	slices.Sort(report.Missing)
+	slices.Sort(report.Mismatched)
+	return report, nil
+}
+
+// storedFingerprints reads every stored row of the database at path, keyed
+// by ID, as a comparable string of its column values.
+func storedFingerprints(ctx context.Context, path string) (map[string]string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.QueryContext(ctx, `SELECT `+resultColumns+`, delete_token_hash FROM results`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	fingerprints := make(map[string]string)
+	for rows.Next() {
+		var (
+			r         Result
+			expiresAt sql.NullTime
+			tokenHash []byte
+		)
+		if err := rows.Scan(&r.ID, &r.DownloadMbps, &r.UploadMbps, &r.LatencyMs, &r.JitterMs,
+			&r.LoadedLatencyMs, &r.BufferbloatGrade, &r.IPv4, &r.IPv6, &r.ServerName,
+			&r.CreatedAt, &expiresAt, &r.Plausibility, &r.ASN, &r.ASOrganization, &r.Country,
+			&tokenHash); err != nil {
+			return nil, err
+		}
+		r.CreatedAt = r.CreatedAt.UTC()
+		if expiresAt.Valid {
+			r.ExpiresAt = expiresAt.Time.UTC()
+		}
+		fingerprints[r.ID] = fmt.Sprintf("%+v|%x", r, tokenHash)
+	}
+	return fingerprints, rows.Err()
+}
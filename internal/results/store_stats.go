@@ -0,0 +1,193 @@
+package results
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatsPeriod groups Stats buckets by time. The zero value aggregates the
+// whole range into one bucket per server (or one overall).
+type StatsPeriod string
+
+const (
+	StatsPeriodNone StatsPeriod = ""
+	StatsPeriodHour StatsPeriod = "hour"
+	StatsPeriodDay  StatsPeriod = "day"
+	StatsPeriodWeek StatsPeriod = "week"
+)
+
+// bucketExpr maps a period to the UTC bucket start. created_at is stored as
+// Go's time.String form, whose first 19 bytes SQLite date functions parse.
+// Weeks start on Monday.
+func (p StatsPeriod) bucketExpr() (string, bool) {
+	switch p {
+	case StatsPeriodNone:
+		return "''", true
+	case StatsPeriodHour:
+		return "strftime('%Y-%m-%dT%H:00:00Z', substr(created_at, 1, 19))", true
+	case StatsPeriodDay:
+		return "strftime('%Y-%m-%dT00:00:00Z', substr(created_at, 1, 19))", true
+	case StatsPeriodWeek:
+		return "strftime('%Y-%m-%dT00:00:00Z', substr(created_at, 1, 19), '-6 days', 'weekday 1')", true
+	default:
+		return "", false
+	}
+}
+
+// ErrInvalidStatsPeriod reports an unknown StatsPeriod.
+var ErrInvalidStatsPeriod = errors.New("invalid stats period")
+
+// StatsQuery selects and groups results for Stats.
+type StatsQuery struct {
+	// From and To bound created_at as [From, To).
+	From       time.Time
+	To         time.Time
+	ServerName string
+	Period     StatsPeriod
+	// ByServer splits each bucket by server name.
+	ByServer bool
+}
+
+// MetricStats summarizes one metric. Percentiles use the nearest-rank method.
+type MetricStats struct {
+	Avg float64 `json:"avg"`
+	P10 float64 `json:"p10"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+}
+
+// StatsBucket aggregates the results in one period and server group. Start
+// is zero without a period; ServerName is empty unless grouping by server.
+type StatsBucket struct {
+	Start        time.Time   `json:"start,omitzero"`
+	ServerName   string      `json:"server_name,omitempty"`
+	Count        int         `json:"count"`
+	DownloadMbps MetricStats `json:"download_mbps"`
+	UploadMbps   MetricStats `json:"upload_mbps"`
+	LatencyMs    MetricStats `json:"latency_ms"`
+}
+
+// statsMetrics are the columns Stats summarizes, in StatsBucket order.
+var statsMetrics = []string{"download_mbps", "upload_mbps", "latency_ms"}
+
+// Stats aggregates results in SQLite. Window functions rank each metric
+// within its group so percentiles come from a single sorted pass instead of
+// loading rows into Go.
+func (s *Store) Stats(ctx context.Context, q StatsQuery) ([]StatsBucket, error) {
+	bucket, ok := q.Period.bucketExpr()
+	if !ok {
+		return nil, ErrInvalidStatsPeriod
+	}
+	server := "''"
+	if q.ByServer {
+		server = "server_name"
+	}
+	where, args := q.where(s.liveCondition(time.Now().UTC()))
+
+	samples := make([]string, 0, len(statsMetrics))
+	for i, metric := range statsMetrics {
+		samples = append(samples, fmt.Sprintf(
+			"SELECT %d AS metric, %s AS bucket, %s AS server, %s AS v FROM results%s",
+			i, bucket, server, metric, where))
+	}
+	query := `WITH samples AS (` + strings.Join(samples, " UNION ALL ") + `),
+		ranked AS (
+			SELECT metric, bucket, server, v,
+				ROW_NUMBER() OVER (PARTITION BY metric, bucket, server ORDER BY v) AS rn,
+				COUNT(*) OVER (PARTITION BY metric, bucket, server) AS n
+			FROM samples
+		)
+		SELECT metric, bucket, server, n, AVG(v),
+			MIN(CASE WHEN rn >= 0.1 * n THEN v END),
+			MIN(CASE WHEN rn >= 0.5 * n THEN v END),
+			MIN(CASE WHEN rn >= 0.9 * n THEN v END)
+		FROM ranked
+		GROUP BY metric, bucket, server
+		ORDER BY bucket, server, metric`
+	// Every metric sample repeats the WHERE arguments.
+	allArgs := make([]any, 0, len(args)*len(statsMetrics))
+	for range statsMetrics {
+		allArgs = append(allArgs, args...)
+	}
+
+	busyDeadline := time.Now().Add(busyRetryBudget)
+	for busyAttempt := 0; ; busyAttempt++ {
+		buckets, err := s.scanStats(ctx, query, allArgs)
+		if err == nil {
+			return buckets, nil
+		}
+		if isBusyError(err) {
+			if waitErr := waitForBusyRetry(ctx, busyDeadline, busyAttempt); waitErr != nil {
+				if errors.Is(waitErr, errBusyRetryBudget) {
+					return nil, fmt.Errorf("%w: aggregate results: %w", ErrStoreRetryable, err)
+				}
+				return nil, fmt.Errorf("aggregate results: %w", waitErr)
+			}
+			continue
+		}
+		return nil, fmt.Errorf("aggregate results: %w", err)
+	}
+}
+
+func (s *Store) scanStats(ctx context.Context, query string, args []any) ([]StatsBucket, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []StatsBucket{}
+	for rows.Next() {
+		var (
+			metric         int
+			bucket, server string
+			count          int
+			m              MetricStats
+		)
+		if err := rows.Scan(&metric, &bucket, &server, &count, &m.Avg, &m.P10, &m.P50, &m.P90); err != nil {
+			return nil, err
+		}
+		if n := len(buckets); n == 0 || metric == 0 {
+			b := StatsBucket{ServerName: server, Count: count}
+			if bucket != "" {
+				if b.Start, err = time.Parse(time.RFC3339, bucket); err != nil {
+					return nil, fmt.Errorf("parse bucket %q: %w", bucket, err)
+				}
+			}
+			buckets = append(buckets, b)
+		}
+		b := &buckets[len(buckets)-1]
+		switch metric {
+		case 0:
+			b.DownloadMbps = m
+		case 1:
+			b.UploadMbps = m
+		case 2:
+			b.LatencyMs = m
+		}
+	}
+	return buckets, rows.Err()
+}
+
+// where filters the live results matched by live to the query's range and
+// server.
+func (q StatsQuery) where(live string, liveArgs []any) (string, []any) {
+	where := []string{live}
+	args := append([]any(nil), liveArgs...)
+	if !q.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, q.To.UTC())
+	}
+	if q.ServerName != "" {
+		where = append(where, "server_name = ?")
+		args = append(args, q.ServerName)
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
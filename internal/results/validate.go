@@ -0,0 +1,73 @@
+package results
+
+import (
+	"errors"
+	"net/netip"
+)
+
+// Limits on the client-reported parts of a result.
+const (
+	MaxMbps             = 100000
+	MaxMs               = 60000
+	MaxServerNameLength = 200
+	MaxIPLength         = 45
+	MaxGradeLength      = 5
+)
+
+var (
+	// ErrNegativeMetric reports a speed or latency below zero.
+	ErrNegativeMetric = errors.New("numeric fields must be >= 0")
+	// ErrMetricOutOfRange reports a speed above MaxMbps, a latency above
+	// MaxMs, or a value that is not a finite number.
+	ErrMetricOutOfRange = errors.New("values out of reasonable range")
+	// ErrFieldTooLong reports a text field longer than its limit.
+	ErrFieldTooLong = errors.New("field too long")
+	// ErrInvalidIP reports an ipv4 or ipv6 field that holds neither an
+	// address nor a form the IP policy stores.
+	ErrInvalidIP = errors.New("ipv4 and ipv6 must be IP addresses")
+)
+
+// Validate checks the metrics and text fields a client reports, which
+// saved and imported results must both satisfy.
+func Validate(r Result) error {
+	speeds := []float64{r.DownloadMbps, r.UploadMbps}
+	latencies := []float64{r.LatencyMs, r.JitterMs, r.LoadedLatencyMs}
+	for _, v := range append(speeds, latencies...) {
+		if v < 0 {
+			return ErrNegativeMetric
+		}
+	}
+	// Negated comparisons also reject NaN, which JSON cannot encode.
+	for _, v := range speeds {
+		if !(v <= MaxMbps) {
+			return ErrMetricOutOfRange
+		}
+	}
+	for _, v := range latencies {
+		if !(v <= MaxMs) {
+			return ErrMetricOutOfRange
+		}
+	}
+	if len(r.ServerName) > MaxServerNameLength || len(r.IPv4) > MaxIPLength || len(r.IPv6) > MaxIPLength ||
+		len(r.BufferbloatGrade) > MaxGradeLength {
+		return ErrFieldTooLong
+	}
+	if !validIP(r.IPv4) || !validIP(r.IPv6) {
+		return ErrInvalidIP
+	}
+	return nil
+}
+
+// validIP accepts an empty field, an address, or the truncated prefix or
+// hash an exported result may hold. Anything else could pass for a sealed
+// field on read.
+func validIP(s string) bool {
+	if s == "" || isIPHash(s) {
+		return true
+	}
+	if _, err := netip.ParseAddr(s); err == nil {
+		return true
+	}
+	_, err := netip.ParsePrefix(s)
+	return err == nil
+}
@@ -418,6 +418,44 @@ test.describe("openByte UI", () => {
     );
   });
 
+  test("lets the sharing browser delete its result", async ({
+    page,
+    request,
+  }) => {
+    const create = await request.post("/api/v1/results", {
+      data: { download_mbps: 50, upload_mbps: 10 },
+    });
+    expect(create.ok()).toBeTruthy();
+    const payload = await create.json();
+    expect(payload.delete_token).toMatch(/^obd_/);
+
+    await page.goto("/results/" + payload.id);
+    await expect(page.locator("#resultView")).toBeVisible();
+    await expect(page.locator("#deleteResultBtn")).toBeHidden();
+
+    await page.evaluate(
+      ([id, token]) =>
+        localStorage.setItem(
+          "openbyte-delete-tokens",
+          JSON.stringify([{ id, token }]),
+        ),
+      [payload.id, payload.delete_token],
+    );
+    await page.reload();
+    page.once("dialog", (dialog) => dialog.accept());
+    await page.locator("#deleteResultBtn").click();
+
+    await expect(page.locator("#errorView .error-message")).toHaveText(
+      "This result has been deleted.",
+    );
+    await expect(page.locator("#errorCode")).toBeHidden();
+    expect(
+      await page.evaluate(() => localStorage.getItem("openbyte-delete-tokens")),
+    ).toBeNull();
+    const get = await request.get("/api/v1/results/" + payload.id);
+    expect(get.status()).toBe(404);
+  });
+
   test("shows error view for invalid shared result id", async ({ page }) => {
     const response = await page.goto("/results/invalid-id");
     expect(response).toBeTruthy();
@@ -0,0 +1,126 @@
+package accesslist_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/accesslist"
+)
+
+const accessListRules = `# monitoring probes
+allow 192.0.2.0/24
+allow 2001:db8::/32
+deny 192.0.2.66   # compromised probe
+deny 198.51.100.0/24
+`
+
+func writeAccessList(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write access list: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes access list: %v", err)
+	}
+}
+
+func TestAccessListCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), accesslist.FileName)
+	writeAccessList(t, path, accessListRules, time.Unix(1000, 0))
+	list, err := accesslist.Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want accesslist.Decision
+	}{
+		{ip: "192.0.2.10", want: accesslist.Allowed},
+		{ip: "2001:db8::1", want: accesslist.Allowed},
+		{ip: "192.0.2.66", want: accesslist.Denied},
+		{ip: "198.51.100.7", want: accesslist.Denied},
+		{ip: "203.0.113.1", want: accesslist.Neutral},
+	}
+	for _, test := range tests {
+		if got := list.Check(net.ParseIP(test.ip)); got != test.want {
+			t.Fatalf("Check(%s) = %v, want %v", test.ip, got, test.want)
+		}
+	}
+	if got := list.Check(nil); got != accesslist.Neutral {
+		t.Fatalf("Check(nil) = %v, want neutral", got)
+	}
+}
+
+func TestAccessListMissingFileIsEmpty(t *testing.T) {
+	list, err := accesslist.Load(filepath.Join(t.TempDir(), accesslist.FileName))
+	if err != nil {
+		t.Fatalf("load missing file: %v", err)
+	}
+	if got := list.Check(net.ParseIP("198.51.100.7")); got != accesslist.Neutral {
+		t.Fatalf("Check = %v, want neutral", got)
+	}
+}
+
+func TestAccessListRejectsMalformedRules(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown action": "block 192.0.2.0/24\n",
+		"bad cidr":       "deny 192.0.2.0/33\n",
+		"missing target": "deny\n",
+		"extra field":    "deny 192.0.2.1 now\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), accesslist.FileName)
+			writeAccessList(t, path, content, time.Unix(1000, 0))
+			if _, err := accesslist.Load(path); err == nil {
+				t.Fatal("expected malformed access list to be rejected")
+			}
+		})
+	}
+}
+
+func TestAccessListReloadIfChangedKeepsRulesOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), accesslist.FileName)
+	writeAccessList(t, path, "deny 198.51.100.0/24\n", time.Unix(1000, 0))
+	list, err := accesslist.Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	blocked := net.ParseIP("198.51.100.7")
+
+	if err := list.ReloadIfChanged(); err != nil {
+		t.Fatalf("unchanged reload: %v", err)
+	}
+
+	writeAccessList(t, path, "deny not-an-ip\n", time.Unix(2000, 0))
+	if err := list.ReloadIfChanged(); err == nil {
+		t.Fatal("expected reload of malformed file to fail")
+	}
+	if got := list.Check(blocked); got != accesslist.Denied {
+		t.Fatalf("Check after failed reload = %v, want previous deny", got)
+	}
+	if err := list.ReloadIfChanged(); err != nil {
+		t.Fatalf("failed version should not be retried until it changes: %v", err)
+	}
+
+	writeAccessList(t, path, "allow 198.51.100.0/24\n", time.Unix(3000, 0))
+	if err := list.ReloadIfChanged(); err != nil {
+		t.Fatalf("reload changed file: %v", err)
+	}
+	if got := list.Check(blocked); got != accesslist.Allowed {
+		t.Fatalf("Check after reload = %v, want allowed", got)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove access list: %v", err)
+	}
+	if err := list.ReloadIfChanged(); err != nil {
+		t.Fatalf("reload removed file: %v", err)
+	}
+	if got := list.Check(blocked); got != accesslist.Neutral {
+		t.Fatalf("Check after removal = %v, want neutral", got)
+	}
+}
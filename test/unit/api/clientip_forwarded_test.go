@@ -0,0 +1,157 @@
+package api_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+)
+
+const headerForwarded = "Forwarded"
+
+func forwardedResolver(headers ...string) *api.ClientIPResolver {
+	cfg := config.DefaultConfig()
+	cfg.TrustProxyHeaders = true
+	cfg.TrustedProxyCIDRs = []string{trustedLoopbackCIDR, trustedPrivateCIDR}
+	cfg.ClientIPHeaders = headers
+	return api.NewClientIPResolver(cfg)
+}
+
+func TestClientIPResolverForwarded(t *testing.T) {
+	resolver := forwardedResolver(config.ClientIPHeaderForwarded)
+
+	tests := []struct {
+		name      string
+		forwarded []string
+		want      string
+	}{
+		{name: "single hop", forwarded: []string{"for=203.0.113.10"}, want: forwardedClientIP},
+		{name: "trusted hops skipped", forwarded: []string{"for=203.0.113.10;proto=https, for=10.0.0.1"}, want: forwardedClientIP},
+		{name: "prepended spoof ignored", forwarded: []string{"for=198.51.100.99, for=203.0.113.10, for=10.0.0.1"}, want: forwardedClientIP},
+		{name: "quoted IPv6 with port", forwarded: []string{`for="[2001:db8:cafe::17]:4711"`}, want: "2001:db8:cafe::17"},
+		{name: "quoted IPv4 with port", forwarded: []string{`for="192.0.2.43:47011"`}, want: "192.0.2.43"},
+		{name: "case and parameter order", forwarded: []string{"by=10.0.0.1;FOR=192.0.2.60;Proto=http"}, want: "192.0.2.60"},
+		{name: "separate header lines", forwarded: []string{"for=198.51.100.99", "for=203.0.113.10"}, want: forwardedClientIP},
+		{name: "empty list elements", forwarded: []string{",for=203.0.113.10,"}, want: forwardedClientIP},
+		// Each of these leaves the client hidden, so the connection wins.
+		{name: "obfuscated hop", forwarded: []string{"for=198.51.100.99, for=_hidden"}, want: clientLoopbackIP},
+		{name: "obfuscated hop with port", forwarded: []string{`for=198.51.100.99, for="_gazonk:_port"`}, want: clientLoopbackIP},
+		{name: "unknown hop", forwarded: []string{"for=198.51.100.99, for=unknown"}, want: clientLoopbackIP},
+		{name: "hop without for", forwarded: []string{"for=198.51.100.99, proto=https"}, want: clientLoopbackIP},
+		{name: "only trusted hops", forwarded: []string{"for=10.0.0.1, for=10.0.0.2"}, want: clientLoopbackIP},
+		{name: "unterminated quote", forwarded: []string{`for="[2001:db8::1]`}, want: clientLoopbackIP},
+		{name: "repeated for", forwarded: []string{"for=198.51.100.99;for=10.0.0.1"}, want: clientLoopbackIP},
+		{name: "pair without value", forwarded: []string{"for=198.51.100.99, secret"}, want: clientLoopbackIP},
+		{name: "comma smuggled in quotes", forwarded: []string{`for="10.0.0.1, for=198.51.100.99"`}, want: clientLoopbackIP},
+		{name: "quote inside token", forwarded: []string{`for=198.51.100.99"`}, want: clientLoopbackIP},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(clientIPMethod, clientIPURL, nil)
+			req.RemoteAddr = localhostWithPort
+			for _, value := range test.forwarded {
+				req.Header.Add(headerForwarded, value)
+			}
+			if got := resolver.FromRequest(req); got != test.want {
+				t.Fatalf(clientIPWantFmt, got, test.want)
+			}
+		})
+	}
+}
+
+func TestClientIPResolverForwardedFromUntrustedPeer(t *testing.T) {
+	resolver := forwardedResolver(config.ClientIPHeaderForwarded)
+	req := httptest.NewRequest(clientIPMethod, clientIPURL, nil)
+	req.RemoteAddr = realIPHeaderValue + ":1234"
+	req.Header.Set(headerForwarded, "for=203.0.113.10")
+	if got := resolver.FromRequest(req); got != realIPHeaderValue {
+		t.Fatalf(clientIPWantFmt, got, realIPHeaderValue)
+	}
+}
+
+func TestClientIPResolverIgnoresForwardedByDefault(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TrustProxyHeaders = true
+	cfg.TrustedProxyCIDRs = []string{trustedLoopbackCIDR}
+	resolver := api.NewClientIPResolver(cfg)
+
+	req := httptest.NewRequest(clientIPMethod, clientIPURL, nil)
+	req.RemoteAddr = localhostWithPort
+	req.Header.Set(headerForwarded, "for=203.0.113.10")
+	if got := resolver.FromRequest(req); got != clientLoopbackIP {
+		t.Fatalf(clientIPWantFmt, got, clientLoopbackIP)
+	}
+}
+
+func TestClientIPResolverHeaderPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		set     map[string]string
+		want    string
+	}{
+		{
+			name:    "forwarded first",
+			headers: []string{config.ClientIPHeaderForwarded, config.ClientIPHeaderXForwardedFor},
+			set:     map[string]string{headerForwarded: "for=203.0.113.10", headerForwardedFor: "198.51.100.99"},
+			want:    forwardedClientIP,
+		},
+		{
+			name:    "x-forwarded-for first",
+			headers: []string{config.ClientIPHeaderXForwardedFor, config.ClientIPHeaderForwarded},
+			set:     map[string]string{headerForwarded: "for=198.51.100.99", headerForwardedFor: forwardedClientIP},
+			want:    forwardedClientIP,
+		},
+		{
+			name:    "later header used when earlier absent",
+			headers: []string{config.ClientIPHeaderXForwardedFor, config.ClientIPHeaderForwarded},
+			set:     map[string]string{headerForwarded: "for=203.0.113.10"},
+			want:    forwardedClientIP,
+		},
+		{
+			// A present header with only trusted hops must not fall through
+			// to a header the proxy may not strip.
+			name:    "no fallback past a present header",
+			headers: []string{config.ClientIPHeaderForwarded, config.ClientIPHeaderXRealIP},
+			set:     map[string]string{headerForwarded: "for=10.0.0.1", headerRealIP: "198.51.100.99"},
+			want:    clientLoopbackIP,
+		},
+		{
+			name:    "no fallback past an obfuscated client",
+			headers: []string{config.ClientIPHeaderForwarded, config.ClientIPHeaderXForwardedFor},
+			set:     map[string]string{headerForwarded: "for=_hidden", headerForwardedFor: "198.51.100.99"},
+			want:    clientLoopbackIP,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolver := forwardedResolver(test.headers...)
+			req := httptest.NewRequest(clientIPMethod, clientIPURL, nil)
+			req.RemoteAddr = localhostWithPort
+			for name, value := range test.set {
+				req.Header.Set(name, value)
+			}
+			if got := resolver.FromRequest(req); got != test.want {
+				t.Fatalf(clientIPWantFmt, got, test.want)
+			}
+		})
+	}
+}
+
+func TestForwardedHTTPSReadsForwardedProto(t *testing.T) {
+	req := httptest.NewRequest(clientIPMethod, clientIPURL, nil)
+	req.RemoteAddr = localhostWithPort
+	req.Header.Set(headerForwarded, "for=203.0.113.10;proto=http, for=10.0.0.1;proto=https")
+
+	if !forwardedResolver(config.ClientIPHeaderForwarded).ForwardedHTTPS(req) {
+		t.Fatal("ForwardedHTTPS = false, want the nearest proxy's proto=https")
+	}
+	if forwardedResolver(config.ClientIPHeaderXForwardedFor).ForwardedHTTPS(req) {
+		t.Fatal("ForwardedHTTPS = true with Forwarded disabled")
+	}
+	req.Header.Set("X-Forwarded-Proto", "http")
+	if forwardedResolver(config.ClientIPHeaderForwarded).ForwardedHTTPS(req) {
+		t.Fatal("X-Forwarded-Proto should take precedence over Forwarded proto")
+	}
+}
@@ -14,12 +14,21 @@ func TestOpenAPIRouteContract(t *testing.T) {
 	got := loadOpenAPIRoutes(t)
 
 	expected := map[string]struct{}{
-		"GET /health":              {},
-		"GET /api/v1/ping":         {},
-		"GET /api/v1/download":     {},
-		"POST /api/v1/upload":      {},
-		"POST /api/v1/results":     {},
-		"GET /api/v1/results/{id}": {},
+		"GET /health":                     {},
+		"GET /api/v1/ping":                {},
+		"GET /api/v1/connection":          {},
+		"GET /api/v1/download":            {},
+		"POST /api/v1/upload":             {},
+		"POST /api/v1/results":            {},
+		"GET /api/v1/results/compare":     {},
+		"GET /api/v1/results/{id}":        {},
+		"DELETE /api/v1/results/{id}":     {},
+		"GET /results/{id}/card.{format}": {},
+		"GET /results/{id}/badge.svg":     {},
+		"GET /api/v1/oembed":              {},
+		"GET /api/v1/admin/results":       {},
+		"GET /api/v1/admin/stats":         {},
+		"POST /api/v1/admin/snapshots":    {},
 	}
 
 	missing := diff(expected, got)
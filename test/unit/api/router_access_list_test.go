@@ -0,0 +1,74 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/accesslist"
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+)
+
+func newAccessListRouter(t *testing.T, cfg *config.Config, rules string) http.Handler {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), accesslist.FileName)
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatalf("write access list: %v", err)
+	}
+	list, err := accesslist.Load(path)
+	if err != nil {
+		t.Fatalf("load access list: %v", err)
+	}
+	router := api.NewRouter(cfg, newTestResultsStore(t))
+	router.SetAccessList(list)
+	return router.SetupRoutes()
+}
+
+func TestAccessListDeniesEveryRoute(t *testing.T) {
+	h := newAccessListRouter(t, config.DefaultConfig(), "deny 192.0.2.0/24\n")
+
+	for _, path := range []string{"/", healthRoutePath, pingAPIPath, resultsPagePath, apiUnknownPath} {
+		req := httptest.NewRequest(http.MethodGet, exampleBaseURL+path, nil)
+		req.RemoteAddr = "192.0.2.1:40000"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s "+statusWantFmt, path, rec.Code, http.StatusForbidden)
+		}
+		if strings.HasPrefix(path, "/api/") && !strings.Contains(rec.Body.String(), `"error":"forbidden"`) {
+			t.Fatalf("%s body = %q, want JSON forbidden", path, rec.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, exampleBaseURL+healthRoutePath, nil)
+	req.RemoteAddr = "203.0.113.1:40000"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("neutral client "+statusWantFmt, rec.Code, http.StatusOK)
+	}
+}
+
+func TestAccessListAllowBypassesRateLimits(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.GlobalRateLimit = 1
+	cfg.RateLimitPerIP = 1
+	h := newAccessListRouter(t, cfg, "allow 192.0.2.0/24\n")
+
+	for i := range 3 {
+		req := httptest.NewRequest(http.MethodGet, exampleBaseURL+resultsPagePath, nil)
+		req.RemoteAddr = "192.0.2.1:40000"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("allowlisted request %d "+statusWantFmt, i, rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get("RateLimit"); got != "" {
+			t.Fatalf("allowlisted request advertised RateLimit %q", got)
+		}
+	}
+}
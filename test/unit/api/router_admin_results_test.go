@@ -0,0 +1,162 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/apikeys"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const adminResultsPath = "/api/v1/admin/results"
+
+type adminFixture struct {
+	handler     http.Handler
+	store       results.Storage
+	adminToken  string
+	clientToken string
+}
+
+func newAdminFixture(t *testing.T) adminFixture {
+	t.Helper()
+	store, err := results.New(":memory:", 100)
+	if err != nil {
+		t.Fatalf(resultsNewErrFmt, err)
+	}
+	t.Cleanup(store.Close)
+	return newAdminFixtureWith(t, config.DefaultConfig(), store)
+}
+
+// newAdminFixtureWith serves store under cfg with an admin and a client key.
+func newAdminFixtureWith(t *testing.T, cfg *config.Config, store results.Storage) adminFixture {
+	t.Helper()
+	keys, err := apikeys.Open(filepath.Join(t.TempDir(), apikeys.FileName))
+	if err != nil {
+		t.Fatalf("open key store: %v", err)
+	}
+	t.Cleanup(keys.Close)
+	_, adminToken, err := keys.Create(context.Background(), "ops", apikeys.Policy{}, true)
+	if err != nil {
+		t.Fatalf("create admin key: %v", err)
+	}
+	_, clientToken, err := keys.Create(context.Background(), "probe", apikeys.Policy{}, false)
+	if err != nil {
+		t.Fatalf("create client key: %v", err)
+	}
+	router := api.NewRouter(cfg, store)
+	router.SetAPIKeys(keys)
+	return adminFixture{handler: router.SetupRoutes(), store: store, adminToken: adminToken, clientToken: clientToken}
+}
+
+func (f adminFixture) get(path, token string) *httptest.ResponseRecorder {
+	return f.do(http.MethodGet, path, token)
+}
+
+func (f adminFixture) do(method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, exampleBaseURL+path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminResultsRequireAdminKey(t *testing.T) {
+	f := newAdminFixture(t)
+
+	if rec := f.get(adminResultsPath, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous "+statusWantFmt, rec.Code, http.StatusUnauthorized)
+	}
+	if rec := f.get(adminResultsPath, f.clientToken); rec.Code != http.StatusForbidden {
+		t.Fatalf("client key "+statusWantFmt, rec.Code, http.StatusForbidden)
+	}
+	if rec := f.get(adminResultsPath, f.adminToken); rec.Code != http.StatusOK {
+		t.Fatalf("admin key "+statusWantFmt, rec.Code, http.StatusOK)
+	}
+}
+
+func TestAdminResultsNotRoutedWithoutKeyStore(t *testing.T) {
+	h := api.NewRouter(config.DefaultConfig(), newTestResultsStore(t)).SetupRoutes()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, exampleBaseURL+adminResultsPath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf(statusWantFmt, rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestAdminResultsFiltersAndPaginates(t *testing.T) {
+	f := newAdminFixture(t)
+	ctx := context.Background()
+	for _, seed := range []results.Result{
+		{DownloadMbps: 100, BufferbloatGrade: "A", IPv4: "192.0.2.1", ServerName: "Frankfurt"},
+		{DownloadMbps: 200, BufferbloatGrade: "B", IPv4: "192.0.2.2", ServerName: "Frankfurt"},
+		{DownloadMbps: 300, BufferbloatGrade: "A", IPv4: "198.51.100.3", ServerName: "Berlin"},
+		{DownloadMbps: 400, BufferbloatGrade: "A", IPv4: "192.0.2.4", ServerName: "Frankfurt"},
+	} {
+		if _, err := f.store.Save(ctx, seed); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+
+	var page struct {
+		Results []struct {
+			DownloadMbps float64 `json:"download_mbps"`
+			IPv4         string  `json:"ipv4"`
+		} `json:"results"`
+		NextCursor string `json:"next_cursor"`
+	}
+	rec := f.get(adminResultsPath+"?server=Frankfurt&grade=A&ip_prefix=192.0.2.0/24&limit=1", f.adminToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf(statusWantFmt+"; body %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if got := rec.Header().Get(cacheControlKey); got != noStoreHeader {
+		t.Fatalf(routerCacheControlFmt, got, noStoreHeader)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decode page: %v", err)
+	}
+	if len(page.Results) != 1 || page.Results[0].DownloadMbps != 400 || page.NextCursor == "" {
+		t.Fatalf("first page = %+v, want the 400 Mbps result and a cursor", page)
+	}
+
+	rec = f.get(adminResultsPath+"?server=Frankfurt&grade=A&ip_prefix=192.0.2.0/24&limit=1&cursor="+page.NextCursor, f.adminToken)
+	page.Results, page.NextCursor = nil, ""
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decode page: %v", err)
+	}
+	if len(page.Results) != 1 || page.Results[0].DownloadMbps != 100 || page.NextCursor != "" {
+		t.Fatalf("second page = %+v, want only the 100 Mbps result", page)
+	}
+
+	rec = f.get(adminResultsPath+"?min_download=150&max_download=350", f.adminToken)
+	page.Results = nil
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decode page: %v", err)
+	}
+	if len(page.Results) != 2 || page.Results[0].DownloadMbps != 300 || page.Results[1].DownloadMbps != 200 {
+		t.Fatalf("download range = %+v, want 300 then 200 Mbps", page.Results)
+	}
+}
+
+func TestAdminResultsRejectsInvalidFilters(t *testing.T) {
+	f := newAdminFixture(t)
+	for _, query := range []string{
+		"from=yesterday",
+		"min_download=-1",
+		"ip_prefix=not-an-ip",
+		"limit=0",
+		"limit=1000",
+		"cursor=bogus",
+	} {
+		if rec := f.get(adminResultsPath+"?"+query, f.adminToken); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: "+statusWantFmt, query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
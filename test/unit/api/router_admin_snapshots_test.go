@@ -0,0 +1,74 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const adminSnapshotsPath = "/api/v1/admin/snapshots"
+
+func snapshotFixture(t *testing.T, store results.Storage, backupDir string) adminFixture {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.BackupDir = backupDir
+	cfg.BackupKeep = 2
+	return newAdminFixtureWith(t, cfg, store)
+}
+
+func TestAdminSnapshotWritesBackup(t *testing.T) {
+	store, err := results.New(":memory:", 100)
+	if err != nil {
+		t.Fatalf(resultsNewErrFmt, err)
+	}
+	t.Cleanup(store.Close)
+	if _, err := store.Save(context.Background(), results.Result{DownloadMbps: 10}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "backups")
+	f := snapshotFixture(t, store, dir)
+
+	if rec := f.do(http.MethodPost, adminSnapshotsPath, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous "+statusWantFmt, rec.Code, http.StatusUnauthorized)
+	}
+	if rec := f.do(http.MethodPost, adminSnapshotsPath, f.clientToken); rec.Code != http.StatusForbidden {
+		t.Fatalf("client key "+statusWantFmt, rec.Code, http.StatusForbidden)
+	}
+
+	rec := f.do(http.MethodPost, adminSnapshotsPath, f.adminToken)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf(statusWantFmt+"; body %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	if got := rec.Header().Get(cacheControlKey); got != noStoreHeader {
+		t.Fatalf(routerCacheControlFmt, got, noStoreHeader)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	name, _ := body["name"].(string)
+	if name == "" || body["created_at"] == nil || body["size_bytes"] == nil || body["path"] != nil {
+		t.Fatalf("snapshot body = %v", body)
+	}
+	if n, err := results.ValidateSnapshot(context.Background(), filepath.Join(dir, name)); err != nil || n != 1 {
+		t.Fatalf("ValidateSnapshot = %d, %v", n, err)
+	}
+}
+
+func TestAdminSnapshotNeedsSnapshotBackend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+	f := snapshotFixture(t, results.NewMemory(100), dir)
+
+	if rec := f.do(http.MethodPost, adminSnapshotsPath, f.adminToken); rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("memory backend status = %d, want no snapshot route", rec.Code)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("backup dir created for memory backend: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+const adminStatsPath = "/api/v1/admin/stats"
+
+type statsBody struct {
+	GroupBy  string `json:"group_by"`
+	ByServer bool   `json:"by_server"`
+	Buckets  []struct {
+		Start        string `json:"start"`
+		ServerName   string `json:"server_name"`
+		Count        int    `json:"count"`
+		DownloadMbps struct {
+			Avg float64 `json:"avg"`
+			P50 float64 `json:"p50"`
+		} `json:"download_mbps"`
+	} `json:"buckets"`
+}
+
+func (f adminFixture) stats(t *testing.T, query string) statsBody {
+	t.Helper()
+	rec := f.get(adminStatsPath+query, f.adminToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf(statusWantFmt+"; body %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if got := rec.Header().Get(cacheControlKey); got != noStoreHeader {
+		t.Fatalf(routerCacheControlFmt, got, noStoreHeader)
+	}
+	var body statsBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	return body
+}
+
+func TestAdminStatsRequireAdminKey(t *testing.T) {
+	f := newAdminFixture(t)
+
+	if rec := f.get(adminStatsPath, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous "+statusWantFmt, rec.Code, http.StatusUnauthorized)
+	}
+	if rec := f.get(adminStatsPath, f.clientToken); rec.Code != http.StatusForbidden {
+		t.Fatalf("client key "+statusWantFmt, rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestAdminStatsGroupsByDayAndServer(t *testing.T) {
+	f := newAdminFixture(t)
+	ctx := context.Background()
+	for _, seed := range []results.Result{
+		{DownloadMbps: 100, ServerName: "Berlin"},
+		{DownloadMbps: 200, ServerName: "Frankfurt"},
+		{DownloadMbps: 400, ServerName: "Frankfurt"},
+	} {
+		if _, err := f.store.Save(ctx, seed); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+
+	body := f.stats(t, "?group_by=day&by_server=true")
+	if body.GroupBy != "day" || !body.ByServer || len(body.Buckets) != 2 {
+		t.Fatalf("stats = %+v, want two daily server buckets", body)
+	}
+	frankfurt := body.Buckets[1]
+	if frankfurt.ServerName != "Frankfurt" || frankfurt.Count != 2 || frankfurt.DownloadMbps.Avg != 300 || frankfurt.Start == "" {
+		t.Fatalf("Frankfurt bucket = %+v", frankfurt)
+	}
+}
+
+func TestAdminStatsAreCached(t *testing.T) {
+	f := newAdminFixture(t)
+	ctx := context.Background()
+	if _, err := f.store.Save(ctx, results.Result{DownloadMbps: 100}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if body := f.stats(t, ""); len(body.Buckets) != 1 || body.Buckets[0].Count != 1 {
+		t.Fatalf("stats = %+v, want one result", body)
+	}
+	if _, err := f.store.Save(ctx, results.Result{DownloadMbps: 100}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if body := f.stats(t, ""); body.Buckets[0].Count != 1 {
+		t.Fatalf("cached count = %d, want 1", body.Buckets[0].Count)
+	}
+	if body := f.stats(t, "?by_server=true"); body.Buckets[0].Count != 2 {
+		t.Fatalf("uncached count = %d, want 2", body.Buckets[0].Count)
+	}
+}
+
+func TestAdminStatsRejectsInvalidQuery(t *testing.T) {
+	f := newAdminFixture(t)
+	for _, query := range []string{
+		"group_by=month",
+		"by_server=maybe",
+		"from=yesterday",
+		"from=2026-10-02T00:00:00Z&to=2026-10-01T00:00:00Z",
+	} {
+		if rec := f.get(adminStatsPath+"?"+query, f.adminToken); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: "+statusWantFmt, query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/conninfo"
+)
+
+const connectionAPIPath = "/api/v1/connection"
+
+type connectionInfo struct {
+	Protocol   string `json:"protocol"`
+	ClientPort int    `json:"client_port"`
+	ViaProxy   bool   `json:"via_proxy"`
+	TLS        *struct {
+		Version     string `json:"version"`
+		CipherSuite string `json:"cipher_suite"`
+		ALPN        string `json:"alpn"`
+	} `json:"tls"`
+	Socket *struct {
+		MSS   uint32  `json:"mss"`
+		RTTMs float64 `json:"rtt_ms"`
+	} `json:"socket"`
+}
+
+func TestConnectionReportsTLSAndSocket(t *testing.T) {
+	srv := httptest.NewUnstartedServer(api.NewRouter(config.DefaultConfig(), nil).SetupRoutes())
+	srv.EnableHTTP2 = true
+	srv.Config.ConnContext = conninfo.ConnContext
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	resp, err := srv.Client().Get(srv.URL + connectionAPIPath)
+	if err != nil {
+		t.Fatalf("get connection: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Cache-Control"); got != "no-store" {
+		t.Fatalf("Cache-Control = %q, want no-store", got)
+	}
+	var info connectionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("decode connection: %v", err)
+	}
+	if info.Protocol != "HTTP/2.0" || info.ClientPort == 0 || info.ViaProxy {
+		t.Fatalf("connection = %+v, want direct HTTP/2.0 with a client port", info)
+	}
+	if info.TLS == nil || info.TLS.Version != "TLS 1.3" || info.TLS.ALPN != "h2" || info.TLS.CipherSuite == "" {
+		t.Fatalf("tls = %+v, want TLS 1.3 with h2", info.TLS)
+	}
+	if runtime.GOOS == "linux" && (info.Socket == nil || info.Socket.MSS == 0) {
+		t.Fatalf("socket = %+v, want TCP_INFO on Linux", info.Socket)
+	}
+}
+
+func TestConnectionBehindTrustedProxy(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TrustProxyHeaders = true
+	cfg.TrustedProxyCIDRs = []string{trustedPrivateCIDR}
+	handler := api.NewRouter(cfg, nil).SetupRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, connectionAPIPath, nil)
+	req.RemoteAddr = "10.0.0.2:40000"
+	req.Header.Set(headerForwardedFor, forwardedClientIP)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var info connectionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatalf("decode connection: %v", err)
+	}
+	if !info.ViaProxy || info.ClientPort != 0 || info.Protocol != "HTTP/1.1" || info.TLS != nil {
+		t.Fatalf("proxied connection = %+v, want via_proxy without client_port or tls", info)
+	}
+}
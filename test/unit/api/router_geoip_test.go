@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/geoip"
+	"github.com/saveenergy/openbyte/internal/geoip/geoiptest"
+)
+
+func openGeoIP(t *testing.T, networks ...geoiptest.Network) *geoip.DB {
+	t.Helper()
+	dir := t.TempDir()
+	if err := geoiptest.WriteFile(filepath.Join(dir, "test.mmdb"), "test", networks...); err != nil {
+		t.Fatalf("write database: %v", err)
+	}
+	db, err := geoip.Open(dir)
+	if err != nil {
+		t.Fatalf("open GeoIP: %v", err)
+	}
+	return db
+}
+
+func TestPingBootstrapIncludesClientNetwork(t *testing.T) {
+	// httptest requests come from 192.0.2.1.
+	router := api.NewRouter(config.DefaultConfig(), nil)
+	router.SetGeoIP(openGeoIP(t, geoiptest.Network{CIDR: "192.0.2.0/24", Record: map[string]any{
+		"autonomous_system_number":       uint32(64500),
+		"autonomous_system_organization": "Example Transit",
+		"country":                        map[string]any{"iso_code": "DE"},
+	}}))
+	handler := router.SetupRoutes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pingAPIPath+"?meta=1", nil))
+	var response struct {
+		ASN            uint32 `json:"asn"`
+		ASOrganization string `json:"as_org"`
+		Country        string `json:"country"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode ping metadata: %v", err)
+	}
+	if response.ASN != 64500 || response.ASOrganization != "Example Transit" || response.Country != "DE" {
+		t.Fatalf("network = %+v, want AS64500 Example Transit DE", response)
+	}
+
+	// Latency samples stay as small as before.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pingAPIPath, nil))
+	if strings.Contains(rec.Body.String(), "asn") {
+		t.Fatalf("plain ping = %s, want no network fields", rec.Body.String())
+	}
+}
+
+func TestSaveResultRecordsClientNetwork(t *testing.T) {
+	store := newTestResultsStore(t)
+	router := api.NewRouter(config.DefaultConfig(), store)
+	router.SetGeoIP(openGeoIP(t, geoiptest.Network{CIDR: "192.0.2.0/24", Record: map[string]any{
+		"asn":          "AS64501",
+		"as_name":      "Example Broadband",
+		"country_code": "NL",
+	}}))
+	handler := router.SetupRoutes()
+
+	rec, resp := saveResultFrom(t, handler, 100, 20)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("save status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	stored, err := store.Get(context.Background(), resp["id"].(string))
+	if err != nil || stored == nil {
+		t.Fatalf("get saved result: %+v, %v", stored, err)
+	}
+	if stored.ASN != 64501 || stored.ASOrganization != "Example Broadband" || stored.Country != "NL" {
+		t.Fatalf("stored network = %d %q %q, want AS64501 Example Broadband NL", stored.ASN, stored.ASOrganization, stored.Country)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/results/"+stored.ID, nil))
+	var fetched map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &fetched); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if fetched["asn"] != float64(64501) || fetched["as_org"] != "Example Broadband" || fetched["country"] != "NL" {
+		t.Fatalf("fetched result = %v, want network fields", fetched)
+	}
+}
@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+)
+
+type pingClientAddress struct {
+	Version      int    `json:"version"`
+	Scope        string `json:"scope"`
+	Transition   string `json:"transition"`
+	EmbeddedIPv4 string `json:"embedded_ipv4"`
+	ViaProxy     bool   `json:"via_proxy"`
+}
+
+func pingClientAddressFor(t *testing.T, handler http.Handler, remoteAddr, forwardedFor string) pingClientAddress {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, pingAPIPath+"?meta=1", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set(headerForwardedFor, forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var response struct {
+		ClientAddress *pingClientAddress `json:"client_address"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode ping metadata: %v", err)
+	}
+	if response.ClientAddress == nil {
+		t.Fatalf("ping for %s has no client_address", remoteAddr)
+	}
+	return *response.ClientAddress
+}
+
+func TestPingBootstrapClassifiesClientAddress(t *testing.T) {
+	handler := api.NewRouter(config.DefaultConfig(), nil).SetupRoutes()
+
+	tests := []struct {
+		remoteAddr string
+		want       pingClientAddress
+	}{
+		{remoteAddr: "203.0.113.10:1234", want: pingClientAddress{Version: 4, Scope: "public"}},
+		{remoteAddr: "100.64.12.7:1234", want: pingClientAddress{Version: 4, Scope: "cgnat"}},
+		{remoteAddr: "192.168.1.20:1234", want: pingClientAddress{Version: 4, Scope: "private"}},
+		{remoteAddr: "127.0.0.1:1234", want: pingClientAddress{Version: 4, Scope: "loopback"}},
+		{remoteAddr: "169.254.3.4:1234", want: pingClientAddress{Version: 4, Scope: "link_local"}},
+		{remoteAddr: "[2001:db8::1]:1234", want: pingClientAddress{Version: 6, Scope: "public"}},
+		{remoteAddr: "[fd00::5]:1234", want: pingClientAddress{Version: 6, Scope: "private"}},
+		{remoteAddr: "[::1]:1234", want: pingClientAddress{Version: 6, Scope: "loopback"}},
+		{remoteAddr: "[::ffff:100.64.0.9]:1234", want: pingClientAddress{
+			Version: 6, Scope: "cgnat", Transition: "ipv4_mapped", EmbeddedIPv4: "100.64.0.9",
+		}},
+		{remoteAddr: "[64:ff9b::c633:6407]:1234", want: pingClientAddress{
+			Version: 6, Scope: "public", Transition: "nat64", EmbeddedIPv4: "198.51.100.7",
+		}},
+		// Teredo stores the client's external 203.0.113.5 with its bits inverted.
+		{remoteAddr: "[2001:0:4136:e378:8000:63bf:34ff:8efa]:1234", want: pingClientAddress{
+			Version: 6, Scope: "public", Transition: "teredo", EmbeddedIPv4: "203.0.113.5",
+		}},
+		{remoteAddr: "[2002:cb00:7105::1]:1234", want: pingClientAddress{
+			Version: 6, Scope: "public", Transition: "6to4", EmbeddedIPv4: "203.0.113.5",
+		}},
+	}
+	for _, test := range tests {
+		if got := pingClientAddressFor(t, handler, test.remoteAddr, ""); got != test.want {
+			t.Fatalf("client_address for %s = %+v, want %+v", test.remoteAddr, got, test.want)
+		}
+	}
+}
+
+func TestPingBootstrapMarksProxyDerivedAddress(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TrustProxyHeaders = true
+	cfg.TrustedProxyCIDRs = []string{trustedPrivateCIDR}
+	handler := api.NewRouter(cfg, nil).SetupRoutes()
+
+	got := pingClientAddressFor(t, handler, "10.0.0.2:1234", "::ffff:100.64.1.1")
+	want := pingClientAddress{Version: 6, Scope: "cgnat", Transition: "ipv4_mapped", EmbeddedIPv4: "100.64.1.1", ViaProxy: true}
+	if got != want {
+		t.Fatalf("forwarded client_address = %+v, want %+v", got, want)
+	}
+
+	// Untrusted peers are classified as connected, headers ignored.
+	got = pingClientAddressFor(t, handler, "198.51.100.5:1234", "100.64.1.1")
+	if want := (pingClientAddress{Version: 4, Scope: "public"}); got != want {
+		t.Fatalf("untrusted client_address = %+v, want %+v", got, want)
+	}
+}
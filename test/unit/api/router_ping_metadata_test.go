@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/saveenergy/openbyte/internal/api"
 	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
 )
 
 func TestPingBootstrapIncludesServerName(t *testing.T) {
@@ -26,8 +28,8 @@ func TestPingBootstrapIncludesServerName(t *testing.T) {
 	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
 		t.Fatalf("decode ping metadata: %v", err)
 	}
-	if len(response) != 2 {
-		t.Fatalf("metadata ping fields = %v, want client_ip and server_name", response)
+	if len(response) != 3 {
+		t.Fatalf("metadata ping fields = %v, want client_ip, server_name, and client_address", response)
 	}
 	if got := response["client_ip"]; got != "192.0.2.1" {
 		t.Fatalf("client_ip = %v, want 192.0.2.1", got)
@@ -37,6 +39,28 @@ func TestPingBootstrapIncludesServerName(t *testing.T) {
 	}
 }
 
+func TestPingBootstrapIncludesResultRetention(t *testing.T) {
+	store, err := results.New(":memory:", 10, results.WithRetention(7*24*time.Hour, 30*24*time.Hour))
+	if err != nil {
+		t.Fatalf(resultsNewErrFmt, err)
+	}
+	t.Cleanup(store.Close)
+	handler := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pingAPIPath+"?meta=1", nil))
+	var response struct {
+		RetentionDays    int `json:"result_retention_days"`
+		MaxRetentionDays int `json:"result_max_retention_days"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode ping metadata: %v", err)
+	}
+	if response.RetentionDays != 7 || response.MaxRetentionDays != 30 {
+		t.Fatalf("retention = %d/%d days, want 7/30", response.RetentionDays, response.MaxRetentionDays)
+	}
+}
+
 func TestPlainPingOmitsServerName(t *testing.T) {
 	handler := api.NewRouter(config.DefaultConfig(), nil).SetupRoutes()
 	req := httptest.NewRequest(http.MethodGet, pingAPIPath, nil)
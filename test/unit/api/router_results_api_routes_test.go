@@ -2,6 +2,7 @@ package api_test
 
 import (
 	"compress/gzip"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -116,6 +117,7 @@ func TestResultsRoutesAbsentWithoutStore(t *testing.T) {
 	}{
 		{method: http.MethodPost, path: "/api/v1/results"},
 		{method: http.MethodGet, path: "/api/v1/results/abc12345"},
+		{method: http.MethodDelete, path: "/api/v1/results/abc12345"},
 		{method: http.MethodGet, path: resultsPagePath},
 		{method: http.MethodHead, path: resultsPagePath},
 	} {
@@ -139,6 +141,7 @@ func TestResultsAPIRoutesRateLimited(t *testing.T) {
 	}{
 		{name: "save", method: http.MethodPost, path: "/api/v1/results", body: `{}`, firstStatus: http.StatusCreated},
 		{name: "get", method: http.MethodGet, path: "/api/v1/results/abc12345", firstStatus: http.StatusNotFound},
+		{name: "delete", method: http.MethodDelete, path: "/api/v1/results/abc12345", firstStatus: http.StatusUnauthorized},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			cfg := config.DefaultConfig()
@@ -202,3 +205,120 @@ func TestResultsPageRouteRateLimited(t *testing.T) {
 		t.Fatalf("rate-limit body = %q, want JSON error", rec.Body.String())
 	}
 }
+
+func TestResultsRoutesUseIndependentRateLimitPolicies(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RateLimitPolicies = map[string]config.RateLimitPolicy{
+		config.RateLimitPolicyResultsRead: {PerIP: 1, Global: 10},
+	}
+	h := api.NewRouter(cfg, newTestResultsStore(t)).SetupRoutes()
+
+	first := httptest.NewRecorder()
+	h.ServeHTTP(first, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/abc12345", nil))
+	if first.Code != http.StatusNotFound {
+		t.Fatalf("first read "+statusWantFmt, first.Code, http.StatusNotFound)
+	}
+	if got, want := first.Header().Get("RateLimit-Policy"), `"results-read";q=1;w=60`; got != want {
+		t.Fatalf("RateLimit-Policy = %q, want %q", got, want)
+	}
+	if got, want := first.Header().Get("RateLimit"), `"results-read";r=0;t=60`; got != want {
+		t.Fatalf("RateLimit = %q, want %q", got, want)
+	}
+
+	second := httptest.NewRecorder()
+	h.ServeHTTP(second, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/abc12345", nil))
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second read "+statusWantFmt, second.Code, http.StatusTooManyRequests)
+	}
+	var body struct {
+		Error  string `json:"error"`
+		Policy string `json:"policy"`
+		Scope  string `json:"scope"`
+	}
+	if err := json.NewDecoder(second.Body).Decode(&body); err != nil {
+		t.Fatalf("decode rate-limit body: %v", err)
+	}
+	if body.Error != "rate limit exceeded" || body.Policy != "results-read" || body.Scope != "ip" {
+		t.Fatalf("rate-limit body = %+v, want results-read ip rejection", body)
+	}
+
+	page := httptest.NewRecorder()
+	h.ServeHTTP(page, httptest.NewRequest(http.MethodGet, exampleBaseURL+resultsPagePath, nil))
+	if page.Code != http.StatusOK {
+		t.Fatalf("results page should keep its own budget, "+statusWantFmt, page.Code, http.StatusOK)
+	}
+	if got := page.Header().Get("RateLimit-Policy"); !strings.HasPrefix(got, `"results-page";`) {
+		t.Fatalf("results page RateLimit-Policy = %q", got)
+	}
+}
+
+func TestUnconfiguredRateLimitPoliciesShareDefaultBudget(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RateLimitPerIP = 1
+	h := api.NewRouter(cfg, newTestResultsStore(t)).SetupRoutes()
+
+	read := httptest.NewRecorder()
+	h.ServeHTTP(read, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/abc12345", nil))
+	if read.Code != http.StatusNotFound {
+		t.Fatalf("read "+statusWantFmt, read.Code, http.StatusNotFound)
+	}
+
+	page := httptest.NewRecorder()
+	h.ServeHTTP(page, httptest.NewRequest(http.MethodGet, exampleBaseURL+resultsPagePath, nil))
+	if page.Code != http.StatusTooManyRequests {
+		t.Fatalf("results page should share the default budget, "+statusWantFmt, page.Code, http.StatusTooManyRequests)
+	}
+	if got := page.Header().Get("RateLimit-Policy"); !strings.HasPrefix(got, `"results-page";`) {
+		t.Fatalf("results page RateLimit-Policy = %q", got)
+	}
+}
+
+func TestPingRateLimitPolicyIsOptIn(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RateLimitPolicies = map[string]config.RateLimitPolicy{
+		config.RateLimitPolicyPing: {PerIP: 1, Global: 1},
+	}
+	h := api.NewRouter(cfg, nil).SetupRoutes()
+
+	first := httptest.NewRecorder()
+	h.ServeHTTP(first, httptest.NewRequest(http.MethodGet, exampleBaseURL+pingAPIPath, nil))
+	if first.Code != http.StatusOK {
+		t.Fatalf("first ping "+statusWantFmt, first.Code, http.StatusOK)
+	}
+	second := httptest.NewRecorder()
+	h.ServeHTTP(second, httptest.NewRequest(http.MethodGet, exampleBaseURL+pingAPIPath, nil))
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second ping "+statusWantFmt, second.Code, http.StatusTooManyRequests)
+	}
+	if !strings.Contains(second.Body.String(), `"policy":"ping"`) {
+		t.Fatalf("rate-limit body = %q, want ping policy", second.Body.String())
+	}
+}
+
+func TestPingRateLimitResponseAllowsCrossOriginProbe(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RateLimitPolicies = map[string]config.RateLimitPolicy{
+		config.RateLimitPolicyPing: {PerIP: 1, Global: 1},
+	}
+	h := api.NewRouter(cfg, nil).SetupRoutes()
+
+	var rec *httptest.ResponseRecorder
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, exampleBaseURL+pingAPIPath, nil)
+		req.Header.Set("Origin", "https://v4.example.com")
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+	}
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second ping "+statusWantFmt, rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	exposed := rec.Header().Get("Access-Control-Expose-Headers")
+	for _, name := range []string{"RateLimit-Policy", "RateLimit", "Retry-After"} {
+		if !strings.Contains(exposed, name) {
+			t.Fatalf("Access-Control-Expose-Headers = %q, want %s", exposed, name)
+		}
+	}
+}
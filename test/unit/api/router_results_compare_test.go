@@ -0,0 +1,108 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+type compareBody struct {
+	Baseline string           `json:"baseline"`
+	Results  []results.Result `json:"results"`
+	Deltas   []struct {
+		ID           string `json:"id"`
+		DownloadMbps struct {
+			Delta   float64  `json:"delta"`
+			Percent *float64 `json:"percent"`
+		} `json:"download_mbps"`
+		LatencyMs struct {
+			Delta   float64  `json:"delta"`
+			Percent *float64 `json:"percent"`
+		} `json:"latency_ms"`
+	} `json:"deltas"`
+}
+
+func TestCompareResultsReturnsDeltasFromBaseline(t *testing.T) {
+	store := newTestResultsStore(t)
+	before := saveStoredResult(t, store, results.Result{DownloadMbps: 100, LatencyMs: 0})
+	after := saveStoredResult(t, store, results.Result{DownloadMbps: 150.5, LatencyMs: 12})
+	worse := saveStoredResult(t, store, results.Result{DownloadMbps: 80, LatencyMs: 30})
+	h := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		exampleBaseURL+"/api/v1/results/compare?ids="+before+","+after+","+worse, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf(statusWantFmt, rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get(cacheControlKey); got != noStoreHeader {
+		t.Fatalf(routerCacheControlFmt, got, noStoreHeader)
+	}
+	var body compareBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode compare response: %v", err)
+	}
+	if body.Baseline != before || len(body.Results) != 3 || body.Results[1].ID != after || len(body.Deltas) != 2 {
+		t.Fatalf("compare = %+v", body)
+	}
+	up, down := body.Deltas[0], body.Deltas[1]
+	if up.ID != after || up.DownloadMbps.Delta != 50.5 || up.DownloadMbps.Percent == nil || *up.DownloadMbps.Percent != 50.5 {
+		t.Fatalf("improved download delta = %+v", up.DownloadMbps)
+	}
+	if down.DownloadMbps.Delta != -20 || *down.DownloadMbps.Percent != -20 {
+		t.Fatalf("worse download delta = %+v", down.DownloadMbps)
+	}
+	if up.LatencyMs.Delta != 12 || up.LatencyMs.Percent != nil {
+		t.Fatalf("latency delta from a zero baseline = %+v, want no percent", up.LatencyMs)
+	}
+}
+
+func TestCompareResultsRejectsBadInput(t *testing.T) {
+	store := newTestResultsStore(t)
+	a := saveStoredResult(t, store, results.Result{DownloadMbps: 1})
+	b := saveStoredResult(t, store, results.Result{DownloadMbps: 2})
+	h := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+
+	six := strings.TrimSuffix(strings.Repeat(a+",", 6), ",")
+	for name, tc := range map[string]struct {
+		query string
+		want  int
+	}{
+		"missing":   {"", http.StatusBadRequest},
+		"one":       {"ids=" + a, http.StatusBadRequest},
+		"six":       {"ids=" + six, http.StatusBadRequest},
+		"invalid":   {"ids=" + a + ",../etc/p", http.StatusBadRequest},
+		"duplicate": {"ids=" + a + "," + a, http.StatusBadRequest},
+		"unknown":   {"ids=" + a + ",zzzz9999", http.StatusNotFound},
+		"valid":     {"ids=" + b + "," + a, http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/compare?"+tc.query, nil))
+		if rec.Code != tc.want {
+			t.Errorf("%s: "+statusWantFmt, name, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestComparePageIsServedFromAllowlist(t *testing.T) {
+	h := api.NewRouter(config.DefaultConfig(), newTestResultsStore(t)).SetupRoutes()
+	for _, path := range []string{"/compare", "/compare.html"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, exampleBaseURL+path+"?ids=abcd1234", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s "+statusWantFmt, path, rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get(cacheControlKey); got != noStoreHeader {
+			t.Fatalf("%s "+routerCacheControlFmt, path, got, noStoreHeader)
+		}
+		if !strings.Contains(rec.Body.String(), `src="/compare.js"`) {
+			t.Fatalf("%s did not serve the compare page", path)
+		}
+	}
+}
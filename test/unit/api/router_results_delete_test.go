@@ -0,0 +1,97 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+)
+
+func saveSharedResult(t *testing.T, h http.Handler) (id, deleteToken string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, exampleBaseURL+"/api/v1/results", strings.NewReader(`{"download_mbps":100}`))
+	req.Header.Set(contentTypeHeader, routerContentTypeJSON)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("save "+statusWantFmt, rec.Code, http.StatusCreated)
+	}
+	var body struct {
+		ID          string `json:"id"`
+		DeleteToken string `json:"delete_token"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode save response: %v", err)
+	}
+	if !strings.HasPrefix(body.DeleteToken, "obd_") {
+		t.Fatalf("delete token = %q, want obd_ prefix", body.DeleteToken)
+	}
+	return body.ID, body.DeleteToken
+}
+
+func deleteSharedResult(h http.Handler, id, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, exampleBaseURL+"/api/v1/results/"+id, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDeleteResultWithOwnerToken(t *testing.T) {
+	h := api.NewRouter(config.DefaultConfig(), newTestResultsStore(t)).SetupRoutes()
+	id, token := saveSharedResult(t, h)
+
+	if rec := deleteSharedResult(h, id, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("missing token "+statusWantFmt, rec.Code, http.StatusUnauthorized)
+	}
+	if rec := deleteSharedResult(h, id, "obd_"+strings.Repeat("A", 43)); rec.Code != http.StatusForbidden {
+		t.Fatalf("wrong token "+statusWantFmt, rec.Code, http.StatusForbidden)
+	}
+	otherID, otherToken := saveSharedResult(t, h)
+	if rec := deleteSharedResult(h, id, otherToken); rec.Code != http.StatusForbidden {
+		t.Fatalf("token of %s "+statusWantFmt, otherID, rec.Code, http.StatusForbidden)
+	}
+
+	rec := deleteSharedResult(h, id, token)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("owner delete "+statusWantFmt, rec.Code, http.StatusNoContent)
+	}
+	if got := rec.Header().Get(cacheControlKey); got != noStoreHeader {
+		t.Fatalf(routerCacheControlFmt, got, noStoreHeader)
+	}
+
+	get := httptest.NewRecorder()
+	h.ServeHTTP(get, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/"+id, nil))
+	if get.Code != http.StatusNotFound {
+		t.Fatalf("get after delete "+statusWantFmt, get.Code, http.StatusNotFound)
+	}
+	if rec := deleteSharedResult(h, id, token); rec.Code != http.StatusNotFound {
+		t.Fatalf("repeat delete "+statusWantFmt, rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestDeleteResultRejectsInvalidID(t *testing.T) {
+	h := api.NewRouter(config.DefaultConfig(), newTestResultsStore(t)).SetupRoutes()
+	if rec := deleteSharedResult(h, "abc-1234", "obd_x"); rec.Code != http.StatusBadRequest {
+		t.Fatalf(statusWantFmt, rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestDeleteResultAcceptsCaseInsensitiveBearerScheme(t *testing.T) {
+	h := api.NewRouter(config.DefaultConfig(), newTestResultsStore(t)).SetupRoutes()
+	id, token := saveSharedResult(t, h)
+
+	req := httptest.NewRequest(http.MethodDelete, exampleBaseURL+"/api/v1/results/"+id, nil)
+	req.Header.Set("authorization", "bearer "+token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("lowercase scheme "+statusWantFmt, rec.Code, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,136 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func TestResultBadgeStylesAndCaching(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.BrandPrimaryColorDark = "#ff8800"
+	cfg.BrandPrimaryColorLight = "#8a4a00"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	h := api.NewRouter(cfg, newTestResultsStore(t)).SetupRoutes()
+	id, token := saveSharedResult(t, h)
+	badgePath := exampleBaseURL + "/results/" + id + "/badge.svg"
+
+	for style, want := range map[string]string{
+		"":              "↓ 100 ↑ 0.0 Mbps",
+		"flat-square":   "↓ 100 ↑ 0.0 Mbps",
+		"for-the-badge": "↓ 100 ↑ 0.0 MBPS",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, badgePath+"?style="+style, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("style %q: "+statusWantFmt, style, rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get(routerContentTypeKey); got != "image/svg+xml" {
+			t.Fatalf(routerContentTypeWantFmt, got, "image/svg+xml")
+		}
+		if got := rec.Header().Get(cacheControlKey); got != "public, max-age=600" {
+			t.Fatalf(routerCacheControlFmt, got, "public, max-age=600")
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, want) || !strings.Contains(body, `fill="#ff8800"`) {
+			t.Fatalf("style %q badge lacks %q or the brand color:\n%s", style, want, body)
+		}
+	}
+
+	first := httptest.NewRecorder()
+	h.ServeHTTP(first, httptest.NewRequest(http.MethodGet, badgePath, nil))
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("badge has no ETag")
+	}
+	req := httptest.NewRequest(http.MethodGet, badgePath, nil)
+	req.Header.Set("If-None-Match", etag)
+	revalidated := httptest.NewRecorder()
+	h.ServeHTTP(revalidated, req)
+	if revalidated.Code != http.StatusNotModified {
+		t.Fatalf("revalidation "+statusWantFmt, revalidated.Code, http.StatusNotModified)
+	}
+
+	bad := httptest.NewRecorder()
+	h.ServeHTTP(bad, httptest.NewRequest(http.MethodGet, badgePath+"?style=plastic", nil))
+	if bad.Code != http.StatusBadRequest {
+		t.Fatalf("unknown style "+statusWantFmt, bad.Code, http.StatusBadRequest)
+	}
+
+	if rec := deleteSharedResult(h, id, token); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete "+statusWantFmt, rec.Code, http.StatusNoContent)
+	}
+	gone := httptest.NewRecorder()
+	h.ServeHTTP(gone, httptest.NewRequest(http.MethodGet, badgePath, nil))
+	if gone.Code != http.StatusNotFound {
+		t.Fatalf("deleted result badge "+statusWantFmt, gone.Code, http.StatusNotFound)
+	}
+}
+
+func TestOEmbedDescribesResultPages(t *testing.T) {
+	store := newTestResultsStore(t)
+	id := saveStoredResult(t, store, results.Result{DownloadMbps: 250, UploadMbps: 20})
+	cfg := config.DefaultConfig()
+	cfg.ServerName = "Frankfurt 25G"
+	h := api.NewRouter(cfg, store).SetupRoutes()
+	pageURL := exampleBaseURL + "/results/" + id
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		exampleBaseURL+"/api/v1/oembed?maxwidth=600&url="+url.QueryEscape(pageURL), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf(statusWantFmt, rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get(cacheControlKey); got != "public, max-age=600" {
+		t.Fatalf(routerCacheControlFmt, got, "public, max-age=600")
+	}
+	var body struct {
+		Type         string `json:"type"`
+		Version      string `json:"version"`
+		Title        string `json:"title"`
+		ProviderName string `json:"provider_name"`
+		HTML         string `json:"html"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		ThumbnailURL string `json:"thumbnail_url"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode oEmbed: %v", err)
+	}
+	if body.Type != "rich" || body.Version != "1.0" || body.ProviderName != "Frankfurt 25G" ||
+		body.Width != 600 || body.Height != 315 || body.ThumbnailURL != pageURL+"/card.png" ||
+		body.Title != "Speed test: 250 Mbps down, 20.0 Mbps up" {
+		t.Fatalf("oEmbed = %+v", body)
+	}
+	if want := `<a href="` + pageURL + `"><img src="` + pageURL + `/card.png" width="600" height="315"`; !strings.HasPrefix(body.HTML, want) {
+		t.Fatalf("oEmbed html = %q, want prefix %q", body.HTML, want)
+	}
+
+	for name, tc := range map[string]struct {
+		query string
+		want  int
+	}{
+		"missing url":   {"", http.StatusBadRequest},
+		"xml format":    {"format=xml&url=" + url.QueryEscape(pageURL), http.StatusNotImplemented},
+		"bad maxwidth":  {"maxwidth=-1&url=" + url.QueryEscape(pageURL), http.StatusBadRequest},
+		"other host":    {"url=" + url.QueryEscape("https://other.example/results/"+id), http.StatusNotFound},
+		"not a result":  {"url=" + url.QueryEscape(exampleBaseURL+"/privacy"), http.StatusNotFound},
+		"unknown id":    {"url=" + url.QueryEscape(exampleBaseURL+resultsPagePath), http.StatusNotFound},
+		"relative path": {"url=" + url.QueryEscape("/results/"+id), http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/oembed?"+tc.query, nil))
+		if rec.Code != tc.want {
+			t.Errorf("%s: "+statusWantFmt, name, rec.Code, tc.want)
+		}
+	}
+}
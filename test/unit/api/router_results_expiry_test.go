@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func TestSaveResultHonorsRequestedRetention(t *testing.T) {
+	store, err := results.New(":memory:", 10, results.WithRetention(7*24*time.Hour, 30*24*time.Hour))
+	if err != nil {
+		t.Fatalf(resultsNewErrFmt, err)
+	}
+	t.Cleanup(store.Close)
+	h := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+
+	save := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, exampleBaseURL+"/api/v1/results", strings.NewReader(body))
+		req.Header.Set(contentTypeHeader, routerContentTypeJSON)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	for _, tt := range []struct {
+		body string
+		want time.Duration
+	}{
+		{`{"download_mbps":1}`, 7 * 24 * time.Hour},
+		{`{"download_mbps":1,"retention_days":1}`, 24 * time.Hour},
+		{`{"download_mbps":1,"retention_days":30}`, 30 * 24 * time.Hour},
+	} {
+		rec := save(tt.body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("%s: "+statusWantFmt, tt.body, rec.Code, http.StatusCreated)
+		}
+		var body struct {
+			ID        string    `json:"id"`
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got := time.Until(body.ExpiresAt); got < tt.want-time.Minute || got > tt.want {
+			t.Fatalf("%s: expires in %s, want about %s", tt.body, got, tt.want)
+		}
+
+		get := httptest.NewRecorder()
+		h.ServeHTTP(get, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/"+body.ID, nil))
+		if !strings.Contains(get.Body.String(), `"expires_at"`) {
+			t.Fatalf("result body = %s, want expires_at", get.Body.String())
+		}
+	}
+
+	for _, body := range []string{
+		`{"download_mbps":1,"retention_days":31}`,
+		`{"download_mbps":1,"retention_days":-1}`,
+	} {
+		if rec := save(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: "+statusWantFmt, body, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestResultsRoutesWorkWithMemoryStorage(t *testing.T) {
+	store := results.NewMemory(10)
+	t.Cleanup(store.Close)
+	h := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+
+	req := httptest.NewRequest(http.MethodPost, exampleBaseURL+"/api/v1/results", strings.NewReader(`{"download_mbps":12.5}`))
+	req.Header.Set(contentTypeHeader, routerContentTypeJSON)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf(statusWantFmt, rec.Code, http.StatusCreated)
+	}
+	var saved struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&saved); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	get := httptest.NewRecorder()
+	h.ServeHTTP(get, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/"+saved.ID, nil))
+	if get.Code != http.StatusOK || !strings.Contains(get.Body.String(), `"download_mbps":12.5`) {
+		t.Fatalf("get = %d %s", get.Code, get.Body.String())
+	}
+}
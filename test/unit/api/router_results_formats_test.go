@@ -0,0 +1,161 @@
+package api_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func TestGetResultNegotiatesFormat(t *testing.T) {
+	store := newTestResultsStore(t)
+	id := saveStoredResult(t, store, results.Result{
+		DownloadMbps: 512.34, UploadMbps: 48.06, LatencyMs: 12.4, JitterMs: 1.2,
+		BufferbloatGrade: "A", ServerName: "Berlin\x1b[2J",
+	})
+	h := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/"+path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, tc := range []struct {
+		path, accept, contentType string
+	}{
+		{id, "", "application/json"},
+		{id, "*/*", "application/json"},
+		{id, "text/html,application/xhtml+xml,*/*;q=0.8", "application/json"},
+		{id, "text/csv", "text/csv; charset=utf-8"},
+		{id, "text/*", "text/plain; charset=utf-8"},
+		{id, "application/json;q=0.5, application/ld+json", "application/ld+json"},
+		{id + ".csv", "application/json", "text/csv; charset=utf-8"},
+		{id + ".txt", "", "text/plain; charset=utf-8"},
+		{id + ".jsonld", "", "application/ld+json"},
+		{id + ".json", "text/csv", "application/json"},
+	} {
+		rec := get(tc.path, tc.accept)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s (Accept %q): "+statusWantFmt, tc.path, tc.accept, rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get("Content-Type"); got != tc.contentType {
+			t.Errorf("%s (Accept %q): content-type = %q, want %q", tc.path, tc.accept, got, tc.contentType)
+		}
+		if got := rec.Header().Get(cacheControlKey); got != noStoreHeader {
+			t.Errorf("%s: "+routerCacheControlFmt, tc.path, got, noStoreHeader)
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept" {
+			t.Errorf("%s: vary = %q, want Accept", tc.path, got)
+		}
+	}
+
+	records, err := csv.NewReader(get(id+".csv", "").Body).ReadAll()
+	if err != nil || len(records) != 2 || records[0][0] != "id" || records[1][0] != id || records[1][3] != "512.34" {
+		t.Fatalf("csv = %v, %v", records, err)
+	}
+
+	text := get(id+".txt", "").Body.String()
+	for _, want := range []string{"openByte result " + id, "Download        512 Mbps", "Bufferbloat     A", "Server          Berlin[2J"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "\x1b") || strings.Contains(text, "IPv4") {
+		t.Errorf("text kept control characters or empty fields:\n%s", text)
+	}
+
+	var ld map[string]any
+	if err := json.NewDecoder(get(id+".jsonld", "").Body).Decode(&ld); err != nil {
+		t.Fatalf("decode JSON-LD: %v", err)
+	}
+	if ld["@context"] != "https://schema.org" || ld["@type"] != "Dataset" ||
+		ld["url"] != exampleBaseURL+"/results/"+id || ld["identifier"] != id {
+		t.Fatalf("JSON-LD = %v", ld)
+	}
+	measured, _ := ld["variableMeasured"].([]any)
+	if len(measured) != 6 {
+		t.Fatalf("variableMeasured = %v, want 6 properties", measured)
+	}
+	if first, _ := measured[0].(map[string]any); first["value"] != 512.34 || first["unitCode"] != "E20" {
+		t.Fatalf("download property = %v", first)
+	}
+}
+
+func TestGetResultCSVEscapesFormulaCells(t *testing.T) {
+	store := newTestResultsStore(t)
+	h := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+
+	// The save API refuses formula addresses outright.
+	for _, field := range []string{"ipv4", "ipv6"} {
+		body := `{"download_mbps": 10, "` + field + `": "=HYPERLINK(\"http://e.vil\",\"x\")"}`
+		req := httptest.NewRequest(http.MethodPost, exampleBaseURL+"/api/v1/results", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("save with formula %s: "+statusWantFmt, field, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	// Rows stored before addresses were validated still download escaped.
+	id := saveStoredResult(t, store, results.Result{
+		DownloadMbps: 10, BufferbloatGrade: "+A", ServerName: "=cmd|' /C calc'!A0",
+		IPv4: `=HYPERLINK("http://e.vil","x")`, IPv6: "@SUM(1+1)",
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/"+id+".csv", nil))
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("csv = %v, %v", records, err)
+	}
+	for i, name := range records[0] {
+		cell := records[1][i]
+		switch name {
+		case "server_name", "bufferbloat_grade", "ipv4", "ipv6":
+			if !strings.HasPrefix(cell, "'") {
+				t.Errorf("%s cell = %q, want a leading apostrophe", name, cell)
+			}
+		case "download_mbps":
+			if cell != "10" {
+				t.Errorf("%s cell = %q, want 10", name, cell)
+			}
+		}
+	}
+}
+
+func TestGetResultFormatErrors(t *testing.T) {
+	store := newTestResultsStore(t)
+	id := saveStoredResult(t, store, results.Result{DownloadMbps: 1})
+	h := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+
+	for _, tc := range []struct {
+		path, accept string
+		want         int
+	}{
+		{id, "image/png", http.StatusNotAcceptable},
+		{id, "text/csv;q=0, application/json;q=0", http.StatusNotAcceptable},
+		{id + ".xml", "", http.StatusBadRequest},
+		{"zzzz9999.csv", "", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/results/"+tc.path, nil)
+		req.Header.Set("Accept", tc.accept)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s (Accept %q): "+statusWantFmt, tc.path, tc.accept, rec.Code, tc.want)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("%s: error content-type = %q, want application/json", tc.path, got)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+// slowBody delivers chunk bytes every interval, count times.
+type slowBody struct {
+	chunk    int
+	interval time.Duration
+	count    int
+}
+
+func (b *slowBody) Read(p []byte) (int, error) {
+	if b.count == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(b.interval)
+	b.count--
+	return copy(p, make([]byte, min(b.chunk, len(p)))), nil
+}
+
+func saveResultFrom(t *testing.T, h http.Handler, download, upload float64) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	body := fmt.Sprintf(`{"download_mbps":%g,"upload_mbps":%g,"latency_ms":10,"jitter_ms":1}`, download, upload)
+	req := httptest.NewRequest(http.MethodPost, exampleBaseURL+"/api/v1/results", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+func TestSaveResultChecksLinkCapacity(t *testing.T) {
+	store := newTestResultsStore(t)
+	cfg := config.DefaultConfig()
+	cfg.LinkCapacityMbps = 1000
+	h := api.NewRouter(cfg, store).SetupRoutes()
+
+	rec, resp := saveResultFrom(t, h, 2000, 0)
+	if rec.Code != http.StatusCreated || resp["plausibility"] != results.PlausibilityImplausible {
+		t.Fatalf("over capacity: status %d, body %v; want 201 flagged implausible", rec.Code, resp)
+	}
+	stored, err := store.Get(context.Background(), resp["id"].(string))
+	if err != nil || stored == nil || stored.Plausibility != results.PlausibilityImplausible {
+		t.Fatalf("stored = %+v, %v; want implausible", stored, err)
+	}
+
+	// Nothing was observed for this client, so an in-capacity result cannot
+	// be confirmed.
+	if _, resp := saveResultFrom(t, h, 900, 0); resp["plausibility"] != results.PlausibilityUnverified {
+		t.Fatalf("in capacity without observations = %v, want unverified", resp["plausibility"])
+	}
+
+	cfg.PlausibilityMode = config.PlausibilityModeReject
+	h = api.NewRouter(cfg, store).SetupRoutes()
+	rec, resp = saveResultFrom(t, h, 10, 1500)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(fmt.Sprint(resp["error"]), "link capacity") {
+		t.Fatalf("reject mode: status %d, body %v; want 422", rec.Code, resp)
+	}
+}
+
+func TestSaveResultChecksObservedThroughput(t *testing.T) {
+	h := api.NewRouter(config.DefaultConfig(), newTestResultsStore(t)).SetupRoutes()
+
+	download := httptest.NewRecorder()
+	h.ServeHTTP(download, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/api/v1/download?duration=1", nil))
+	if download.Code != http.StatusOK {
+		t.Fatalf("download "+statusWantFmt, download.Code, http.StatusOK)
+	}
+	// About 1.2 Mbps over 1.2 s.
+	upload := httptest.NewRecorder()
+	h.ServeHTTP(upload, httptest.NewRequest(http.MethodPost, exampleBaseURL+"/api/v1/upload",
+		&slowBody{chunk: 30000, interval: 200 * time.Millisecond, count: 6}))
+	if upload.Code != http.StatusOK {
+		t.Fatalf("upload "+statusWantFmt, upload.Code, http.StatusOK)
+	}
+
+	if _, resp := saveResultFrom(t, h, 100, 1); resp["plausibility"] != results.PlausibilityPlausible {
+		t.Fatalf("observed result = %v, want plausible", resp["plausibility"])
+	}
+	rec, resp := saveResultFrom(t, h, 100, 50)
+	if rec.Code != http.StatusCreated || resp["plausibility"] != results.PlausibilityImplausible {
+		t.Fatalf("upload far above observed: status %d, body %v; want flagged implausible", rec.Code, resp)
+	}
+}
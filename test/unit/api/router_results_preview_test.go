@@ -0,0 +1,187 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func saveStoredResult(t *testing.T, store *results.Store, r results.Result) string {
+	t.Helper()
+	id, err := store.Save(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return id
+}
+
+func TestResultsPageInjectsSharePreview(t *testing.T) {
+	store := newTestResultsStore(t)
+	id := saveStoredResult(t, store, results.Result{
+		DownloadMbps: 512.34, UploadMbps: 48.06, LatencyMs: 12.4, JitterMs: 1.2,
+		BufferbloatGrade: "A", ServerName: `Berlin <"DE">`,
+	})
+	h := api.NewRouter(config.DefaultConfig(), store).SetupRoutes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/results/"+id, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf(statusWantFmt, rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get(cacheControlKey); got != noStoreHeader {
+		t.Fatalf(routerCacheControlFmt, got, noStoreHeader)
+	}
+	page := rec.Body.String()
+	for _, want := range []string{
+		`<meta property="og:title" content="Speed test: 512 Mbps down, 48.1 Mbps up" />`,
+		`content="Latency 12.4 ms · Jitter 1.2 ms · Bufferbloat A · tested against Berlin &lt;&#34;DE&#34;&gt;"`,
+		`<meta property="og:url" content="http://example.com/results/` + id + `" />`,
+		`<meta property="og:image" content="http://example.com/results/` + id + `/card.png" />`,
+		`<meta name="twitter:card" content="summary_large_image" />`,
+		`<link rel="alternate" type="application/json+oembed" href="http://example.com/api/v1/oembed?format=json&amp;url=http%3A%2F%2Fexample.com%2Fresults%2F` + id + `"`,
+		`<meta name="theme-color"`,
+		`<script`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("results page missing %q", want)
+		}
+	}
+	if strings.Contains(page, "/favicon.svg\" />\n    <!--") || strings.Contains(page, "share-preview") {
+		t.Error("generic share metadata was not replaced")
+	}
+
+	head := httptest.NewRecorder()
+	h.ServeHTTP(head, httptest.NewRequest(http.MethodHead, exampleBaseURL+"/results/"+id, nil))
+	if head.Code != http.StatusOK || head.Body.Len() != 0 {
+		t.Fatalf("HEAD status/body = %d/%d bytes", head.Code, head.Body.Len())
+	}
+}
+
+func TestResultsPageKeepsGenericPreviewForUnknownResult(t *testing.T) {
+	h := api.NewRouter(config.DefaultConfig(), newTestResultsStore(t)).SetupRoutes()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, exampleBaseURL+resultsPagePath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf(statusWantFmt, rec.Code, http.StatusOK)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `content="openByte — Speed Test Result"`) ||
+		strings.Contains(body, "og:url") {
+		t.Fatal("unknown result should get the generic page")
+	}
+}
+
+func TestResultsPagePreviewURLSchemeTrustsOnlyProxies(t *testing.T) {
+	store := newTestResultsStore(t)
+	id := saveStoredResult(t, store, results.Result{DownloadMbps: 10})
+	for _, tc := range []struct {
+		name  string
+		cidrs []string
+		want  string
+	}{
+		{"trusted proxy", []string{"192.0.2.0/24"}, "https://example.com/results/"},
+		{"untrusted peer", []string{"198.51.100.0/24"}, "http://example.com/results/"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.TrustProxyHeaders = true
+			cfg.TrustedProxyCIDRs = tc.cidrs
+			h := api.NewRouter(cfg, store).SetupRoutes()
+			req := httptest.NewRequest(http.MethodGet, exampleBaseURL+"/results/"+id, nil)
+			req.RemoteAddr = "192.0.2.10:4321"
+			req.Header.Set("X-Forwarded-Proto", "https")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if want := `og:url" content="` + tc.want + id; !strings.Contains(rec.Body.String(), want) {
+				t.Fatalf("page lacks %q", want)
+			}
+		})
+	}
+}
+
+func TestResultCardsUseBrandingAndFollowDeletes(t *testing.T) {
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewNRGBA(image.Rect(0, 0, 4, 2))); err != nil {
+		t.Fatalf("encode logo: %v", err)
+	}
+	logoPath := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(logoPath, logo.Bytes(), 0o600); err != nil {
+		t.Fatalf("write logo: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.BrandPrimaryColorDark = "#ff8800"
+	cfg.BrandPrimaryColorLight = "#8a4a00"
+	cfg.BrandLogoPath = logoPath
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	h := api.NewRouter(cfg, newTestResultsStore(t)).SetupRoutes()
+	id, token := saveSharedResult(t, h)
+
+	svg := httptest.NewRecorder()
+	h.ServeHTTP(svg, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/results/"+id+"/card.svg", nil))
+	if svg.Code != http.StatusOK {
+		t.Fatalf("svg "+statusWantFmt, svg.Code, http.StatusOK)
+	}
+	if got := svg.Header().Get(routerContentTypeKey); got != "image/svg+xml" {
+		t.Fatalf(routerContentTypeWantFmt, got, "image/svg+xml")
+	}
+	if got := svg.Header().Get(cacheControlKey); got != "public, max-age=600" {
+		t.Fatalf(routerCacheControlFmt, got, "public, max-age=600")
+	}
+	for _, want := range []string{`fill="#ff8800">100<tspan`, `href="data:image/png;base64,`} {
+		if !strings.Contains(svg.Body.String(), want) {
+			t.Errorf("svg card missing %q", want)
+		}
+	}
+
+	pngRec := httptest.NewRecorder()
+	h.ServeHTTP(pngRec, httptest.NewRequest(http.MethodGet, exampleBaseURL+"/results/"+id+"/card.png", nil))
+	if pngRec.Code != http.StatusOK {
+		t.Fatalf("png "+statusWantFmt, pngRec.Code, http.StatusOK)
+	}
+	card, err := png.Decode(pngRec.Body)
+	if err != nil {
+		t.Fatalf("decode png card: %v", err)
+	}
+	if b := card.Bounds(); b.Dx() != 1200 || b.Dy() != 630 {
+		t.Fatalf("png card is %dx%d, want 1200x630", b.Dx(), b.Dy())
+	}
+	if !imageHasColor(card, color.RGBA{R: 0xff, G: 0x88, A: 0xff}) {
+		t.Fatal("png card does not use the brand primary color")
+	}
+
+	if rec := deleteSharedResult(h, id, token); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete "+statusWantFmt, rec.Code, http.StatusNoContent)
+	}
+	for _, path := range []string{"/results/" + id + "/card.svg", "/results/" + id + "/card.png", "/results/bad!id/card.png"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, exampleBaseURL+path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%s "+statusWantFmt, path, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func imageHasColor(img image.Image, want color.RGBA) bool {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
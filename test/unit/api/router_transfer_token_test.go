@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/api"
+	"github.com/saveenergy/openbyte/internal/config"
+)
+
+func transferTokenConfig(keys ...config.TransferTokenKey) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.TransferTokensRequired = true
+	cfg.TransferTokenKeys = keys
+	return cfg
+}
+
+func testTransferKey(id string, fill byte) config.TransferTokenKey {
+	return config.TransferTokenKey{ID: id, Secret: bytes.Repeat([]byte{fill}, 32)}
+}
+
+func bootstrapTransferToken(t *testing.T, h http.Handler, remoteAddr string, headers map[string]string) (string, int) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, exampleBaseURL+pingAPIPath+"?meta=1", nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("bootstrap ping "+statusWantFmt, rec.Code, http.StatusOK)
+	}
+	var response struct {
+		TransferToken string `json:"transfer_token"`
+		ExpiresIn     int    `json:"transfer_token_expires_in"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode ping: %v", err)
+	}
+	return response.TransferToken, response.ExpiresIn
+}
+
+func transferStatus(h http.Handler, method, path, token, remoteAddr string) int {
+	target := exampleBaseURL + path
+	if token != "" {
+		target += "?duration=1&token=" + url.QueryEscape(token)
+	}
+	var body *strings.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader("payload")
+	} else {
+		body = strings.NewReader("")
+	}
+	req := httptest.NewRequest(method, target, body)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestTransferTokensRequiredForTransfers(t *testing.T) {
+	h := api.NewRouter(transferTokenConfig(testTransferKey("k1", 1)), nil).SetupRoutes()
+	const client = "198.51.100.7:4000"
+
+	if got := transferStatus(h, http.MethodGet, downloadAPIPath, "", client); got != http.StatusForbidden {
+		t.Fatalf("download without token "+statusWantFmt, got, http.StatusForbidden)
+	}
+	if got := transferStatus(h, http.MethodPost, uploadAPIPath, "", client); got != http.StatusForbidden {
+		t.Fatalf("upload without token "+statusWantFmt, got, http.StatusForbidden)
+	}
+
+	token, expiresIn := bootstrapTransferToken(t, h, client, nil)
+	if token == "" || expiresIn != 600 {
+		t.Fatalf("bootstrap token = %q expiring in %d, want token with default 600s TTL", token, expiresIn)
+	}
+	if got := transferStatus(h, http.MethodGet, downloadAPIPath, token, client); got != http.StatusOK {
+		t.Fatalf("download with token "+statusWantFmt, got, http.StatusOK)
+	}
+	if got := transferStatus(h, http.MethodPost, uploadAPIPath, token, client); got != http.StatusOK {
+		t.Fatalf("upload with token "+statusWantFmt, got, http.StatusOK)
+	}
+	if got := transferStatus(h, http.MethodGet, downloadAPIPath, token, "203.0.113.9:4000"); got != http.StatusForbidden {
+		t.Fatalf("token relayed to another client "+statusWantFmt, got, http.StatusForbidden)
+	}
+	if got := transferStatus(h, http.MethodGet, downloadAPIPath, token+"x", client); got != http.StatusForbidden {
+		t.Fatalf("tampered token "+statusWantFmt, got, http.StatusForbidden)
+	}
+}
+
+func TestTransferTokensNotIssuedCrossOrigin(t *testing.T) {
+	h := api.NewRouter(transferTokenConfig(testTransferKey("k1", 1)), nil).SetupRoutes()
+
+	for name, headers := range map[string]map[string]string{
+		"foreign origin":   {"Origin": "https://hotlinker.example"},
+		"cross-site fetch": {"Sec-Fetch-Site": "cross-site"},
+		"same-site fetch":  {"Sec-Fetch-Site": "same-site"},
+	} {
+		if token, _ := bootstrapTransferToken(t, h, "198.51.100.7:4000", headers); token != "" {
+			t.Fatalf("%s: ping issued token %q", name, token)
+		}
+	}
+	token, _ := bootstrapTransferToken(t, h, "198.51.100.7:4000", map[string]string{
+		"Origin":         "http://example.com",
+		"Sec-Fetch-Site": "same-origin",
+	})
+	if token == "" {
+		t.Fatal("same-origin bootstrap should issue a token")
+	}
+}
+
+func TestTransferTokenKeyRotation(t *testing.T) {
+	const client = "198.51.100.7:4000"
+	oldKey := testTransferKey("old", 1)
+	newKey := testTransferKey("new", 2)
+	oldToken, _ := bootstrapTransferToken(t, api.NewRouter(transferTokenConfig(oldKey), nil).SetupRoutes(), client, nil)
+
+	rotated := api.NewRouter(transferTokenConfig(newKey, oldKey), nil).SetupRoutes()
+	if got := transferStatus(rotated, http.MethodGet, downloadAPIPath, oldToken, client); got != http.StatusOK {
+		t.Fatalf("token signed by retiring key "+statusWantFmt, got, http.StatusOK)
+	}
+	newToken, _ := bootstrapTransferToken(t, rotated, client, nil)
+	if !strings.HasPrefix(newToken, "new.") {
+		t.Fatalf("rotated token = %q, want it signed by the first key", newToken)
+	}
+
+	retired := api.NewRouter(transferTokenConfig(newKey), nil).SetupRoutes()
+	if got := transferStatus(retired, http.MethodGet, downloadAPIPath, oldToken, client); got != http.StatusForbidden {
+		t.Fatalf("token signed by removed key "+statusWantFmt, got, http.StatusForbidden)
+	}
+}
+
+func TestTransferTokensOffByDefault(t *testing.T) {
+	h := api.NewRouter(config.DefaultConfig(), nil).SetupRoutes()
+	if token, _ := bootstrapTransferToken(t, h, "198.51.100.7:4000", nil); token != "" {
+		t.Fatalf("default config issued token %q", token)
+	}
+	if got := transferStatus(h, http.MethodPost, uploadAPIPath, "", "198.51.100.7:4000"); got != http.StatusOK {
+		t.Fatalf("upload without token "+statusWantFmt, got, http.StatusOK)
+	}
+}
@@ -0,0 +1,100 @@
+package apikeys_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/apikeys"
+)
+
+func openTestKeyStore(t *testing.T) *apikeys.Store {
+	t.Helper()
+	store, err := apikeys.Open(filepath.Join(t.TempDir(), apikeys.FileName))
+	if err != nil {
+		t.Fatalf("open key store: %v", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestCreateAuthenticateAndRevoke(t *testing.T) {
+	store := openTestKeyStore(t)
+	ctx := context.Background()
+	policy := apikeys.Policy{MaxConcurrent: 4, StartRate: 30, DailyQuota: 1000, MaxDuration: 60 * time.Second}
+
+	key, token, err := store.Create(ctx, "monitoring", policy, false)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(token, "obk_"+key.ID+"_") {
+		t.Fatalf("token %q does not embed key ID %q", token, key.ID)
+	}
+
+	got, err := store.Authenticate(ctx, token)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if got.ID != key.ID || got.Name != "monitoring" || got.Policy != policy {
+		t.Fatalf("authenticated key = %+v, want %+v", got, key)
+	}
+
+	if err := store.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := store.Authenticate(ctx, token); !errors.Is(err, apikeys.ErrInvalidKey) {
+		t.Fatalf("revoked key error = %v, want ErrInvalidKey", err)
+	}
+	if err := store.Revoke(ctx, key.ID); !errors.Is(err, apikeys.ErrNotFound) {
+		t.Fatalf("second revoke error = %v, want ErrNotFound", err)
+	}
+
+	keys, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(keys) != 1 || !keys[0].Revoked() {
+		t.Fatalf("listed keys = %+v, want one revoked key", keys)
+	}
+}
+
+func TestAuthenticateRejectsForgedTokens(t *testing.T) {
+	store := openTestKeyStore(t)
+	ctx := context.Background()
+	key, token, err := store.Create(ctx, "probe", apikeys.Policy{}, false)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	for _, forged := range []string{
+		"",
+		"obk_",
+		"obk_" + key.ID,
+		"obk_" + key.ID + "_wrong-secret",
+		"obk_000000000000_" + strings.TrimPrefix(token, "obk_"+key.ID+"_"),
+		strings.TrimPrefix(token, "obk_"),
+	} {
+		if _, err := store.Authenticate(ctx, forged); !errors.Is(err, apikeys.ErrInvalidKey) {
+			t.Fatalf("Authenticate(%q) error = %v, want ErrInvalidKey", forged, err)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	for name, policy := range map[string]apikeys.Policy{
+		"negative concurrency": {MaxConcurrent: -1},
+		"negative quota":       {DailyQuota: -1},
+		"fractional duration":  {MaxDuration: 1500 * time.Millisecond},
+		"oversized duration":   {MaxDuration: 2 * time.Hour},
+	} {
+		if policy.Validate() == nil {
+			t.Fatalf("%s policy should be rejected", name)
+		}
+	}
+	if err := (apikeys.Policy{}).Validate(); err != nil {
+		t.Fatalf("zero policy should be valid: %v", err)
+	}
+}
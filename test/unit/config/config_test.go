@@ -1,8 +1,12 @@
 package config_test
 
 import (
+	"bytes"
+	"encoding/base64"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -218,6 +222,19 @@ func TestValidateTrustedProxyCIDRsRejectsInvalidCIDR(t *testing.T) {
 	}
 }
 
+func TestTrustedProxyNetworks(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TrustedProxyCIDRs = []string{" 10.0.0.0/8", "", "2001:db8::/32 "}
+	networks, err := cfg.TrustedProxyNetworks()
+	if err != nil || len(networks) != 2 || networks[0].String() != "10.0.0.0/8" || networks[1].String() != "2001:db8::/32" {
+		t.Fatalf("TrustedProxyNetworks = %v, %v", networks, err)
+	}
+	cfg.TrustedProxyCIDRs = append(cfg.TrustedProxyCIDRs, "not-a-cidr")
+	if networks, err := cfg.TrustedProxyNetworks(); err == nil {
+		t.Fatalf("TrustedProxyNetworks with an invalid CIDR = %v, want an error", networks)
+	}
+}
+
 func TestValidateTrustProxyHeadersRequiresTrustedCIDRs(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.TrustProxyHeaders = true
@@ -232,6 +249,25 @@ func TestValidateTrustProxyHeadersRequiresTrustedCIDRs(t *testing.T) {
 	}
 }
 
+func TestValidateProxyProtocolRequiresTrustedCIDRs(t *testing.T) {
+	t.Setenv("PROXY_PROTOCOL", "true")
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load PROXY_PROTOCOL: %v", err)
+	}
+	if !cfg.ProxyProtocol {
+		t.Fatal("PROXY_PROTOCOL=true should enable PROXY protocol")
+	}
+	if cfg.Validate() == nil {
+		t.Fatal("expected error when PROXY protocol enabled without trusted CIDRs")
+	}
+
+	cfg.TrustedProxyCIDRs = []string{"10.0.0.0/8"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected trusted CIDR to satisfy validation: %v", err)
+	}
+}
+
 func TestConfigValidateTLS(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.TLSCertFile = "/tmp/cert.pem"
@@ -395,3 +431,385 @@ func TestValidateServerName(t *testing.T) {
 		t.Fatal("overlong server name should fail validation")
 	}
 }
+
+func TestConfigLoadRateLimitPoliciesEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_POLICIES", "results-save=10/50, ping=600/6000,results-page=off")
+
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load RATE_LIMIT_POLICIES: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate RATE_LIMIT_POLICIES: %v", err)
+	}
+	tests := map[string]config.RateLimitPolicy{
+		config.RateLimitPolicyResultsSave: {PerIP: 10, Global: 50},
+		config.RateLimitPolicyResultsRead: {PerIP: cfg.RateLimitPerIP, Global: cfg.GlobalRateLimit},
+		config.RateLimitPolicyResultsPage: {},
+		config.RateLimitPolicyPing:        {PerIP: 600, Global: 6000},
+	}
+	for name, want := range tests {
+		if got := cfg.RateLimitPolicy(name); got != want {
+			t.Fatalf("policy %s = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestConfigPingRateLimitPolicyDisabledByDefault(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if cfg.RateLimitPolicy(config.RateLimitPolicyPing).Enabled() {
+		t.Fatal("ping rate limit policy should be disabled by default")
+	}
+}
+
+func TestConfigRejectsInvalidRateLimitPolicies(t *testing.T) {
+	for _, raw := range []string{"results-save", "results-save=10", "results-save=0/10", "results-save=a/b"} {
+		t.Run(raw, func(t *testing.T) {
+			t.Setenv("RATE_LIMIT_POLICIES", raw)
+			cfg := config.DefaultConfig()
+			if err := cfg.LoadFromEnv(); err == nil {
+				t.Fatalf("expected RATE_LIMIT_POLICIES=%q to be rejected", raw)
+			}
+		})
+	}
+
+	for name, policies := range map[string]map[string]config.RateLimitPolicy{
+		"unknown":         {"download": {PerIP: 1, Global: 1}},
+		"global below ip": {config.RateLimitPolicyResultsSave: {PerIP: 10, Global: 5}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.RateLimitPolicies = policies
+			if cfg.Validate() == nil {
+				t.Fatalf("expected %s policy to be rejected", name)
+			}
+		})
+	}
+}
+
+func TestConfigLoadTransferTokenEnv(t *testing.T) {
+	secret := strings.Repeat("A", 43)
+	t.Setenv("REQUIRE_TRANSFER_TOKENS", "true")
+	t.Setenv("TRANSFER_TOKEN_TTL", "5m")
+	t.Setenv("TRANSFER_TOKEN_KEYS", "2026b:"+secret+", 2026a:"+secret)
+
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load transfer token env: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate transfer token env: %v", err)
+	}
+	if !cfg.TransferTokensRequired || cfg.TransferTokenTTL != 5*time.Minute {
+		t.Fatalf("transfer tokens = %t/%s, want required with 5m TTL", cfg.TransferTokensRequired, cfg.TransferTokenTTL)
+	}
+	if len(cfg.TransferTokenKeys) != 2 || cfg.TransferTokenKeys[0].ID != "2026b" || len(cfg.TransferTokenKeys[0].Secret) != 32 {
+		t.Fatalf("transfer token keys = %+v, want 2026b then 2026a with 32-byte secrets", cfg.TransferTokenKeys)
+	}
+}
+
+func TestConfigRejectsInvalidTransferTokenKeys(t *testing.T) {
+	secret := strings.Repeat("A", 43)
+	for _, raw := range []string{"nokey", "k1:not base64!"} {
+		t.Run(raw, func(t *testing.T) {
+			t.Setenv("TRANSFER_TOKEN_KEYS", raw)
+			if err := config.DefaultConfig().LoadFromEnv(); err == nil {
+				t.Fatalf("expected TRANSFER_TOKEN_KEYS=%q to be rejected", raw)
+			}
+		})
+	}
+	for _, raw := range []string{"k1:AAAA", "bad id:" + secret, "k1:" + secret + ",k1:" + secret} {
+		t.Run(raw, func(t *testing.T) {
+			t.Setenv("TRANSFER_TOKEN_KEYS", raw)
+			cfg := config.DefaultConfig()
+			if err := cfg.LoadFromEnv(); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if cfg.Validate() == nil {
+				t.Fatalf("expected TRANSFER_TOKEN_KEYS=%q to fail validation", raw)
+			}
+		})
+	}
+	cfg := config.DefaultConfig()
+	cfg.TransferTokenTTL = 10 * time.Second
+	if cfg.Validate() == nil {
+		t.Fatal("expected a TTL below one minute to be rejected")
+	}
+}
+
+func TestConfigLoadUploadThroughputFloorEnv(t *testing.T) {
+	t.Setenv("UPLOAD_MIN_THROUGHPUT_KBPS", "0")
+	t.Setenv("UPLOAD_THROUGHPUT_GRACE", "30s")
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load upload floor env: %v", err)
+	}
+	if cfg.UploadMinThroughputKbps != 0 || cfg.UploadThroughputGrace != 30*time.Second {
+		t.Fatalf("upload floor = %d kbps after %s, want disabled after 30s", cfg.UploadMinThroughputKbps, cfg.UploadThroughputGrace)
+	}
+
+	for name, value := range map[string]string{
+		"UPLOAD_MIN_THROUGHPUT_KBPS": "-1",
+		"UPLOAD_THROUGHPUT_GRACE":    "500ms",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if err := config.DefaultConfig().LoadFromEnv(); err == nil {
+				t.Fatalf("expected %s=%q to be rejected", name, value)
+			}
+		})
+	}
+}
+
+func TestConfigLoadStatsCacheTTLEnv(t *testing.T) {
+	if got := config.DefaultConfig().StatsCacheTTL; got != 5*time.Minute {
+		t.Fatalf("default stats cache TTL = %s, want 5m", got)
+	}
+	t.Setenv("STATS_CACHE_TTL", "0")
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load stats cache env: %v", err)
+	}
+	if cfg.StatsCacheTTL != 0 {
+		t.Fatalf("stats cache TTL = %s, want disabled", cfg.StatsCacheTTL)
+	}
+	t.Setenv("STATS_CACHE_TTL", "-1m")
+	if err := config.DefaultConfig().LoadFromEnv(); err == nil {
+		t.Fatal("expected negative STATS_CACHE_TTL to be rejected")
+	}
+}
+
+func TestConfigLoadPlausibilityEnv(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if cfg.LinkCapacityMbps != 0 || cfg.PlausibilityMode != config.PlausibilityModeFlag {
+		t.Fatalf("defaults = %d/%q, want unchecked capacity and flag mode", cfg.LinkCapacityMbps, cfg.PlausibilityMode)
+	}
+	t.Setenv("LINK_CAPACITY_MBPS", "1000")
+	t.Setenv("PLAUSIBILITY_MODE", " Reject ")
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load plausibility env: %v", err)
+	}
+	if cfg.LinkCapacityMbps != 1000 || cfg.PlausibilityMode != config.PlausibilityModeReject {
+		t.Fatalf("loaded = %d/%q, want 1000 and reject", cfg.LinkCapacityMbps, cfg.PlausibilityMode)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	t.Setenv("LINK_CAPACITY_MBPS", "-1")
+	if err := config.DefaultConfig().LoadFromEnv(); err == nil {
+		t.Fatal("expected negative LINK_CAPACITY_MBPS to be rejected")
+	}
+	t.Setenv("LINK_CAPACITY_MBPS", "")
+	t.Setenv("PLAUSIBILITY_MODE", "warn")
+	cfg = config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected unknown PLAUSIBILITY_MODE to fail validation")
+	}
+}
+
+func TestConfigLoadClientIPHeadersEnv(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if !slices.Equal(cfg.ClientIPHeaders, []string{config.ClientIPHeaderXForwardedFor, config.ClientIPHeaderXRealIP}) {
+		t.Fatalf("default client IP headers = %v, want X-Forwarded-For then X-Real-IP", cfg.ClientIPHeaders)
+	}
+	t.Setenv("CLIENT_IP_HEADERS", " Forwarded , X-Forwarded-For")
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load client IP headers: %v", err)
+	}
+	if !slices.Equal(cfg.ClientIPHeaders, []string{config.ClientIPHeaderForwarded, config.ClientIPHeaderXForwardedFor}) {
+		t.Fatalf("loaded client IP headers = %v, want forwarded then x-forwarded-for", cfg.ClientIPHeaders)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	for _, value := range []string{"x-client-ip", "forwarded,Forwarded"} {
+		t.Setenv("CLIENT_IP_HEADERS", value)
+		cfg = config.DefaultConfig()
+		if err := cfg.LoadFromEnv(); err != nil {
+			t.Fatalf("load %q: %v", value, err)
+		}
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("expected CLIENT_IP_HEADERS=%q to fail validation", value)
+		}
+	}
+}
+
+func TestConfigResultRetention(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if defaultRetention, maxRetention := cfg.ResultRetention(); defaultRetention != 90*24*time.Hour || maxRetention != defaultRetention {
+		t.Fatalf("default retention = %s/%s, want 90 days for both", defaultRetention, maxRetention)
+	}
+
+	t.Setenv("RESULT_RETENTION_DAYS", "7")
+	t.Setenv("RESULT_MAX_RETENTION_DAYS", "30")
+	cfg = config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load retention env: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if defaultRetention, maxRetention := cfg.ResultRetention(); defaultRetention != 7*24*time.Hour || maxRetention != 30*24*time.Hour {
+		t.Fatalf("retention = %s/%s, want 7 and 30 days", defaultRetention, maxRetention)
+	}
+
+	cfg.ResultRetentionDays = 60
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected default retention above the maximum to be rejected")
+	}
+	t.Setenv("RESULT_RETENTION_DAYS", "0")
+	if err := config.DefaultConfig().LoadFromEnv(); err == nil {
+		t.Fatal("expected RESULT_RETENTION_DAYS=0 to be rejected")
+	}
+}
+
+func TestConfigIPPrivacy(t *testing.T) {
+	t.Setenv("IP_STORAGE_MODE", "Hash")
+	t.Setenv("IP_HASH_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	t.Setenv("IP_ANONYMIZE_AFTER_DAYS", "30")
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load IP privacy env: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if cfg.IPStorageMode != config.IPStorageHash || len(cfg.IPHashKey) != 32 || cfg.IPAnonymizeAfter() != 30*24*time.Hour {
+		t.Fatalf("IP privacy = %q, %d-byte key, %s", cfg.IPStorageMode, len(cfg.IPHashKey), cfg.IPAnonymizeAfter())
+	}
+
+	cfg.IPHashKey = []byte("short")
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected short IP hash key to be rejected")
+	}
+	cfg.IPStorageMode = "mask"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected unknown IP storage mode to be rejected")
+	}
+	if config.DefaultConfig().IPStorageMode != config.IPStorageFull {
+		t.Fatal("default IP storage mode should keep full addresses")
+	}
+}
+
+func TestConfigFieldEncryptionKeys(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, 32))
+	path := filepath.Join(t.TempDir(), "field-keys")
+	if err := os.WriteFile(path, []byte("# newest first\nnew:"+key+"\n\nold:"+key+"\n"), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	t.Setenv("FIELD_ENCRYPTION_KEY_FILE", path)
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load key file: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(cfg.FieldEncryptionKeys) != 2 || cfg.FieldEncryptionKeys[0].ID != "new" {
+		t.Fatalf("keys = %+v, want new then old", cfg.FieldEncryptionKeys)
+	}
+
+	t.Setenv("FIELD_ENCRYPTION_KEYS", "env:"+key)
+	if err := config.DefaultConfig().LoadFromEnv(); err == nil {
+		t.Fatal("expected both key sources to be rejected")
+	}
+
+	cfg.FieldEncryptionKeys[1].Key = []byte("short")
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected a non-32-byte key to be rejected")
+	}
+}
+
+func TestConfigResultsBackend(t *testing.T) {
+	if got := config.DefaultConfig().ResultsBackend; got != config.ResultsBackendSQLite {
+		t.Fatalf("default backend = %q, want sqlite", got)
+	}
+	t.Setenv("RESULTS_BACKEND", "NDJSON")
+	cfg := config.DefaultConfig()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := cfg.Validate(); err != nil || cfg.ResultsBackend != config.ResultsBackendNDJSON {
+		t.Fatalf("backend = %q, err = %v", cfg.ResultsBackend, err)
+	}
+	cfg.ResultsBackend = "postgres"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected unknown results backend to be rejected")
+	}
+}
+
+func TestConfigBackups(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if cfg.BackupInterval != 0 || cfg.BackupKeep != 7 || cfg.ResultBackupDir() != filepath.Join(cfg.DataDir, "backups") {
+		t.Fatalf("defaults = %s/%d/%q", cfg.BackupInterval, cfg.BackupKeep, cfg.ResultBackupDir())
+	}
+	t.Setenv("BACKUP_DIR", "/srv/backups")
+	t.Setenv("BACKUP_INTERVAL", "6h")
+	t.Setenv("BACKUP_KEEP", "14")
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if cfg.ResultBackupDir() != "/srv/backups" || cfg.BackupInterval != 6*time.Hour || cfg.BackupKeep != 14 {
+		t.Fatalf("loaded = %q/%s/%d", cfg.ResultBackupDir(), cfg.BackupInterval, cfg.BackupKeep)
+	}
+
+	for name, mutate := range map[string]func(*config.Config){
+		"short interval": func(c *config.Config) { c.BackupInterval = time.Second },
+		"memory backend": func(c *config.Config) { c.ResultsBackend = config.ResultsBackendMemory },
+		"zero keep":      func(c *config.Config) { c.BackupKeep = 0 },
+	} {
+		bad := *cfg
+		mutate(&bad)
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	t.Setenv("BACKUP_INTERVAL", "-1h")
+	if err := config.DefaultConfig().LoadFromEnv(); err == nil {
+		t.Fatal("expected negative BACKUP_INTERVAL to be rejected")
+	}
+}
+
+func TestConfigReplica(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if cfg.ReplicaDir != "" || cfg.ReplicaSnapshotInterval != time.Hour {
+		t.Fatalf("defaults = %q/%s", cfg.ReplicaDir, cfg.ReplicaSnapshotInterval)
+	}
+	t.Setenv("REPLICA_DIR", "/mnt/replica")
+	t.Setenv("REPLICA_SNAPSHOT_INTERVAL", "30m")
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if cfg.ReplicaDir != "/mnt/replica" || cfg.ReplicaSnapshotInterval != 30*time.Minute {
+		t.Fatalf("loaded = %q/%s", cfg.ReplicaDir, cfg.ReplicaSnapshotInterval)
+	}
+
+	for name, mutate := range map[string]func(*config.Config){
+		"short interval": func(c *config.Config) { c.ReplicaSnapshotInterval = time.Second },
+		"ndjson backend": func(c *config.Config) { c.ResultsBackend = config.ResultsBackendNDJSON },
+		"data dir":       func(c *config.Config) { c.ReplicaDir = c.DataDir + "/" },
+	} {
+		bad := *cfg
+		mutate(&bad)
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	t.Setenv("REPLICA_SNAPSHOT_INTERVAL", "0")
+	if err := config.DefaultConfig().LoadFromEnv(); err == nil {
+		t.Fatal("expected zero REPLICA_SNAPSHOT_INTERVAL to be rejected")
+	}
+}
@@ -0,0 +1,144 @@
+package conninfo_test
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/conninfo"
+	"github.com/saveenergy/openbyte/internal/tlsutil"
+)
+
+type connFacts struct {
+	Handshake time.Duration
+	TLS       bool
+	Socket    bool
+	MSS       uint32
+}
+
+func factsHandler(w http.ResponseWriter, r *http.Request) {
+	info := conninfo.FromContext(r.Context())
+	if info == nil {
+		http.Error(w, "no connection info", http.StatusInternalServerError)
+		return
+	}
+	stats, ok := conninfo.SocketStats(info.Conn)
+	_ = json.NewEncoder(w).Encode(connFacts{
+		Handshake: info.Handshake,
+		TLS:       r.TLS != nil,
+		Socket:    ok,
+		MSS:       stats.MSS,
+	})
+}
+
+func getFacts(t *testing.T, client *http.Client, url string) connFacts {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	var facts connFacts
+	if err := json.NewDecoder(resp.Body).Decode(&facts); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return facts
+}
+
+func TestTLSListenerTimesHandshake(t *testing.T) {
+	cert, err := tlsutil.SelfSignedLocalhost()
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	tlsListener := conninfo.NewTLSListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}}, 5*time.Second)
+	srv := &http.Server{Handler: http.HandlerFunc(factsHandler), ConnContext: tlsListener.ConnContext}
+	go func() { _ = srv.Serve(tlsListener) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	t.Cleanup(client.CloseIdleConnections)
+	facts := getFacts(t, client, "https://"+ln.Addr().String())
+	if !facts.TLS || facts.Handshake <= 0 {
+		t.Fatalf("facts = %+v, want a timed TLS handshake", facts)
+	}
+	if runtime.GOOS == "linux" && (!facts.Socket || facts.MSS == 0) {
+		t.Fatalf("facts = %+v, want TCP_INFO on Linux", facts)
+	}
+}
+
+func TestTLSListenerDropsFailedHandshakes(t *testing.T) {
+	cert, err := tlsutil.SelfSignedLocalhost()
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	tlsListener := conninfo.NewTLSListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}}, 5*time.Second)
+	accepted := make(chan error, 1)
+	go func() {
+		conn, err := tlsListener.Accept()
+		if err == nil {
+			conn.Close()
+		}
+		accepted <- err
+	}()
+
+	plain, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	_, _ = plain.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	plain.Close()
+
+	select {
+	case err := <-accepted:
+		t.Fatalf("Accept returned %v for a connection that never completed TLS", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := tlsListener.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := <-accepted; !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("Accept after Close = %v, want net.ErrClosed", err)
+	}
+}
+
+func TestConnContextWithoutTLS(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(factsHandler), ConnContext: conninfo.ConnContext}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	client := &http.Client{Transport: &http.Transport{}}
+	t.Cleanup(client.CloseIdleConnections)
+	facts := getFacts(t, client, "http://"+ln.Addr().String())
+	if facts.TLS || facts.Handshake != 0 {
+		t.Fatalf("facts = %+v, want no TLS handshake", facts)
+	}
+	if runtime.GOOS == "linux" && !facts.Socket {
+		t.Fatalf("facts = %+v, want TCP_INFO on Linux", facts)
+	}
+}
+
+func TestSocketStatsRejectsNonTCP(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	if _, ok := conninfo.SocketStats(server); ok {
+		t.Fatal("SocketStats on a pipe reported TCP statistics")
+	}
+}
@@ -85,12 +85,36 @@ func TestComposeUsesCanonicalContainerContract(t *testing.T) {
 		"BRAND_LOGO_PATH",
 		"TRUST_PROXY_HEADERS",
 		"TRUSTED_PROXY_CIDRS",
+		"CLIENT_IP_HEADERS",
+		"PROXY_PROTOCOL",
 		"MAX_CONCURRENT_TRANSFERS",
 		"MAX_CONCURRENT_PER_IP",
 		"RATE_LIMIT_PER_IP",
 		"GLOBAL_RATE_LIMIT",
+		"UPLOAD_MIN_THROUGHPUT_KBPS",
+		"UPLOAD_THROUGHPUT_GRACE",
+		"RATE_LIMIT_POLICIES",
+		"REQUIRE_TRANSFER_TOKENS",
+		"TRANSFER_TOKEN_TTL",
+		"TRANSFER_TOKEN_KEYS",
 		"MAX_TEST_DURATION",
+		"RESULTS_BACKEND",
 		"MAX_STORED_RESULTS",
+		"RESULT_RETENTION_DAYS",
+		"RESULT_MAX_RETENTION_DAYS",
+		"LINK_CAPACITY_MBPS",
+		"PLAUSIBILITY_MODE",
+		"IP_STORAGE_MODE",
+		"IP_HASH_KEY",
+		"IP_ANONYMIZE_AFTER_DAYS",
+		"FIELD_ENCRYPTION_KEYS",
+		"FIELD_ENCRYPTION_KEY_FILE",
+		"STATS_CACHE_TTL",
+		"BACKUP_INTERVAL",
+		"BACKUP_KEEP",
+		"BACKUP_DIR",
+		"REPLICA_DIR",
+		"REPLICA_SNAPSHOT_INTERVAL",
 	}
 	if got := composeEnvironmentEntries(t, compose); !slices.Equal(got, wantEnvironment) {
 		t.Fatalf("Compose environment = %q, want explicit overrides %q", got, wantEnvironment)
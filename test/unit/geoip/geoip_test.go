@@ -0,0 +1,264 @@
+package geoip_test
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/geoip"
+	"github.com/saveenergy/openbyte/internal/geoip/geoiptest"
+)
+
+func writeDatabase(t *testing.T, path, databaseType string, modTime time.Time, networks ...geoiptest.Network) {
+	t.Helper()
+	if err := geoiptest.WriteFile(path, databaseType, networks...); err != nil {
+		t.Fatalf("write database: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes database: %v", err)
+	}
+}
+
+func asnNetworks() []geoiptest.Network {
+	return []geoiptest.Network{
+		{CIDR: "192.0.2.0/24", Record: map[string]any{
+			"autonomous_system_number":       uint32(64500),
+			"autonomous_system_organization": "Example Transit",
+		}},
+		{CIDR: "2001:db8::/32", Record: map[string]any{
+			"autonomous_system_number":       uint32(64501),
+			"autonomous_system_organization": "Example Broadband",
+		}},
+	}
+}
+
+func openDB(t *testing.T, dir string) *geoip.DB {
+	t.Helper()
+	db, err := geoip.Open(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return db
+}
+
+func TestLookupMaxMindASNDatabase(t *testing.T) {
+	dir := t.TempDir()
+	writeDatabase(t, filepath.Join(dir, "GeoLite2-ASN.mmdb"), "GeoLite2-ASN", time.Unix(1000, 0), asnNetworks()...)
+	db := openDB(t, dir)
+
+	tests := []struct {
+		ip   string
+		want geoip.Info
+	}{
+		{ip: "192.0.2.10", want: geoip.Info{ASN: 64500, Organization: "Example Transit"}},
+		{ip: "2001:db8::1", want: geoip.Info{ASN: 64501, Organization: "Example Broadband"}},
+		{ip: "198.51.100.7", want: geoip.Info{}},
+		{ip: "2001:db9::1", want: geoip.Info{}},
+	}
+	for _, test := range tests {
+		if got := db.Lookup(net.ParseIP(test.ip)); got != test.want {
+			t.Fatalf("Lookup(%s) = %+v, want %+v", test.ip, got, test.want)
+		}
+	}
+	if got := db.Lookup(nil); got != (geoip.Info{}) {
+		t.Fatalf("Lookup(nil) = %+v, want empty", got)
+	}
+}
+
+func TestLookupMergesDatabasesInNameOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeDatabase(t, filepath.Join(dir, "a-asn.mmdb"), "GeoLite2-ASN", time.Unix(1000, 0), asnNetworks()...)
+	writeDatabase(t, filepath.Join(dir, "b-country.mmdb"), "GeoLite2-Country", time.Unix(1000, 0),
+		geoiptest.Network{CIDR: "192.0.2.0/24", Record: map[string]any{
+			"autonomous_system_organization": "Ignored Name",
+			"country":                        map[string]any{"iso_code": "DE", "names": map[string]any{"en": "Germany"}},
+		}},
+		geoiptest.Network{CIDR: "2001:db8::/32", Record: map[string]any{
+			"registered_country": map[string]any{"iso_code": "NL"},
+		}},
+	)
+	db := openDB(t, dir)
+
+	if got, want := db.Lookup(net.ParseIP("192.0.2.10")), (geoip.Info{ASN: 64500, Organization: "Example Transit", Country: "DE"}); got != want {
+		t.Fatalf("IPv4 lookup = %+v, want %+v", got, want)
+	}
+	if got, want := db.Lookup(net.ParseIP("2001:db8::1")), (geoip.Info{ASN: 64501, Organization: "Example Broadband", Country: "NL"}); got != want {
+		t.Fatalf("IPv6 lookup = %+v, want %+v", got, want)
+	}
+}
+
+func TestLookupIPinfoLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeDatabase(t, filepath.Join(dir, "ipinfo_lite.mmdb"), "ipinfo ipinfo_lite.mmdb", time.Unix(1000, 0),
+		geoiptest.Network{CIDR: "203.0.113.0/24", Record: map[string]any{
+			"asn":          "AS64502",
+			"as_name":      "Example Hosting",
+			"country":      "Japan",
+			"country_code": "jp",
+		}},
+	)
+	db := openDB(t, dir)
+
+	want := geoip.Info{ASN: 64502, Organization: "Example Hosting", Country: "JP"}
+	if got := db.Lookup(net.ParseIP("203.0.113.9")); got != want {
+		t.Fatalf("Lookup = %+v, want %+v", got, want)
+	}
+}
+
+func TestOpenWithoutDatabasesAnnotatesNothing(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "missing"))
+	if got := db.Lookup(net.ParseIP("192.0.2.10")); got != (geoip.Info{}) {
+		t.Fatalf("Lookup = %+v, want empty", got)
+	}
+	var nilDB *geoip.DB
+	if got := nilDB.Lookup(net.ParseIP("192.0.2.10")); got != (geoip.Info{}) {
+		t.Fatalf("nil Lookup = %+v, want empty", got)
+	}
+}
+
+func TestOpenRejectsMalformedDatabase(t *testing.T) {
+	for name, content := range map[string][]byte{
+		"no metadata": []byte("not a database"),
+		"truncated":   truncatedDatabase(t),
+		"huge tree":   lyingDatabase(t, map[string]any{"node_count": uint64(1) << 62, "record_size": uint16(32)}),
+		"no nodes":    lyingDatabase(t, map[string]any{"node_count": uint32(0)}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "broken.mmdb"), content, 0o600); err != nil {
+				t.Fatalf("write database: %v", err)
+			}
+			if _, err := geoip.Open(dir); err == nil {
+				t.Fatal("expected malformed database to be rejected")
+			}
+		})
+	}
+}
+
+// truncatedDatabase keeps the metadata but drops most of the search tree.
+func truncatedDatabase(t *testing.T) []byte {
+	t.Helper()
+	buf, err := geoiptest.Build("GeoLite2-ASN", asnNetworks()...)
+	if err != nil {
+		t.Fatalf("build database: %v", err)
+	}
+	return buf[bytes.LastIndex(buf, []byte("\xab\xcd\xefMaxMind.com")):]
+}
+
+// lyingDatabase builds a valid search tree under metadata that misdescribes
+// it.
+func lyingDatabase(t *testing.T, metadata map[string]any) []byte {
+	t.Helper()
+	buf, err := geoiptest.BuildWithMetadata("GeoLite2-ASN", metadata, asnNetworks()...)
+	if err != nil {
+		t.Fatalf("build database: %v", err)
+	}
+	return buf
+}
+
+// pointer encodes a one-byte-offset pointer to offset in the data section.
+func pointer(offset int) []byte {
+	return []byte{0x20 | byte(offset>>8), byte(offset)}
+}
+
+func TestLookupStopsOnPointerExpansion(t *testing.T) {
+	for name, loop := range map[string]geoiptest.Raw{
+		// The record is the first value in the data section, so offset 0
+		// points back at it.
+		"self-referential": pointer(0),
+		"shared":           sharedChain(30),
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeDatabase(t, filepath.Join(dir, "GeoLite2-ASN.mmdb"), "GeoLite2-ASN", time.Unix(1000, 0),
+				geoiptest.Network{CIDR: "192.0.2.0/24", Record: map[string]any{"loop": loop}})
+			db := openDB(t, dir)
+
+			done := make(chan geoip.Info, 1)
+			go func() { done <- db.Lookup(net.ParseIP("192.0.2.10")) }()
+			select {
+			case got := <-done:
+				if got != (geoip.Info{}) {
+					t.Fatalf("Lookup = %+v, want empty", got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Lookup did not return")
+			}
+		})
+	}
+}
+
+// sharedChain returns a pointer to the last of n maps that each hold two
+// pointers to the map before them, so decoding it visits 2^n leaves within
+// the depth limit. It is placed right after the record's `{"loop":` header.
+func sharedChain(n int) geoiptest.Raw {
+	const base = 6
+	raw := geoiptest.Raw{0, 0, 0x41, 'x'}
+	prev := base + 2
+	for range n {
+		node := base + len(raw)
+		raw = append(raw, 0xe2, 0x41, 'a')
+		raw = append(raw, pointer(prev)...)
+		raw = append(raw, 0x41, 'b')
+		raw = append(raw, pointer(prev)...)
+		prev = node
+	}
+	copy(raw, pointer(prev))
+	return raw
+}
+
+func TestReloadIfChangedPicksUpNewFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "GeoLite2-ASN.mmdb")
+	writeDatabase(t, path, "GeoLite2-ASN", time.Unix(1000, 0), asnNetworks()...)
+	db := openDB(t, dir)
+
+	writeDatabase(t, path, "GeoLite2-ASN", time.Unix(2000, 0),
+		geoiptest.Network{CIDR: "192.0.2.0/24", Record: map[string]any{
+			"autonomous_system_number":       uint32(64510),
+			"autonomous_system_organization": "Renumbered",
+		}},
+	)
+	if err := db.ReloadIfChanged(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := db.Lookup(net.ParseIP("192.0.2.10")); got.ASN != 64510 || got.Organization != "Renumbered" {
+		t.Fatalf("Lookup after reload = %+v, want AS64510", got)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove database: %v", err)
+	}
+	if err := db.ReloadIfChanged(); err != nil {
+		t.Fatalf("reload after removal: %v", err)
+	}
+	if got := db.Lookup(net.ParseIP("192.0.2.10")); got != (geoip.Info{}) {
+		t.Fatalf("Lookup after removal = %+v, want empty", got)
+	}
+}
+
+func TestReloadIfChangedKeepsDatabasesOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "GeoLite2-ASN.mmdb")
+	writeDatabase(t, path, "GeoLite2-ASN", time.Unix(1000, 0), asnNetworks()...)
+	db := openDB(t, dir)
+
+	if err := os.WriteFile(path, []byte("half-copied"), 0o600); err != nil {
+		t.Fatalf("write database: %v", err)
+	}
+	if err := os.Chtimes(path, time.Unix(2000, 0), time.Unix(2000, 0)); err != nil {
+		t.Fatalf("chtimes database: %v", err)
+	}
+	if err := db.ReloadIfChanged(); err == nil {
+		t.Fatal("expected reload of malformed database to fail")
+	}
+	if got := db.Lookup(net.ParseIP("192.0.2.10")); got.ASN != 64500 {
+		t.Fatalf("Lookup after failed reload = %+v, want previous AS64500", got)
+	}
+	if err := db.ReloadIfChanged(); err != nil {
+		t.Fatalf("unchanged failed file should not be retried: %v", err)
+	}
+}
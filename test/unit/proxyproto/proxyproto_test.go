@@ -0,0 +1,223 @@
+package proxyproto_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/conninfo"
+	"github.com/saveenergy/openbyte/internal/proxyproto"
+)
+
+const (
+	loopbackCIDR = "127.0.0.0/8"
+	privateCIDR  = "10.0.0.0/8"
+	httpRequest  = "GET / HTTP/1.1\r\nHost: openbyte.test\r\nConnection: close\r\n\r\n"
+	v2Signature  = "\r\n\r\n\x00\r\nQUIT\n"
+)
+
+// serve runs an HTTP server behind a PROXY protocol listener that trusts
+// cidr and answers every request with its RemoteAddr.
+func serve(t *testing.T, cidr string, timeout time.Duration) string {
+	t.Helper()
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("parse CIDR: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, r.RemoteAddr)
+		}),
+		ConnContext:       conninfo.ConnContext,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() { _ = srv.Serve(proxyproto.NewListener(ln, []*net.IPNet{network}, timeout)) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return ln.Addr().String()
+}
+
+// roundTrip sends prefix followed by a GET and returns the RemoteAddr the
+// handler saw, or an error when the server dropped or refused the request.
+func roundTrip(t *testing.T, addr, prefix string) (string, error) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.WriteString(conn, prefix+httpRequest); err != nil {
+		return "", err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("status %d: %s", resp.StatusCode, body)
+	}
+	return string(body), err
+}
+
+func v2Header(command, family byte, addresses []byte) string {
+	header := []byte(v2Signature)
+	header = append(header, 0x20|command, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addresses)))
+	return string(append(header, addresses...))
+}
+
+func v2Inet(src, dst net.IP, srcPort, dstPort uint16) []byte {
+	var addresses []byte
+	addresses = append(addresses, src...)
+	addresses = append(addresses, dst...)
+	addresses = binary.BigEndian.AppendUint16(addresses, srcPort)
+	return binary.BigEndian.AppendUint16(addresses, dstPort)
+}
+
+func TestListenerReadsHeaders(t *testing.T) {
+	addr := serve(t, loopbackCIDR, time.Second)
+	inet4 := v2Inet(net.ParseIP("203.0.113.10").To4(), net.ParseIP("192.0.2.1").To4(), 51000, 443)
+	inet6 := v2Inet(net.ParseIP("2001:db8::17"), net.ParseIP("2001:db8::1"), 4711, 443)
+	tlv := []byte{0x04, 0x00, 0x03, 'n', 'l', 'b'}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   string
+	}{
+		{name: "v1 TCP4", prefix: "PROXY TCP4 203.0.113.10 192.0.2.1 51000 443\r\n", want: "203.0.113.10:51000"},
+		{name: "v1 TCP6", prefix: "PROXY TCP6 2001:db8::17 2001:db8::1 4711 443\r\n", want: "[2001:db8::17]:4711"},
+		{name: "v2 TCP4", prefix: v2Header(0x1, 0x11, inet4), want: "203.0.113.10:51000"},
+		{name: "v2 TCP6 with TLV", prefix: v2Header(0x1, 0x21, append(inet6, tlv...)), want: "[2001:db8::17]:4711"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := roundTrip(t, addr, test.prefix)
+			if err != nil {
+				t.Fatalf("round trip: %v", err)
+			}
+			if got != test.want {
+				t.Fatalf("RemoteAddr = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestListenerKeepsConnectionAddress(t *testing.T) {
+	addr := serve(t, loopbackCIDR, time.Second)
+	tests := []struct {
+		name   string
+		prefix string
+	}{
+		{name: "no header", prefix: ""},
+		{name: "v1 UNKNOWN", prefix: "PROXY UNKNOWN\r\n"},
+		{name: "v2 LOCAL", prefix: v2Header(0x0, 0x00, nil)},
+		{name: "v2 UDP", prefix: v2Header(0x1, 0x12, v2Inet(net.ParseIP("203.0.113.10").To4(), net.ParseIP("192.0.2.1").To4(), 1, 2))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := roundTrip(t, addr, test.prefix)
+			if err != nil {
+				t.Fatalf("round trip: %v", err)
+			}
+			if !strings.HasPrefix(got, "127.0.0.1:") {
+				t.Fatalf("RemoteAddr = %q, want the loopback connection address", got)
+			}
+		})
+	}
+}
+
+func TestListenerRejectsMalformedHeaders(t *testing.T) {
+	addr := serve(t, loopbackCIDR, time.Second)
+	tests := []struct {
+		name   string
+		prefix string
+	}{
+		{name: "v1 missing CRLF", prefix: "PROXY TCP4 203.0.113.10 192.0.2.1 51000 443\n"},
+		{name: "v1 family mismatch", prefix: "PROXY TCP4 2001:db8::17 192.0.2.1 51000 443\r\n"},
+		{name: "v1 bad port", prefix: "PROXY TCP4 203.0.113.10 192.0.2.1 70000 443\r\n"},
+		{name: "v1 too long", prefix: "PROXY TCP6 " + strings.Repeat("0", 120) + "\r\n"},
+		{name: "v2 bad version", prefix: v2Signature + "\x11\x11\x00\x00"},
+		{name: "v2 short addresses", prefix: v2Header(0x1, 0x11, []byte{203, 0, 113, 10})},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, err := roundTrip(t, addr, test.prefix); err == nil {
+				t.Fatalf("malformed header was served with RemoteAddr %q", got)
+			}
+		})
+	}
+}
+
+func TestListenerIgnoresHeadersFromUntrustedPeers(t *testing.T) {
+	addr := serve(t, privateCIDR, time.Second)
+	// The header reaches the HTTP parser unread, which rejects it.
+	if got, err := roundTrip(t, addr, "PROXY TCP4 203.0.113.10 192.0.2.1 51000 443\r\n"); err == nil {
+		t.Fatalf("untrusted peer's header was served with RemoteAddr %q", got)
+	}
+	got, err := roundTrip(t, addr, "")
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	if !strings.HasPrefix(got, "127.0.0.1:") {
+		t.Fatalf("RemoteAddr = %q, want the loopback connection address", got)
+	}
+}
+
+func TestListenerTimesOutIncompleteHeaders(t *testing.T) {
+	addr := serve(t, loopbackCIDR, 50*time.Millisecond)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "PROXY TCP4 203.0.113.10"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("server answered an incomplete header")
+	} else if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Fatal("server kept a stalled header open past its timeout")
+	}
+}
+
+func TestSocketStatsUnwrapsProxyConn(t *testing.T) {
+	_, network, _ := net.ParseCIDR(loopbackCIDR)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	pl := proxyproto.NewListener(ln, []*net.IPNet{network}, time.Second)
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+	server, err := pl.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer server.Close()
+	if _, ok := server.(*proxyproto.Conn); !ok {
+		t.Fatalf("accepted %T, want *proxyproto.Conn", server)
+	}
+	_, direct := conninfo.SocketStats(server.(*proxyproto.Conn).NetConn())
+	if _, ok := conninfo.SocketStats(server); ok != direct {
+		t.Fatalf("SocketStats through PROXY conn = %v, want %v", ok, direct)
+	}
+}
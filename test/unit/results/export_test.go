@@ -0,0 +1,245 @@
+package results_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []results.ExportFormat{results.FormatNDJSON, results.FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			forEachStorage(t, func(t *testing.T, _ storageBackend, src results.Storage, _ string) {
+				ctx := context.Background()
+				var ids []string
+				for i := range 3 {
+					id, err := src.Save(ctx, results.Result{
+						DownloadMbps: 100.25 * float64(i+1), LatencyMs: 9.5, BufferbloatGrade: "B",
+						IPv4: "198.51.100.7", ServerName: "Berlin, \"DE\"",
+					})
+					if err != nil {
+						t.Fatalf(storeSaveFmt, err)
+					}
+					ids = append(ids, id)
+				}
+
+				var buf bytes.Buffer
+				n, err := results.Export(ctx, src, &buf, format, results.Query{})
+				if err != nil || n != 3 {
+					t.Fatalf("Export = %d, %v; want 3", n, err)
+				}
+				exported := buf.String()
+
+				dst, err := results.New(filepath.Join(t.TempDir(), "dst.db"), 100)
+				if err != nil {
+					t.Fatalf(storeNewErrFmt, err)
+				}
+				defer dst.Close()
+				report, err := results.Import(ctx, dst, strings.NewReader(exported), format)
+				if err != nil || report.Imported != 3 || len(report.Collisions) != 0 {
+					t.Fatalf("Import = %+v, %v", report, err)
+				}
+				for _, id := range ids {
+					want, err := src.Get(ctx, id)
+					if err != nil || want == nil {
+						t.Fatalf("source Get %s = %v, %v", id, want, err)
+					}
+					got, err := dst.Get(ctx, id)
+					if err != nil || got == nil {
+						t.Fatalf("imported Get %s = %v, %v", id, got, err)
+					}
+					if got.DownloadMbps != want.DownloadMbps || got.ServerName != want.ServerName || got.IPv4 != want.IPv4 ||
+						!got.CreatedAt.Equal(want.CreatedAt) || !got.ExpiresAt.Equal(want.ExpiresAt) {
+						t.Fatalf("imported %+v, want %+v", got, want)
+					}
+				}
+
+				again, err := results.Import(ctx, dst, strings.NewReader(exported), format)
+				if err != nil || again.Imported != 0 || len(again.Collisions) != 3 {
+					t.Fatalf("re-import = %+v, %v; want three collisions", again, err)
+				}
+			})
+		})
+	}
+}
+
+func TestExportFiltersByTime(t *testing.T) {
+	ctx := context.Background()
+	s, cleanup := tempStore(t, 100)
+	defer cleanup()
+	if _, err := s.Save(ctx, results.Result{DownloadMbps: 1}); err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	var buf bytes.Buffer
+	n, err := results.Export(ctx, s, &buf, results.FormatCSV, results.Query{To: time.Now().Add(-time.Hour)})
+	if err != nil || n != 0 {
+		t.Fatalf("Export(to an hour ago) = %d, %v; want 0", n, err)
+	}
+	if got := strings.TrimSpace(buf.String()); !strings.HasPrefix(got, "id,created_at,expires_at,") || strings.Contains(got, "\n") {
+		t.Fatalf("empty CSV export = %q, want only the header", got)
+	}
+	n, err = results.Export(ctx, s, &buf, results.FormatNDJSON, results.Query{From: time.Now().Add(-time.Hour)})
+	if err != nil || n != 1 {
+		t.Fatalf("Export(from an hour ago) = %d, %v; want 1", n, err)
+	}
+}
+
+func TestImportSkipsExpiredAndRejectsBadRecords(t *testing.T) {
+	ctx := context.Background()
+	s := results.NewMemory(100, results.WithRetention(24*time.Hour, 48*time.Hour))
+	defer s.Close()
+
+	old := time.Now().Add(-72 * time.Hour).UTC().Format(time.RFC3339)
+	input := `{"id":"fresh001","download_mbps":5}` + "\n" +
+		`{"id":"stale001","created_at":"` + old + `"}` + "\n\n"
+	report, err := results.Import(ctx, s, strings.NewReader(input), results.FormatNDJSON)
+	if err != nil || report.Imported != 1 || report.Expired != 1 {
+		t.Fatalf("Import = %+v, %v; want one imported and one expired", report, err)
+	}
+	got, err := s.Get(ctx, "fresh001")
+	if err != nil || got == nil {
+		t.Fatalf("Get = %v, %v", got, err)
+	}
+	if d := got.ExpiresAt.Sub(got.CreatedAt); d != 24*time.Hour {
+		t.Fatalf("imported result lives %s, want the default 24h", d)
+	}
+
+	for name, tc := range map[string]struct {
+		format results.ExportFormat
+		input  string
+		want   error
+	}{
+		"bad id":      {results.FormatNDJSON, `{"id":"../etc"}`, results.ErrInvalidID},
+		"csv no id":   {results.FormatCSV, "download_mbps\n5\n", nil},
+		"csv number":  {results.FormatCSV, "id,download_mbps\nabcd0001,fast\n", nil},
+		"bad json":    {results.FormatNDJSON, `{"id":`, nil},
+		"bad format":  {"xml", "", results.ErrInvalidExportFormat},
+		"csv bad row": {results.FormatCSV, "id,upload_mbps\nabcd0002\n", nil},
+	} {
+		_, err := results.Import(ctx, s, strings.NewReader(tc.input), tc.format)
+		if err == nil || (tc.want != nil && !errors.Is(err, tc.want)) {
+			t.Errorf("%s: Import error = %v, want %v", name, err, tc.want)
+		}
+	}
+}
+
+func TestImportValidatesResultsLikeSave(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, _ storageBackend, s results.Storage, _ string) {
+		ctx := context.Background()
+		for name, tc := range map[string]struct {
+			input string
+			want  error
+		}{
+			"negative":     {"id,latency_ms\nabcd0001,-1\n", results.ErrNegativeMetric},
+			"too fast":     {"id,download_mbps\nabcd0001,100000.5\n", results.ErrMetricOutOfRange},
+			"NaN":          {"id,upload_mbps\nabcd0001,NaN\n", results.ErrMetricOutOfRange},
+			"infinite":     {"id,jitter_ms\nabcd0001,+Inf\n", results.ErrMetricOutOfRange},
+			"long name":    {"id,server_name\nabcd0001," + strings.Repeat("x", 201) + "\n", results.ErrFieldTooLong},
+			"long grade":   {"id,bufferbloat_grade\nabcd0001,AAAAAA\n", results.ErrFieldTooLong},
+			"sealed ipv4":  {"id,ipv4\nabcd0001,enc1:x:AAAA\n", results.ErrInvalidIP},
+			"formula ipv6": {"id,ipv6\nabcd0001,=1+1\n", results.ErrInvalidIP},
+			"later record": {"id,loaded_latency_ms\nabcd0001,5\nabcd0002,60001\n", results.ErrMetricOutOfRange},
+		} {
+			_, err := results.Import(ctx, s, strings.NewReader(tc.input), results.FormatCSV)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("%s: Import error = %v, want %v", name, err, tc.want)
+				continue
+			}
+			line := strings.Count(tc.input, "\n")
+			if !strings.HasPrefix(err.Error(), fmt.Sprintf("line %d: ", line)) {
+				t.Errorf("%s: Import error = %q, want it to name line %d", name, err, line)
+			}
+		}
+	})
+}
+
+func TestCSVEscapesFormulaCells(t *testing.T) {
+	ctx := context.Background()
+	want := results.Result{
+		ID: "abcd0001", CreatedAt: time.Now().UTC().Truncate(time.Second),
+		ServerName: `=HYPERLINK("http://evil.test")`, BufferbloatGrade: "-A",
+		ASOrganization: "'quoted", IPv4: "198.51.100.7", Plausibility: "@unverified", Country: "+DE",
+	}
+	var buf bytes.Buffer
+	if err := results.WriteCSV(&buf, want); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("CSV = %v, %v", records, err)
+	}
+	row := make(map[string]string, len(records[0]))
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+	for column, cell := range map[string]string{
+		"server_name":       `'=HYPERLINK("http://evil.test")`,
+		"bufferbloat_grade": "'-A",
+		"as_org":            "''quoted",
+		"ipv4":              "198.51.100.7",
+		"plausibility":      "'@unverified",
+		"country":           "'+DE",
+	} {
+		if row[column] != cell {
+			t.Errorf("%s cell = %q, want %q", column, row[column], cell)
+		}
+	}
+
+	s := results.NewMemory(100)
+	defer s.Close()
+	if report, err := results.Import(ctx, s, &buf, results.FormatCSV); err != nil || report.Imported != 1 {
+		t.Fatalf("Import = %+v, %v", report, err)
+	}
+	got, err := s.Get(ctx, want.ID)
+	if err != nil || got == nil {
+		t.Fatalf("Get = %v, %v", got, err)
+	}
+	if got.ServerName != want.ServerName || got.BufferbloatGrade != want.BufferbloatGrade || got.ASOrganization != want.ASOrganization ||
+		got.Plausibility != want.Plausibility || got.Country != want.Country {
+		t.Fatalf("imported %+v, want the original text fields of %+v", got, want)
+	}
+
+	// Addresses stored before they were validated are escaped too, and
+	// import refuses them.
+	buf.Reset()
+	formula := `=HYPERLINK("http://e.vil","x")`
+	if err := results.WriteCSV(&buf, results.Result{ID: "abcd0002", IPv4: formula, IPv6: "-1+1"}); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	records, err = csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("CSV = %v, %v", records, err)
+	}
+	for i, name := range records[0] {
+		if (name == "ipv4" || name == "ipv6") && !strings.HasPrefix(records[1][i], "'") {
+			t.Errorf("%s cell = %q, want a leading apostrophe", name, records[1][i])
+		}
+	}
+	if _, err := results.Import(ctx, s, &buf, results.FormatCSV); !errors.Is(err, results.ErrInvalidIP) {
+		t.Fatalf("Import of formula addresses error = %v, want ErrInvalidIP", err)
+	}
+}
+
+func TestImportKeepsTruncatedPrefixes(t *testing.T) {
+	ctx := context.Background()
+	s := results.NewMemory(100, results.WithIPPolicy(results.IPPolicy{Mode: results.IPModeTruncate}))
+	defer s.Close()
+	input := "id,ipv4,ipv6\nabcd0001,203.0.113.0/24,2001:db8:5::/48\nabcd0002,203.0.113.0/16,\n"
+	if report, err := results.Import(ctx, s, strings.NewReader(input), results.FormatCSV); err != nil || report.Imported != 2 {
+		t.Fatalf("Import = %+v, %v", report, err)
+	}
+	for id, want := range map[string]string{"abcd0001": "203.0.113.0/24", "abcd0002": "203.0.0.0/16"} {
+		got, err := s.Get(ctx, id)
+		if err != nil || got == nil || got.IPv4 != want {
+			t.Fatalf("Get %s = %+v, %v; want ipv4 %s", id, got, err, want)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package results_test
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -245,3 +247,34 @@ func TestHandlerSaveRejectsUnknownFields(t *testing.T) {
 		t.Fatalf(cacheControlWantFmt, got, cacheNoStore)
 	}
 }
+
+func TestSaveRejectsAddressesThatLookSealed(t *testing.T) {
+	store, cleanup := tempStore(t, 100)
+	defer cleanup()
+	h := newResultsAPI(store)
+
+	for _, body := range []string{
+		`{"download_mbps": 1, "ipv4": "enc1:x:AAAA"}`,
+		`{"download_mbps": 1, "ipv6": "enc1:x:AAAA"}`,
+		`{"download_mbps": 1, "ipv4": "not an address"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, resultsPath, strings.NewReader(body))
+		req.Header.Set(contentTypeHeader, applicationJSON)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: "+statusCodeWantFmt, body, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	ctx := context.Background()
+	if _, err := store.Save(ctx, results.Result{DownloadMbps: 1, IPv4: "203.0.113.10"}); err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	if page, err := store.List(ctx, results.Query{Limit: 10}); err != nil || len(page.Results) != 1 {
+		t.Fatalf("List = %+v, %v; want the one valid result", page, err)
+	}
+	if n, err := results.Export(ctx, store, io.Discard, results.FormatCSV, results.Query{}); err != nil || n != 1 {
+		t.Fatalf("Export = %d, %v; want 1", n, err)
+	}
+}
@@ -0,0 +1,319 @@
+package results_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+// storageBackend opens one Storage implementation. Persistent backends
+// reopen the same data when open is called again with the same dir.
+type storageBackend struct {
+	name       string
+	persistent bool
+	open       func(t *testing.T, dir string, opts ...results.Option) results.Storage
+}
+
+var storageBackends = []storageBackend{
+	{
+		name:       "sqlite",
+		persistent: true,
+		open: func(t *testing.T, dir string, opts ...results.Option) results.Storage {
+			t.Helper()
+			s, err := results.New(filepath.Join(dir, "results.db"), 100, opts...)
+			if err != nil {
+				t.Fatalf(storeNewErrFmt, err)
+			}
+			return s
+		},
+	},
+	{
+		name: "memory",
+		open: func(t *testing.T, _ string, opts ...results.Option) results.Storage {
+			return results.NewMemory(100, opts...)
+		},
+	},
+	{
+		name:       "ndjson",
+		persistent: true,
+		open: func(t *testing.T, dir string, opts ...results.Option) results.Storage {
+			t.Helper()
+			s, err := results.OpenNDJSON(filepath.Join(dir, "results.ndjson"), 100, opts...)
+			if err != nil {
+				t.Fatalf(storeNewErrFmt, err)
+			}
+			return s
+		},
+	},
+}
+
+// forEachStorage runs test against every backend with a fresh store.
+func forEachStorage(t *testing.T, test func(t *testing.T, b storageBackend, s results.Storage, dir string), opts ...results.Option) {
+	for _, b := range storageBackends {
+		t.Run(b.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := b.open(t, dir, opts...)
+			t.Cleanup(s.Close)
+			test(t, b, s, dir)
+		})
+	}
+}
+
+func TestStorageSaveAndGet(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, _ storageBackend, s results.Storage, _ string) {
+		ctx := context.Background()
+		want := results.Result{
+			DownloadMbps: 123.4, UploadMbps: 56.7, LatencyMs: 12.3, JitterMs: 1.5,
+			LoadedLatencyMs: 25, BufferbloatGrade: "A", IPv4: "1.2.3.4", IPv6: "::1", ServerName: "Test",
+		}
+		id, err := s.Save(ctx, want)
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		got, err := s.Get(ctx, id)
+		if err != nil || got == nil {
+			t.Fatalf("Get = %v, %v", got, err)
+		}
+		if got.ID != id || got.DownloadMbps != want.DownloadMbps || got.IPv4 != want.IPv4 ||
+			got.ServerName != want.ServerName || got.BufferbloatGrade != want.BufferbloatGrade {
+			t.Fatalf("Get = %+v, want %+v", got, want)
+		}
+		if time.Since(got.CreatedAt) > time.Minute || !got.ExpiresAt.After(got.CreatedAt) {
+			t.Fatalf("timestamps = %s / %s", got.CreatedAt, got.ExpiresAt)
+		}
+		if missing, err := s.Get(ctx, "missing1"); err != nil || missing != nil {
+			t.Fatalf("Get(missing) = %v, %v; want nil, nil", missing, err)
+		}
+	})
+}
+
+func TestStorageDeleteWithToken(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, _ storageBackend, s results.Storage, _ string) {
+		ctx := context.Background()
+		saved, err := s.SaveWithDeleteToken(ctx, results.Result{DownloadMbps: 1})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		tokenless, err := s.Save(ctx, results.Result{DownloadMbps: 2})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		if err := s.Delete(ctx, "missing1", saved.DeleteToken); !errors.Is(err, results.ErrNotFound) {
+			t.Fatalf("Delete(missing) = %v, want ErrNotFound", err)
+		}
+		if err := s.Delete(ctx, saved.ID, "obd_wrong"); !errors.Is(err, results.ErrInvalidDeleteToken) {
+			t.Fatalf("Delete(wrong token) = %v, want ErrInvalidDeleteToken", err)
+		}
+		if err := s.Delete(ctx, tokenless, saved.DeleteToken); !errors.Is(err, results.ErrInvalidDeleteToken) {
+			t.Fatalf("Delete(tokenless) = %v, want ErrInvalidDeleteToken", err)
+		}
+		if err := s.Delete(ctx, saved.ID, saved.DeleteToken); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if got, err := s.Get(ctx, saved.ID); err != nil || got != nil {
+			t.Fatalf("Get after delete = %v, %v", got, err)
+		}
+	})
+}
+
+func TestStorageRetention(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, _ storageBackend, s results.Storage, _ string) {
+		ctx := context.Background()
+		if def, maxRetention := s.Retention(); def != 24*time.Hour || maxRetention != 48*time.Hour {
+			t.Fatalf("Retention = %s/%s", def, maxRetention)
+		}
+		def, err := s.SaveWithDeleteToken(ctx, results.Result{})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		clamped, err := s.SaveWithDeleteToken(ctx, results.Result{ExpiresAt: time.Now().Add(365 * 24 * time.Hour)})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		if d := time.Until(def.ExpiresAt); d < 23*time.Hour || d > 24*time.Hour {
+			t.Fatalf("default expiry in %s, want 24h", d)
+		}
+		if d := time.Until(clamped.ExpiresAt); d < 47*time.Hour || d > 48*time.Hour {
+			t.Fatalf("clamped expiry in %s, want 48h", d)
+		}
+	}, results.WithRetention(24*time.Hour, 48*time.Hour))
+}
+
+func TestStorageAppliesIPPolicy(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, _ storageBackend, s results.Storage, _ string) {
+		ctx := context.Background()
+		id, err := s.Save(ctx, results.Result{IPv4: "203.0.113.9", IPv6: "2001:db8:5:6::1"})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		got, err := s.Get(ctx, id)
+		if err != nil || got == nil {
+			t.Fatalf(storeGetFmt, err)
+		}
+		if got.IPv4 != "203.0.113.0/24" || got.IPv6 != "2001:db8:5::/48" {
+			t.Fatalf("stored %q / %q, want truncated prefixes", got.IPv4, got.IPv6)
+		}
+	}, results.WithIPPolicy(results.IPPolicy{Mode: results.IPModeTruncate}))
+}
+
+func TestStorageListFiltersAndPaginates(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, _ storageBackend, s results.Storage, _ string) {
+		ctx := context.Background()
+		ids := make([]string, 5)
+		for i := range ids {
+			id, err := s.Save(ctx, results.Result{
+				DownloadMbps: float64(100 * (i + 1)), BufferbloatGrade: "A", ServerName: "a", IPv4: "192.0.2.1",
+			})
+			if err != nil {
+				t.Fatalf(storeSaveFmt, err)
+			}
+			ids[i] = id
+			time.Sleep(2 * time.Millisecond)
+		}
+		if _, err := s.Save(ctx, results.Result{DownloadMbps: 50, BufferbloatGrade: "F", ServerName: "b"}); err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+
+		q := results.Query{ServerName: "a", Download: results.SpeedRange{Min: 200}, Limit: 2}
+		var got []string
+		for range 3 {
+			page, err := s.List(ctx, q)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			for _, r := range page.Results {
+				got = append(got, r.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+		want := []string{ids[4], ids[3], ids[2], ids[1]}
+		if len(got) != len(want) {
+			t.Fatalf("List IDs = %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("List IDs = %v, want %v", got, want)
+			}
+		}
+
+		for name, q := range map[string]results.Query{
+			"grade":  {Grades: []string{"F"}},
+			"prefix": {IPPrefix: netip.MustParsePrefix("192.0.2.0/24")},
+		} {
+			page, err := s.List(ctx, q)
+			if err != nil {
+				t.Fatalf("List %s: %v", name, err)
+			}
+			wantLen := map[string]int{"grade": 1, "prefix": 5}[name]
+			if len(page.Results) != wantLen {
+				t.Fatalf("List %s returned %d results, want %d", name, len(page.Results), wantLen)
+			}
+		}
+		if _, err := s.List(ctx, results.Query{Cursor: "!"}); !errors.Is(err, results.ErrInvalidCursor) {
+			t.Fatalf("List(bad cursor) = %v, want ErrInvalidCursor", err)
+		}
+	})
+}
+
+func TestStorageStats(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, _ storageBackend, s results.Storage, _ string) {
+		ctx := context.Background()
+		for i := 1; i <= 10; i++ {
+			server := "a"
+			if i > 8 {
+				server = "b"
+			}
+			if _, err := s.Save(ctx, results.Result{
+				DownloadMbps: float64(i * 10), UploadMbps: float64(i), LatencyMs: float64(100 - i), ServerName: server,
+			}); err != nil {
+				t.Fatalf(storeSaveFmt, err)
+			}
+		}
+		buckets, err := s.Stats(ctx, results.StatsQuery{})
+		if err != nil {
+			t.Fatalf("Stats: %v", err)
+		}
+		if len(buckets) != 1 || buckets[0].Count != 10 {
+			t.Fatalf("Stats = %+v, want one bucket of 10", buckets)
+		}
+		want := results.MetricStats{Avg: 55, P10: 10, P50: 50, P90: 90}
+		if buckets[0].DownloadMbps != want {
+			t.Fatalf("download stats = %+v, want %+v", buckets[0].DownloadMbps, want)
+		}
+
+		byServer, err := s.Stats(ctx, results.StatsQuery{Period: results.StatsPeriodDay, ByServer: true})
+		if err != nil {
+			t.Fatalf("Stats by server: %v", err)
+		}
+		if len(byServer) != 2 || byServer[0].ServerName != "a" || byServer[0].Count != 8 || byServer[1].Count != 2 {
+			t.Fatalf("Stats by server = %+v", byServer)
+		}
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		if !byServer[0].Start.Equal(today) {
+			t.Fatalf("day bucket start = %s, want %s", byServer[0].Start, today)
+		}
+		if _, err := s.Stats(ctx, results.StatsQuery{Period: "month"}); !errors.Is(err, results.ErrInvalidStatsPeriod) {
+			t.Fatalf("Stats(month) = %v, want ErrInvalidStatsPeriod", err)
+		}
+	})
+}
+
+func TestStorageStatsSkipsExpiredResults(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, _ storageBackend, s results.Storage, _ string) {
+		ctx := context.Background()
+		for _, r := range []results.Result{
+			{DownloadMbps: 100},
+			{DownloadMbps: 900, ExpiresAt: time.Now().Add(-time.Minute)},
+		} {
+			if _, err := s.Save(ctx, r); err != nil {
+				t.Fatalf(storeSaveFmt, err)
+			}
+		}
+		buckets, err := s.Stats(ctx, results.StatsQuery{})
+		if err != nil {
+			t.Fatalf("Stats: %v", err)
+		}
+		if len(buckets) != 1 || buckets[0].Count != 1 || buckets[0].DownloadMbps.Avg != 100 {
+			t.Fatalf("Stats = %+v, want only the live result", buckets)
+		}
+	})
+}
+
+func TestStoragePersistsAcrossReopen(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, b storageBackend, s results.Storage, dir string) {
+		if !b.persistent {
+			t.Skip("backend keeps results in memory only")
+		}
+		ctx := context.Background()
+		kept, err := s.Save(ctx, results.Result{DownloadMbps: 42, IPv4: "198.51.100.4"})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		deleted, err := s.SaveWithDeleteToken(ctx, results.Result{DownloadMbps: 7})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		if err := s.Delete(ctx, deleted.ID, deleted.DeleteToken); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		s.Close()
+
+		reopened := b.open(t, dir)
+		defer reopened.Close()
+		got, err := reopened.Get(ctx, kept)
+		if err != nil || got == nil || got.DownloadMbps != 42 || got.IPv4 != "198.51.100.4" {
+			t.Fatalf("Get after reopen = %+v, %v", got, err)
+		}
+		if got, err := reopened.Get(ctx, deleted.ID); err != nil || got != nil {
+			t.Fatalf("deleted result after reopen = %+v, %v", got, err)
+		}
+	})
+}
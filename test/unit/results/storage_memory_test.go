@@ -0,0 +1,48 @@
+package results_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func TestMemoryStoreTrimsOldestResults(t *testing.T) {
+	ctx := context.Background()
+	s := results.NewMemory(3)
+	defer s.Close()
+
+	// An imported result is older than every saved one however late it
+	// arrives, so it is trimmed first.
+	var saved []string
+	for range 2 {
+		id, err := s.Save(ctx, results.Result{DownloadMbps: 1})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		saved = append(saved, id)
+	}
+	old := results.Result{ID: "old00001", CreatedAt: time.Now().Add(-time.Hour)}
+	if err := s.Import(ctx, old); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	for range 2 {
+		id, err := s.Save(ctx, results.Result{DownloadMbps: 1})
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		saved = append(saved, id)
+	}
+
+	for _, id := range []string{old.ID, saved[0]} {
+		if got, err := s.Get(ctx, id); err != nil || got != nil {
+			t.Fatalf("Get %s = %+v, %v; want it trimmed", id, got, err)
+		}
+	}
+	for _, id := range saved[1:] {
+		if got, err := s.Get(ctx, id); err != nil || got == nil {
+			t.Fatalf("Get %s = %+v, %v; want it kept", id, got, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package results_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func TestNDJSONJournalEncryptsAndForgetsDeletedResults(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	key := results.FieldKey{ID: "k1", Key: bytes.Repeat([]byte{9}, results.FieldKeySize)}
+	s, err := results.OpenNDJSON(path, 10, fieldEncryption(t, key))
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	defer s.Close()
+
+	kept, err := s.Save(ctx, results.Result{IPv4: "203.0.113.50"})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	gone, err := s.SaveWithDeleteToken(ctx, results.Result{IPv4: "203.0.113.51", ServerName: "to-delete"})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	if err := s.Delete(ctx, gone.ID, gone.DeleteToken); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	if strings.Contains(string(data), "203.0.113") || !strings.Contains(string(data), "enc1:k1:") {
+		t.Fatalf("journal = %s, want sealed addresses", data)
+	}
+	if strings.Contains(string(data), gone.ID) || strings.Contains(string(data), "to-delete") {
+		t.Fatalf("journal still holds deleted result: %s", data)
+	}
+	if got, err := s.Get(ctx, kept); err != nil || got == nil || got.IPv4 != "203.0.113.50" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+}
+
+func TestOpenNDJSONSkipsTornFinalRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	s, err := results.OpenNDJSON(path, 10)
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	id, err := s.Save(context.Background(), results.Result{DownloadMbps: 5})
+	s.Close()
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	f.WriteString(`{"op":"save","result":{"id":"torn`)
+	f.Close()
+
+	reopened, err := results.OpenNDJSON(path, 10)
+	if err != nil {
+		t.Fatalf("reopen with torn tail: %v", err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.Get(context.Background(), id); err != nil || got == nil {
+		t.Fatalf("Get after torn tail = %+v, %v", got, err)
+	}
+
+	corrupt := filepath.Join(t.TempDir(), "corrupt.ndjson")
+	if err := os.WriteFile(corrupt, []byte("not json\n{\"op\":\"delete\",\"id\":\"x\"}\n"), 0o600); err != nil {
+		t.Fatalf("write corrupt journal: %v", err)
+	}
+	if _, err := results.OpenNDJSON(corrupt, 10); err == nil {
+		t.Fatal("expected a damaged record before the tail to fail")
+	}
+}
+
+func TestOpenNDJSONRejectsUnreadableRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	now := time.Now().UTC()
+	times := `"created_at":"` + now.Format(time.RFC3339) + `","expires_at":"` + now.Add(time.Hour).Format(time.RFC3339) + `"`
+	poisoned := `{"op":"save","result":{"id":"bad00001","ipv4":"enc1:x:AAAA",` + times + `}}`
+	journal := `{"op":"save","result":{"id":"good0001","download_mbps":5,` + times + `}}` + "\n" + poisoned + "\n"
+	if err := os.WriteFile(path, []byte(journal), 0o600); err != nil {
+		t.Fatalf("write journal: %v", err)
+	}
+
+	s, err := results.OpenNDJSON(path, 10)
+	if err != nil {
+		t.Fatalf("open with unreadable record: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+	if got, err := s.Get(ctx, "good0001"); err != nil || got == nil {
+		t.Fatalf("Get readable result = %+v, %v", got, err)
+	}
+	if page, err := s.List(ctx, results.Query{Limit: 10}); err != nil || len(page.Results) != 1 {
+		t.Fatalf("List = %+v, %v; want only the readable result", page, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	if strings.Contains(string(data), "bad00001") {
+		t.Fatalf("journal still holds the unreadable record: %s", data)
+	}
+	rejected, err := os.ReadFile(path + ".rejected")
+	if err != nil || string(rejected) != poisoned+"\n" {
+		t.Fatalf("rejected file = %q, %v; want the unreadable record", rejected, err)
+	}
+}
@@ -0,0 +1,167 @@
+package results_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func TestBackupWritesSnapshotsAndKeepsNewest(t *testing.T) {
+	ctx := context.Background()
+	s, cleanup := tempStore(t, 100)
+	defer cleanup()
+	id, err := s.Save(ctx, results.Result{DownloadMbps: 321, IPv4: "192.0.2.7"})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "backups")
+	unrelated := filepath.Join(dir, "notes.txt")
+	var snaps []results.Snapshot
+	for i := range 4 {
+		snap, err := s.Backup(ctx, dir, 2)
+		if err != nil {
+			t.Fatalf("Backup %d: %v", i, err)
+		}
+		if !strings.HasPrefix(snap.Name, "results-") || snap.SizeBytes == 0 || snap.Path != filepath.Join(dir, snap.Name) {
+			t.Fatalf("snapshot = %+v", snap)
+		}
+		if i == 0 {
+			if err := os.WriteFile(unrelated, []byte("keep"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		snaps = append(snaps, snap)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"notes.txt", snaps[2].Name, snaps[3].Name}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("backup dir = %v, want %v", names, want)
+	}
+	info, err := os.Stat(snaps[3].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("snapshot mode = %o, want 600", perm)
+	}
+
+	n, err := results.ValidateSnapshot(ctx, snaps[3].Path)
+	if err != nil || n != 1 {
+		t.Fatalf("ValidateSnapshot = %d, %v; want 1, nil", n, err)
+	}
+	restored, err := results.New(snaps[3].Path, 100)
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	defer restored.Close()
+	if got, err := restored.Get(ctx, id); err != nil || got == nil || got.DownloadMbps != 321 {
+		t.Fatalf("Get from snapshot = %+v, %v", got, err)
+	}
+}
+
+func TestValidateSnapshotRejectsDamagedFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	garbage := filepath.Join(dir, "garbage.db")
+	if err := os.WriteFile(garbage, []byte(strings.Repeat("not a database ", 512)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	other, err := results.New(filepath.Join(dir, "other.db"), 10)
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	other.Close()
+	empty := filepath.Join(dir, "empty.db")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{
+		"missing": filepath.Join(dir, "missing.db"),
+		"garbage": garbage,
+		"empty":   empty,
+		"dir":     dir,
+	} {
+		if _, err := results.ValidateSnapshot(ctx, path); !errors.Is(err, results.ErrInvalidSnapshot) {
+			t.Errorf("ValidateSnapshot(%s) = %v, want ErrInvalidSnapshot", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("validation created the missing snapshot: %v", err)
+	}
+}
+
+func TestRestoreSnapshotReplacesDatabase(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "results.db")
+	s, err := results.New(dbPath, 100)
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	kept, err := s.Save(ctx, results.Result{DownloadMbps: 1})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	snap, err := s.Backup(ctx, filepath.Join(dir, "backups"), 3)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	later, err := s.Save(ctx, results.Result{DownloadMbps: 2})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	if _, err := results.RestoreSnapshot(ctx, dbPath, dbPath); err == nil {
+		t.Fatal("expected restoring the live database onto itself to fail")
+	}
+	s.Close()
+
+	garbage := filepath.Join(dir, "garbage.db")
+	if err := os.WriteFile(garbage, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := results.RestoreSnapshot(ctx, garbage, dbPath); !errors.Is(err, results.ErrInvalidSnapshot) {
+		t.Fatalf("RestoreSnapshot(garbage) = %v, want ErrInvalidSnapshot", err)
+	}
+
+	n, err := results.RestoreSnapshot(ctx, snap.Path, dbPath)
+	if err != nil || n != 1 {
+		t.Fatalf("RestoreSnapshot = %d, %v; want 1, nil", n, err)
+	}
+	reopened, err := results.New(dbPath, 100)
+	if err != nil {
+		t.Fatalf(storeReopenFmt, err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.Get(ctx, kept); err != nil || got == nil {
+		t.Fatalf("snapshot result after restore = %+v, %v", got, err)
+	}
+	if got, err := reopened.Get(ctx, later); err != nil || got != nil {
+		t.Fatalf("post-snapshot result after restore = %+v, %v; want nil", got, err)
+	}
+
+	previous, err := results.New(dbPath+".pre-restore", 100)
+	if err != nil {
+		t.Fatalf("open pre-restore copy: %v", err)
+	}
+	defer previous.Close()
+	if got, err := previous.Get(ctx, later); err != nil || got == nil {
+		t.Fatalf("pre-restore copy lost result = %+v, %v", got, err)
+	}
+}
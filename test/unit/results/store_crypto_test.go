@@ -0,0 +1,145 @@
+package results_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func fieldEncryption(t *testing.T, keys ...results.FieldKey) results.Option {
+	t.Helper()
+	fields, err := results.NewFieldEncryption(keys)
+	if err != nil {
+		t.Fatalf("NewFieldEncryption: %v", err)
+	}
+	return results.WithFieldEncryption(fields)
+}
+
+func storedIPs(t *testing.T, dbPath, id string) (ipv4, ipv6 string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf(storeOpenSQLiteFmt, err)
+	}
+	defer db.Close()
+	if err := db.QueryRow(`SELECT ipv4, ipv6 FROM results WHERE id = ?`, id).Scan(&ipv4, &ipv6); err != nil {
+		t.Fatalf("read stored fields: %v", err)
+	}
+	return ipv4, ipv6
+}
+
+func TestFieldEncryptionIsTransparentAndRotates(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "results.db")
+	oldKey := results.FieldKey{ID: "old", Key: bytes.Repeat([]byte{1}, results.FieldKeySize)}
+	newKey := results.FieldKey{ID: "new", Key: bytes.Repeat([]byte{2}, results.FieldKeySize)}
+
+	// A plaintext row from before encryption was enabled.
+	plain, err := results.New(dbPath, 10)
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	legacyID, err := plain.Save(ctx, results.Result{IPv4: "192.0.2.1"})
+	plain.Close()
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+
+	store, err := results.New(dbPath, 10, fieldEncryption(t, oldKey))
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	id, err := store.Save(ctx, results.Result{IPv4: "203.0.113.5", IPv6: "2001:db8::5"})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	got, err := store.Get(ctx, id)
+	if err != nil || got == nil {
+		t.Fatalf(storeGetFmt, err)
+	}
+	if got.IPv4 != "203.0.113.5" || got.IPv6 != "2001:db8::5" {
+		t.Fatalf("Get = %q / %q, want decrypted addresses", got.IPv4, got.IPv6)
+	}
+	if legacy, err := store.Get(ctx, legacyID); err != nil || legacy.IPv4 != "192.0.2.1" {
+		t.Fatalf("plaintext row = %+v, %v", legacy, err)
+	}
+	store.Close()
+
+	ipv4, ipv6 := storedIPs(t, dbPath, id)
+	if !strings.HasPrefix(ipv4, "enc1:old:") || !strings.HasPrefix(ipv6, "enc1:old:") || strings.Contains(ipv4, "203.0.113") {
+		t.Fatalf("stored fields = %q / %q, want key-tagged ciphertext", ipv4, ipv6)
+	}
+
+	rotated, err := results.New(dbPath, 10, fieldEncryption(t, newKey, oldKey))
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	n, err := rotated.Reencrypt(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Reencrypt = %d, %v; want 2 rows", n, err)
+	}
+	if n, err := rotated.Reencrypt(ctx); err != nil || n != 0 {
+		t.Fatalf("second Reencrypt = %d, %v; want no rows", n, err)
+	}
+	rotated.Close()
+	if ipv4, _ := storedIPs(t, dbPath, legacyID); !strings.HasPrefix(ipv4, "enc1:new:") {
+		t.Fatalf("legacy row stored %q, want encrypted with new key", ipv4)
+	}
+
+	newOnly, err := results.New(dbPath, 10, fieldEncryption(t, newKey))
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	defer newOnly.Close()
+	if got, err := newOnly.Get(ctx, id); err != nil || got.IPv4 != "203.0.113.5" {
+		t.Fatalf("Get after dropping old key = %+v, %v", got, err)
+	}
+}
+
+func TestFieldEncryptionRejectsUnknownKey(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "results.db")
+	store, err := results.New(dbPath, 10, fieldEncryption(t, results.FieldKey{ID: "a", Key: bytes.Repeat([]byte{1}, results.FieldKeySize)}))
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	id, err := store.Save(ctx, results.Result{IPv4: "203.0.113.5"})
+	store.Close()
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+
+	other, err := results.New(dbPath, 10, fieldEncryption(t, results.FieldKey{ID: "b", Key: bytes.Repeat([]byte{2}, results.FieldKeySize)}))
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	defer other.Close()
+	if _, err := other.Get(ctx, id); !errors.Is(err, results.ErrUnknownFieldKey) {
+		t.Fatalf("Get with unknown key error = %v, want ErrUnknownFieldKey", err)
+	}
+}
+
+func TestNewFieldEncryptionValidatesKeys(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, results.FieldKeySize)
+	for name, keys := range map[string][]results.FieldKey{
+		"none":      nil,
+		"short":     {{ID: "a", Key: key[:16]}},
+		"colon":     {{ID: "a:b", Key: key}},
+		"duplicate": {{ID: "a", Key: key}, {ID: "a", Key: key}},
+	} {
+		if _, err := results.NewFieldEncryption(keys); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	store, cleanup := tempStore(t, 10)
+	defer cleanup()
+	if _, err := store.Reencrypt(context.Background()); !errors.Is(err, results.ErrNoFieldEncryption) {
+		t.Fatalf("Reencrypt without keys error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package results_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func TestSaveAppliesIPPolicy(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	for _, tt := range []struct {
+		mode     results.IPMode
+		wantIPv4 string
+		wantIPv6 string
+	}{
+		{mode: results.IPModeFull, wantIPv4: "203.0.113.77", wantIPv6: "2001:DB8:1:2::7"},
+		{mode: results.IPModeTruncate, wantIPv4: "203.0.113.0/24", wantIPv6: "2001:db8:1::/48"},
+		{mode: results.IPModeDrop},
+	} {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			got := saveWithIPPolicy(t, results.IPPolicy{Mode: tt.mode, HashKey: key})
+			if got.IPv4 != tt.wantIPv4 || got.IPv6 != tt.wantIPv6 {
+				t.Fatalf("stored %q / %q, want %q / %q", got.IPv4, got.IPv6, tt.wantIPv4, tt.wantIPv6)
+			}
+		})
+	}
+
+	t.Run("hash", func(t *testing.T) {
+		got := saveWithIPPolicy(t, results.IPPolicy{Mode: results.IPModeHash, HashKey: key})
+		again := saveWithIPPolicy(t, results.IPPolicy{Mode: results.IPModeHash, HashKey: key})
+		other := saveWithIPPolicy(t, results.IPPolicy{Mode: results.IPModeHash, HashKey: []byte(strings.Repeat("x", 32))})
+		if len(got.IPv4) != 32 || strings.Contains(got.IPv4, "203.0.113") {
+			t.Fatalf("hashed ipv4 = %q, want 32 hex characters", got.IPv4)
+		}
+		if got.IPv4 != again.IPv4 || got.IPv6 != again.IPv6 {
+			t.Fatal("hash is not stable for the same key")
+		}
+		if got.IPv4 == other.IPv4 {
+			t.Fatal("hash does not depend on the key")
+		}
+	})
+}
+
+func TestListIPPrefixMatchesTruncatedAddresses(t *testing.T) {
+	store, err := results.New(":memory:", 10, results.WithIPPolicy(results.IPPolicy{Mode: results.IPModeTruncate}))
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	defer store.Close()
+	if _, err := store.Save(context.Background(), results.Result{IPv4: "198.51.100.9"}); err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	for prefix, want := range map[string]int{"198.51.100.9/32": 1, "198.51.0.0/16": 1, "198.51.101.0/24": 0} {
+		page, err := store.List(context.Background(), results.Query{IPPrefix: netip.MustParsePrefix(prefix)})
+		if err != nil {
+			t.Fatalf("List(%s): %v", prefix, err)
+		}
+		if len(page.Results) != want {
+			t.Fatalf("List(%s) returned %d results, want %d", prefix, len(page.Results), want)
+		}
+	}
+}
+
+func TestIPPolicyValidate(t *testing.T) {
+	for _, policy := range []results.IPPolicy{
+		{Mode: "mask"},
+		{Mode: results.IPModeHash},
+		{Mode: results.IPModeFull, AnonymizeAfter: -1},
+	} {
+		if err := policy.Validate(); !errors.Is(err, results.ErrInvalidIPPolicy) {
+			t.Fatalf("Validate(%+v) = %v, want ErrInvalidIPPolicy", policy, err)
+		}
+	}
+	if err := (results.IPPolicy{}).Validate(); err != nil {
+		t.Fatalf("zero policy: %v", err)
+	}
+}
+
+func saveWithIPPolicy(t *testing.T, policy results.IPPolicy) *results.Result {
+	t.Helper()
+	store, err := results.New(":memory:", 10, results.WithIPPolicy(policy))
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	defer store.Close()
+	id, err := store.Save(context.Background(), results.Result{IPv4: "203.0.113.77", IPv6: "2001:DB8:1:2::7"})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	got, err := store.Get(context.Background(), id)
+	if err != nil || got == nil {
+		t.Fatalf(storeGetFmt, err)
+	}
+	return got
+}
@@ -0,0 +1,295 @@
+package results_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func memoryStore(t *testing.T) *results.Store {
+	t.Helper()
+	store, err := results.New(":memory:", 1000)
+	if err != nil {
+		t.Fatalf(storeNewFmt, err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func seedResults(t *testing.T, store *results.Store, seeds ...results.Result) []string {
+	t.Helper()
+	ids := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		id, err := store.Save(context.Background(), seed)
+		if err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func listIDs(t *testing.T, store *results.Store, q results.Query) []string {
+	t.Helper()
+	page, err := store.List(context.Background(), q)
+	if err != nil {
+		t.Fatalf("List(%+v): %v", q, err)
+	}
+	ids := make([]string, 0, len(page.Results))
+	for _, r := range page.Results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, name string, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: ids = %v, want %v", name, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s: ids = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestStoreListPaginatesNewestFirst(t *testing.T) {
+	store := memoryStore(t)
+	var ids []string
+	for range 5 {
+		ids = append(ids, seedResults(t, store, results.Result{DownloadMbps: 10})...)
+	}
+
+	var seen []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := store.List(context.Background(), results.Query{Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		for _, r := range page.Results {
+			seen = append(seen, r.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assertIDs(t, "all pages", seen, ids[4], ids[3], ids[2], ids[1], ids[0])
+}
+
+func TestStoreListFilters(t *testing.T) {
+	store := memoryStore(t)
+	early := seedResults(t, store, results.Result{
+		DownloadMbps: 50, UploadMbps: 10, BufferbloatGrade: "C", IPv4: "198.51.100.20", ServerName: "Berlin",
+	})[0]
+	time.Sleep(5 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	late := seedResults(t, store,
+		results.Result{DownloadMbps: 500, UploadMbps: 100, BufferbloatGrade: "A", IPv4: "192.0.2.10", ServerName: "Frankfurt"},
+		results.Result{DownloadMbps: 900, UploadMbps: 40, BufferbloatGrade: "B", IPv6: "2001:db8::7", ServerName: "Frankfurt"},
+	)
+
+	tests := []struct {
+		name  string
+		query results.Query
+		want  []string
+	}{
+		{"from", results.Query{From: cutoff}, []string{late[1], late[0]}},
+		{"to", results.Query{To: cutoff}, []string{early}},
+		{"server", results.Query{ServerName: "Berlin"}, []string{early}},
+		{"download range", results.Query{Download: results.SpeedRange{Min: 100, Max: 600}}, []string{late[0]}},
+		{"upload floor", results.Query{Upload: results.SpeedRange{Min: 40}}, []string{late[1], late[0]}},
+		{"grades", results.Query{Grades: []string{"A", "C"}}, []string{late[0], early}},
+		{"ipv4 prefix", results.Query{IPPrefix: netip.MustParsePrefix("192.0.2.0/24")}, []string{late[0]}},
+		{"ipv6 prefix", results.Query{IPPrefix: netip.MustParsePrefix("2001:db8::/32")}, []string{late[1]}},
+		{"combined", results.Query{ServerName: "Frankfurt", Grades: []string{"B"}, From: cutoff}, []string{late[1]}},
+	}
+	for _, tt := range tests {
+		assertIDs(t, tt.name, listIDs(t, store, tt.query), tt.want...)
+	}
+}
+
+func TestStoreListIPPrefixPaginates(t *testing.T) {
+	store := memoryStore(t)
+	var matching []string
+	for i := range 6 {
+		ip := "203.0.113.1"
+		if i%2 == 0 {
+			ip = "192.0.2.1"
+		}
+		id := seedResults(t, store, results.Result{IPv4: ip})[0]
+		if ip == "192.0.2.1" {
+			matching = append(matching, id)
+		}
+	}
+
+	prefix := netip.MustParsePrefix("192.0.2.0/24")
+	first, err := store.List(context.Background(), results.Query{IPPrefix: prefix, Limit: 2})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(first.Results) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page = %d results, cursor %q; want 2 and a cursor", len(first.Results), first.NextCursor)
+	}
+	second := listIDs(t, store, results.Query{IPPrefix: prefix, Limit: 2, Cursor: first.NextCursor})
+	assertIDs(t, "second page", second, matching[0])
+}
+
+func TestStoreListIPPrefixStopsAfterScanLimit(t *testing.T) {
+	store := memoryStore(t)
+	match := seedResults(t, store, results.Result{IPv4: "192.0.2.1"})[0]
+	for range results.MaxPrefixScan {
+		seedResults(t, store, results.Result{IPv4: "203.0.113.1"})
+	}
+
+	prefix := netip.MustParsePrefix("192.0.2.0/24")
+	first, err := store.List(context.Background(), results.Query{IPPrefix: prefix})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(first.Results) != 0 || first.NextCursor == "" {
+		t.Fatalf("first page = %d results, cursor %q; want none and a cursor", len(first.Results), first.NextCursor)
+	}
+	second, err := store.List(context.Background(), results.Query{IPPrefix: prefix, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(second.Results) != 1 || second.Results[0].ID != match || second.NextCursor != "" {
+		t.Fatalf("second page = %+v, want only %s and no cursor", second, match)
+	}
+}
+
+func TestStoreListRejectsInvalidCursor(t *testing.T) {
+	store := memoryStore(t)
+	for _, cursor := range []string{"not base64!", "Zm9v"} {
+		if _, err := store.List(context.Background(), results.Query{Cursor: cursor}); !errors.Is(err, results.ErrInvalidCursor) {
+			t.Fatalf("List(cursor=%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestStoreStatsPercentiles(t *testing.T) {
+	store := memoryStore(t)
+	for i := 1; i <= 10; i++ {
+		seedResults(t, store, results.Result{
+			DownloadMbps: float64(i * 10),
+			UploadMbps:   float64(i),
+			LatencyMs:    float64(100 - i),
+			ServerName:   "fra",
+		})
+	}
+
+	buckets, err := store.Stats(context.Background(), results.StatsQuery{})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(buckets) != 1 {
+		t.Fatalf("buckets = %+v, want one overall bucket", buckets)
+	}
+	b := buckets[0]
+	if b.Count != 10 || !b.Start.IsZero() || b.ServerName != "" {
+		t.Fatalf("bucket = %+v, want 10 results without start or server", b)
+	}
+	want := results.MetricStats{Avg: 55, P10: 10, P50: 50, P90: 90}
+	if b.DownloadMbps != want {
+		t.Fatalf("download = %+v, want %+v", b.DownloadMbps, want)
+	}
+	if b.UploadMbps.P50 != 5 || b.LatencyMs.P90 != 98 || b.LatencyMs.P10 != 90 {
+		t.Fatalf("upload = %+v latency = %+v", b.UploadMbps, b.LatencyMs)
+	}
+}
+
+func TestStoreStatsGroupsByServer(t *testing.T) {
+	store := memoryStore(t)
+	seedResults(t, store,
+		results.Result{DownloadMbps: 100, ServerName: "ams"},
+		results.Result{DownloadMbps: 300, ServerName: "ams"},
+		results.Result{DownloadMbps: 50, ServerName: "fra"},
+	)
+
+	buckets, err := store.Stats(context.Background(), results.StatsQuery{Period: results.StatsPeriodDay, ByServer: true})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(buckets) != 2 || buckets[0].ServerName != "ams" || buckets[1].ServerName != "fra" {
+		t.Fatalf("buckets = %+v, want ams then fra", buckets)
+	}
+	if buckets[0].Count != 2 || buckets[0].DownloadMbps.Avg != 200 || buckets[0].Start.IsZero() {
+		t.Fatalf("ams bucket = %+v", buckets[0])
+	}
+
+	filtered, err := store.Stats(context.Background(), results.StatsQuery{ServerName: "fra"})
+	if err != nil {
+		t.Fatalf("Stats(server): %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Count != 1 || filtered[0].DownloadMbps.P50 != 50 {
+		t.Fatalf("filtered = %+v", filtered)
+	}
+
+	if _, err := store.Stats(context.Background(), results.StatsQuery{Period: "month"}); !errors.Is(err, results.ErrInvalidStatsPeriod) {
+		t.Fatalf("month period err = %v, want ErrInvalidStatsPeriod", err)
+	}
+}
+
+func TestStoreStatsSkipsExpiredResults(t *testing.T) {
+	store := memoryStore(t)
+	seedResults(t, store,
+		results.Result{DownloadMbps: 100},
+		results.Result{DownloadMbps: 900, ExpiresAt: time.Now().Add(-time.Minute)},
+	)
+
+	buckets, err := store.Stats(context.Background(), results.StatsQuery{})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].Count != 1 || buckets[0].DownloadMbps.Avg != 100 {
+		t.Fatalf("buckets = %+v, want only the live result", buckets)
+	}
+}
+
+func TestStoreStatsEmpty(t *testing.T) {
+	buckets, err := memoryStore(t).Stats(context.Background(), results.StatsQuery{Period: results.StatsPeriodHour})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if buckets == nil || len(buckets) != 0 {
+		t.Fatalf("buckets = %#v, want empty slice", buckets)
+	}
+}
+
+func TestStoreDeleteRequiresMatchingToken(t *testing.T) {
+	store := memoryStore(t)
+	ctx := context.Background()
+	saved, err := store.SaveWithDeleteToken(ctx, results.Result{DownloadMbps: 10})
+	if err != nil {
+		t.Fatalf("SaveWithDeleteToken: %v", err)
+	}
+	id, token := saved.ID, saved.DeleteToken
+	legacyID := seedResults(t, store, results.Result{DownloadMbps: 20})[0]
+
+	if err := store.Delete(ctx, id, token+"x"); !errors.Is(err, results.ErrInvalidDeleteToken) {
+		t.Fatalf("wrong token err = %v, want ErrInvalidDeleteToken", err)
+	}
+	if err := store.Delete(ctx, legacyID, ""); !errors.Is(err, results.ErrInvalidDeleteToken) {
+		t.Fatalf("tokenless result err = %v, want ErrInvalidDeleteToken", err)
+	}
+	if err := store.Delete(ctx, "zzzzzzzz", token); !errors.Is(err, results.ErrNotFound) {
+		t.Fatalf("missing result err = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, id, token); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, err := store.Get(ctx, id); err != nil || got != nil {
+		t.Fatalf("Get after delete = %v, %v; want nil", got, err)
+	}
+}
@@ -0,0 +1,205 @@
+package results_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saveenergy/openbyte/internal/results"
+)
+
+func replicatedStore(t *testing.T) (s *results.Store, dbPath, replicaDir string) {
+	t.Helper()
+	dir := t.TempDir()
+	dbPath = filepath.Join(dir, "results.db")
+	replicaDir = filepath.Join(dir, "replica")
+	s, err := results.New(dbPath, 100)
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	t.Cleanup(s.Close)
+	if err := s.Replicate(context.Background(), replicaDir, time.Hour); err != nil {
+		t.Fatalf("Replicate: %v", err)
+	}
+	return s, dbPath, replicaDir
+}
+
+func TestReplicaFollowsSavesAndDeletes(t *testing.T) {
+	ctx := context.Background()
+	s, dbPath, replicaDir := replicatedStore(t)
+	kept, err := s.Save(ctx, results.Result{DownloadMbps: 11, IPv4: "192.0.2.1", ServerName: "a"})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	owned, err := s.SaveWithDeleteToken(ctx, results.Result{DownloadMbps: 22})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	deleted, err := s.SaveWithDeleteToken(ctx, results.Result{DownloadMbps: 33})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	if err := s.Delete(ctx, deleted.ID, deleted.DeleteToken); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	report, err := results.CheckReplica(ctx, replicaDir, dbPath)
+	if err != nil {
+		t.Fatalf("CheckReplica: %v", err)
+	}
+	if !report.Consistent() || report.Results != 2 || report.Pending != 0 {
+		t.Fatalf("report = %+v, want 2 consistent results", report)
+	}
+
+	// Simulate losing the host: only the replica survives.
+	restoredPath := filepath.Join(t.TempDir(), "results.db")
+	n, err := results.RestoreReplica(ctx, replicaDir, restoredPath)
+	if err != nil || n != 2 {
+		t.Fatalf("RestoreReplica = %d, %v; want 2, nil", n, err)
+	}
+	restored, err := results.New(restoredPath, 100)
+	if err != nil {
+		t.Fatalf(storeReopenFmt, err)
+	}
+	defer restored.Close()
+	if got, err := restored.Get(ctx, kept); err != nil || got == nil || got.IPv4 != "192.0.2.1" || got.ServerName != "a" {
+		t.Fatalf("restored result = %+v, %v", got, err)
+	}
+	if got, err := restored.Get(ctx, deleted.ID); err != nil || got != nil {
+		t.Fatalf("deleted result restored = %+v, %v", got, err)
+	}
+	if err := restored.Delete(ctx, owned.ID, owned.DeleteToken); err != nil {
+		t.Fatalf("delete token lost in replica: %v", err)
+	}
+}
+
+func TestReplicaSkipsTornFinalRecord(t *testing.T) {
+	ctx := context.Background()
+	s, _, replicaDir := replicatedStore(t)
+	id, err := s.Save(ctx, results.Result{DownloadMbps: 5})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	s.Close()
+	log, err := os.OpenFile(filepath.Join(replicaDir, "changes.ndjson"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := log.WriteString(`{"op":"save","result":{"id":"torn`); err != nil {
+		t.Fatal(err)
+	}
+	log.Close()
+
+	restoredPath := filepath.Join(t.TempDir(), "results.db")
+	if n, err := results.RestoreReplica(ctx, replicaDir, restoredPath); err != nil || n != 1 {
+		t.Fatalf("RestoreReplica = %d, %v; want 1, nil", n, err)
+	}
+	restored, err := results.New(restoredPath, 100)
+	if err != nil {
+		t.Fatalf(storeReopenFmt, err)
+	}
+	defer restored.Close()
+	if got, err := restored.Get(ctx, id); err != nil || got == nil {
+		t.Fatalf("restored result = %+v, %v", got, err)
+	}
+}
+
+func TestReplicaKeepsChangesSavedDuringSnapshots(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "results.db")
+	replicaDir := filepath.Join(dir, "replica")
+	s, err := results.New(dbPath, 1000)
+	if err != nil {
+		t.Fatalf(storeNewErrFmt, err)
+	}
+	// A tiny interval re-snapshots on every tick while results are saved.
+	if err := s.Replicate(ctx, replicaDir, time.Millisecond); err != nil {
+		t.Fatalf("Replicate: %v", err)
+	}
+	for i := range 50 {
+		if _, err := s.Save(ctx, results.Result{DownloadMbps: float64(i + 1)}); err != nil {
+			t.Fatalf(storeSaveFmt, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	s.Close()
+
+	report, err := results.CheckReplica(ctx, replicaDir, dbPath)
+	if err != nil {
+		t.Fatalf("CheckReplica: %v", err)
+	}
+	if !report.Consistent() || report.Results != 50 {
+		t.Fatalf("report = %+v, want 50 consistent results", report)
+	}
+}
+
+func TestRestoreReplicaReplaysInterruptedSnapshotLog(t *testing.T) {
+	ctx := context.Background()
+	s, _, replicaDir := replicatedStore(t)
+	id, err := s.Save(ctx, results.Result{DownloadMbps: 5})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	s.Close()
+	// A crash mid-snapshot leaves the old base with recent changes in the
+	// next log.
+	if err := os.Rename(filepath.Join(replicaDir, "changes.ndjson"), filepath.Join(replicaDir, "changes.next.ndjson")); err != nil {
+		t.Fatal(err)
+	}
+
+	restoredPath := filepath.Join(t.TempDir(), "results.db")
+	if n, err := results.RestoreReplica(ctx, replicaDir, restoredPath); err != nil || n != 1 {
+		t.Fatalf("RestoreReplica = %d, %v; want 1, nil", n, err)
+	}
+	restored, err := results.New(restoredPath, 100)
+	if err != nil {
+		t.Fatalf(storeReopenFmt, err)
+	}
+	defer restored.Close()
+	if got, err := restored.Get(ctx, id); err != nil || got == nil {
+		t.Fatalf("restored result = %+v, %v", got, err)
+	}
+}
+
+func TestCheckReplicaReportsDivergence(t *testing.T) {
+	ctx := context.Background()
+	s, dbPath, replicaDir := replicatedStore(t)
+	changed, err := s.Save(ctx, results.Result{DownloadMbps: 1})
+	if err != nil {
+		t.Fatalf(storeSaveFmt, err)
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf(storeOpenSQLiteFmt, err)
+	}
+	defer db.Close()
+	// Writes behind the store's back never reach the replica.
+	if _, err := db.Exec(`UPDATE results SET download_mbps = 2 WHERE id = ?`, changed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO results (id, download_mbps, upload_mbps, latency_ms, jitter_ms, created_at)
+		VALUES ('unlogged', 1, 1, 1, 1, ?)`, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := results.CheckReplica(ctx, replicaDir, dbPath)
+	if err != nil {
+		t.Fatalf("CheckReplica: %v", err)
+	}
+	if report.Consistent() || len(report.Missing) != 1 || report.Missing[0] != "unlogged" ||
+		len(report.Mismatched) != 1 || report.Mismatched[0] != changed {
+		t.Fatalf("report = %+v, want one missing and one mismatched result", report)
+	}
+}
+
+func TestRestoreReplicaNeedsReplica(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := results.RestoreReplica(context.Background(), dir, filepath.Join(dir, "results.db")); !errors.Is(err, results.ErrNoReplica) {
+		t.Fatalf("RestoreReplica(empty dir) = %v, want ErrNoReplica", err)
+	}
+}
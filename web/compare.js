@@ -0,0 +1,251 @@
+/** Compare page: load two to five shared results and show them side by side. */
+
+import { consumeErrorBody, fetchWithTimeout } from "./utils.js";
+import { formatDateTime, formatNumber, t } from "./i18n.js";
+import { formatLatency, formatSpeedText } from "./presentation.js";
+
+const COMPARE_TIMEOUT_MS = 20000;
+const RESULT_ID_REGEX = /^[0-9a-zA-Z]{8}$/;
+const MIN_RESULTS = 2;
+const MAX_RESULTS = 5;
+
+// higherIsBetter decides whether a positive delta is an improvement.
+const METRICS = [
+  {
+    key: "download_mbps",
+    label: "result.download",
+    higherIsBetter: true,
+    format: formatSpeedText,
+    unit: "Mbps",
+  },
+  {
+    key: "upload_mbps",
+    label: "result.upload",
+    higherIsBetter: true,
+    format: formatSpeedText,
+    unit: "Mbps",
+  },
+  {
+    key: "latency_ms",
+    label: "metric.idleLatency",
+    higherIsBetter: false,
+    format: formatLatency,
+    unit: "ms",
+  },
+  {
+    key: "jitter_ms",
+    label: "metric.jitter",
+    higherIsBetter: false,
+    format: formatLatency,
+    unit: "ms",
+  },
+  {
+    key: "loaded_latency_ms",
+    label: "metric.loadedLatency",
+    higherIsBetter: false,
+    format: formatLatency,
+    unit: "ms",
+  },
+];
+
+const form = document.getElementById("compareForm");
+const input = document.getElementById("compareInput");
+const statusEl = document.getElementById("compareStatus");
+const loadingEl = document.getElementById("compareLoading");
+const view = document.getElementById("compareView");
+const table = document.getElementById("compareTable");
+
+/** Accepts result IDs or result links, separated by whitespace or commas. */
+function parseResultIDs(text) {
+  const ids = [];
+  for (const token of text.split(/[\s,]+/)) {
+    if (!token) continue;
+    let candidate = token;
+    try {
+      const url = new URL(token, globalThis.location.origin);
+      const parts = url.pathname.split("/").filter(Boolean);
+      if (parts.length === 2 && parts[0] === "results") candidate = parts[1];
+    } catch {
+      // Not a URL; treat it as a bare ID.
+    }
+    ids.push(candidate);
+  }
+  return ids;
+}
+
+function showStatus(key, variables) {
+  if (!statusEl) return;
+  statusEl.textContent = key ? t(key, variables) : "";
+  statusEl.classList.toggle("hidden", !key);
+}
+
+function validationError(ids) {
+  if (ids.length < MIN_RESULTS || ids.length > MAX_RESULTS) {
+    return "compare.count";
+  }
+  if (!ids.every((id) => RESULT_ID_REGEX.test(id))) return "compare.invalidId";
+  if (new Set(ids).size !== ids.length) return "compare.duplicate";
+  return "";
+}
+
+async function loadComparison(ids) {
+  const url =
+    "/api/v1/results/compare?ids=" + encodeURIComponent(ids.join(","));
+  const res = await fetchWithTimeout(url, {}, COMPARE_TIMEOUT_MS);
+  if (res.status === 404) {
+    await consumeErrorBody(res);
+    throw Object.assign(new Error("not found"), {
+      userKey: "compare.notFound",
+    });
+  }
+  if (!res.ok) {
+    await consumeErrorBody(res);
+    throw Object.assign(new Error("HTTP " + res.status), {
+      userKey: "error.resultUnavailable",
+    });
+  }
+  return res.json();
+}
+
+function cell(tag, text, className) {
+  const el = document.createElement(tag);
+  if (text !== undefined) el.textContent = text;
+  if (className) el.className = className;
+  return el;
+}
+
+function signed(value, options) {
+  const formatted = formatNumber(Math.abs(value), options);
+  if (value > 0) return "+" + formatted;
+  if (value < 0) return "−" + formatted;
+  return formatted;
+}
+
+function deltaText(delta, unit) {
+  const amount = signed(delta.delta, { maximumFractionDigits: 1 }) + " " + unit;
+  if (delta.percent === null || delta.percent === undefined) return amount;
+  const percent = signed(delta.percent, { maximumFractionDigits: 1 });
+  return amount + " (" + percent + " %)";
+}
+
+function deltaClass(delta, higherIsBetter) {
+  if (!delta.delta) return "compare-delta";
+  const improved = delta.delta > 0 === higherIsBetter;
+  return "compare-delta " + (improved ? "delta-better" : "delta-worse");
+}
+
+function headerRow(data) {
+  const row = document.createElement("tr");
+  row.append(cell("th", ""));
+  data.results.forEach((result, index) => {
+    const th = cell("th");
+    th.scope = "col";
+    const link = cell("a", "#" + (index + 1));
+    link.href = "/results/" + result.id;
+    th.append(link);
+    if (index === 0) {
+      th.append(cell("span", t("compare.baseline"), "compare-baseline"));
+    }
+    const created = new Date(result.created_at);
+    if (Number.isFinite(created.getTime())) {
+      const date = formatDateTime(created, {
+        dateStyle: "medium",
+        timeStyle: "short",
+      });
+      th.append(cell("span", date, "compare-date"));
+    }
+    row.append(th);
+  });
+  return row;
+}
+
+function metricRow(data, metric) {
+  const row = document.createElement("tr");
+  const label = cell("th", t(metric.label));
+  label.scope = "row";
+  row.append(label);
+  data.results.forEach((result, index) => {
+    const td = cell("td");
+    td.append(cell("span", metric.format(result[metric.key]), "compare-value"));
+    const delta = index > 0 ? data.deltas[index - 1]?.[metric.key] : null;
+    if (delta) {
+      td.append(
+        cell(
+          "span",
+          deltaText(delta, metric.unit),
+          deltaClass(delta, metric.higherIsBetter),
+        ),
+      );
+    }
+    row.append(td);
+  });
+  return row;
+}
+
+function textRow(data, labelKey, value) {
+  const row = document.createElement("tr");
+  const label = cell("th", t(labelKey));
+  label.scope = "row";
+  row.append(label);
+  for (const result of data.results) {
+    row.append(cell("td", value(result) || "-"));
+  }
+  return row;
+}
+
+function renderComparison(data) {
+  if (!Array.isArray(data?.results) || !Array.isArray(data?.deltas)) {
+    showStatus("error.resultInvalidPayload");
+    return;
+  }
+  const head = document.createElement("thead");
+  head.append(headerRow(data));
+  const body = document.createElement("tbody");
+  for (const metric of METRICS) body.append(metricRow(data, metric));
+  body.append(
+    textRow(data, "metric.bufferbloat", (r) => r.bufferbloat_grade),
+  );
+  body.append(textRow(data, "result.server", (r) => r.server_name));
+  table.replaceChildren(head, body);
+  view?.classList.remove("hidden");
+}
+
+async function compare(ids) {
+  const error = validationError(ids);
+  if (error) {
+    showStatus(error, { min: MIN_RESULTS, max: MAX_RESULTS });
+    view?.classList.add("hidden");
+    return;
+  }
+  const query = "?ids=" + ids.join(",");
+  if (globalThis.location.search !== query) {
+    globalThis.history.replaceState(null, "", "/compare" + query);
+  }
+  showStatus("");
+  loadingEl?.classList.remove("hidden");
+  try {
+    renderComparison(await loadComparison(ids));
+  } catch (err) {
+    console.error("Compare fetch failed:", err);
+    view?.classList.add("hidden");
+    if (err?.name === "AbortError") showStatus("error.resultTimeout");
+    else showStatus(err?.userKey || "error.resultUnavailable");
+  } finally {
+    loadingEl?.classList.add("hidden");
+  }
+}
+
+if (!form || !input || !table) {
+  console.error("Compare page missing required elements");
+} else {
+  form.addEventListener("submit", (event) => {
+    event.preventDefault();
+    compare(parseResultIDs(input.value));
+  });
+  const initial = new URLSearchParams(globalThis.location.search).get("ids");
+  if (initial) {
+    const ids = parseResultIDs(initial);
+    input.value = ids.join("\n");
+    if (ids.length >= MIN_RESULTS) compare(ids);
+  }
+}
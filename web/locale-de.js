@@ -2,6 +2,7 @@
 
 export const de = Object.freeze({
   "meta.shared.title": "openByte — Geteiltes Testergebnis",
+  "meta.compare.title": "openByte — Ergebnisse vergleichen",
 
   "common.skipToMain": "Zum Hauptinhalt springen",
   "common.error": "Fehler",
@@ -26,6 +27,21 @@ export const de = Object.freeze({
   "network.publicIp": "Öffentliche IP-Adressen",
   "network.detecting": "Wird ermittelt…",
   "network.notDetected": "Nicht verfügbar",
+  "network.connection": "Verbindung",
+  "network.note.cgnat":
+    "Ihr Anbieter teilt diese Adresse mit anderen Kunden (Carrier-Grade NAT). Das kann die Geschwindigkeit begrenzen und eingehende Verbindungen verhindern.",
+  "network.note.private":
+    "Der Server sieht eine private Adresse. Er steht also in Ihrem lokalen Netz oder hinter einem VPN.",
+  "network.note.local":
+    "Der Server läuft auf diesem Gerät oder in Ihrem lokalen Netzsegment, daher wird die Internetverbindung nicht gemessen.",
+  "network.note.ipv4Mapped":
+    "Der Server hat eine IPv4-Verbindung über einen IPv6-Socket erhalten.",
+  "network.note.nat64":
+    "Ihre IPv4-Verbindung erreicht diesen Server über einen IPv6-Übersetzer (NAT64).",
+  "network.note.tunnel":
+    "Ihr IPv6-Verkehr läuft über einen Teredo- oder 6to4-Tunnel, was meist die Latenz erhöht.",
+  "network.note.proxy":
+    "Diese Adresse wurde vom Reverse Proxy des Servers gemeldet.",
   "test.progressAria": "Netzwerkmessung läuft",
   "test.progressText": "Netzwerk wird gemessen",
   "test.phasesAria": "Testphasen",
@@ -42,6 +58,9 @@ export const de = Object.freeze({
   "result.server": "Server",
   "result.tested": "Getestet am",
   "result.loading": "Ergebnis wird geladen…",
+  "result.deleteConfirm":
+    "Dieses geteilte Ergebnis löschen? Der Link funktioniert dann für niemanden mehr.",
+  "result.deleted": "Dieses Ergebnis wurde gelöscht.",
   "result.loadedLatencyAdvisory":
     "Die Latenz steigt unter Last. Anrufe und Spiele können dann stocken.",
   "metric.idleLatency": "Leerlauf",
@@ -64,16 +83,33 @@ export const de = Object.freeze({
   "history.rememberHint":
     "Speichert bis zu 10 Ergebnisse in diesem Browser. Beim Ausschalten werden sie gelöscht.",
 
+  "compare.heading": "Ergebnisse vergleichen",
+  "compare.inputLabel":
+    "Ergebnislinks oder IDs, eine pro Zeile. Die erste dient als Ausgangswert.",
+  "compare.submit": "Vergleichen",
+  "compare.loading": "Ergebnisse werden geladen…",
+  "compare.baseline": "Ausgangswert",
+  "compare.count": "Geben Sie {min} bis {max} Ergebnisse zum Vergleichen ein.",
+  "compare.invalidId": "Einer der Einträge ist weder Ergebnislink noch ID.",
+  "compare.duplicate": "Jedes Ergebnis kann nur einmal verglichen werden.",
+  "compare.notFound":
+    "Eines der Ergebnisse wurde nicht gefunden oder ist abgelaufen.",
+
   "action.testAgain": "Nochmal testen",
   "action.share": "Teilen",
   "action.runOwnTest": "Eigenen Speedtest starten",
+  "action.deleteResult": "Ergebnis löschen",
   "action.runSpeedTest": "Speedtest starten",
+  "action.compareResult": "Mit einem anderen Ergebnis vergleichen",
   "share.preparing": "Link wird erstellt…",
   "share.copied": "Link kopiert",
   "share.unavailable":
     "Der Link zum Teilen kann gerade nicht erstellt werden",
   "share.nativeTitle": "openByte-Speedtest-Ergebnis",
   "share.copyPrompt": "Diesen Link kopieren:",
+  "share.lifetimeAria": "Gültigkeit des geteilten Links",
+  "share.lifetimeDay": "{days} Tag behalten",
+  "share.lifetimeDays": "{days} Tage behalten",
   "nav.speedTest": "Speedtest",
   "nav.privacy": "Datenschutz",
   "nav.impressum": "Impressum",
@@ -94,10 +130,10 @@ export const de = Object.freeze({
     "Bei jeder Anfrage wird die Quell-IP-Adresse zwangsläufig von diesem Server und einem etwaigen Reverse-Proxy verarbeitet. openByte verwendet sie, um zu antworten, die öffentliche IPv4- und IPv6-Adresse anzuzeigen, Übertragungs- und Anfragelimits durchzusetzen und die Dienstkapazität zu schützen. Die Download- und Uploadtests tauschen bedeutungslose Zufallsdaten aus, die verworfen werden. Ein abgeschlossenes Messergebnis wird nur dann in die Ergebnisdatenbank geschrieben, wenn Sie Teilen wählen.",
   "privacy.share.heading": "Ergebnis teilen",
   "privacy.share.body":
-    "Erst das Aktivieren von Teilen sendet ein Ergebnis an die Serverdatenbank. Der Eintrag enthält Download, Upload, Latenz, Jitter, Latenz unter Last, Bufferbloat-Bewertung, die angezeigten öffentlichen IPv4- und IPv6-Adressen, den Servernamen und den Zeitpunkt des Teilens. Der zufällige Ergebnislink hat keine Zugriffskontrolle: Wer ihn kennt, kann den Eintrag ansehen.",
+    "Erst das Aktivieren von Teilen sendet ein Ergebnis an die Serverdatenbank. Der Eintrag enthält Download, Upload, Latenz, Jitter, Latenz unter Last, Bufferbloat-Bewertung, die angezeigten öffentlichen IPv4- und IPv6-Adressen, den Servernamen und den Zeitpunkt des Teilens. Der Betreiber kann den Server so einstellen, dass er die Adressen auf ihr Netz gekürzt (/24 bzw. /48), als schlüsselbasierten Hash oder gar nicht speichert und sie aus älteren Einträgen entfernt. Der zufällige Ergebnislink hat keine Zugriffskontrolle: Wer ihn kennt, kann den Eintrag ansehen. Der teilende Browser erhält einen geheimen Löschschlüssel und kann den Eintrag auf der Ergebnisseite entfernen; der Server speichert nur einen Hash dieses Schlüssels.",
   "privacy.local.heading": "Speicherung auf Ihrem Gerät",
   "privacy.local.body":
-    "Sprache und Farbschema werden erst nach Ihrer Auswahl im lokalen Speicher abgelegt. Der Verlauf letzter Tests ist standardmäßig ausgeschaltet; beim Einschalten speichert der Browser die Einstellung und bis zu 10 Ergebnisse (Messwerte, Zeitpunkt und Bewertung), bis Sie die Funktion ausschalten oder Websitedaten löschen. Die lokale Kopie wird nicht automatisch übertragen. Fehlgeschlagene optionale Adressabfragen bleiben nur im Arbeitsspeicher der Seite und werden nicht auf dem Gerät gespeichert.",
+    "Sprache und Farbschema werden erst nach Ihrer Auswahl im lokalen Speicher abgelegt. Der Verlauf letzter Tests ist standardmäßig ausgeschaltet; beim Einschalten speichert der Browser die Einstellung und bis zu 10 Ergebnisse (Messwerte, Zeitpunkt und Bewertung), bis Sie die Funktion ausschalten oder Websitedaten löschen. Die lokale Kopie wird nicht automatisch übertragen. Beim Teilen speichert der Browser den Löschschlüssel für bis zu 20 geteilte Ergebnisse, damit er sie später löschen kann; das Löschen der Websitedaten verwirft die Schlüssel. Fehlgeschlagene optionale Adressabfragen bleiben nur im Arbeitsspeicher der Seite und werden nicht auf dem Gerät gespeichert.",
   "privacy.tracking.heading": "Cookies und Tracking",
   "privacy.tracking.body":
     "openByte setzt keine Cookies, führt kein Analyse- oder Werbetracking durch und bindet keine Ressourcen Dritter ein. Schriften und andere Anwendungsdateien kommen von diesem Server. Erst wenn Sie einem externen Link oder einer vom Betreiber konfigurierten Weiterleitung folgen, wird eine Anfrage an dieses Ziel gesendet.",
@@ -106,10 +142,10 @@ export const de = Object.freeze({
     "API-Protokolle können Methode, Pfad, Status, Dauer und IP-Adresse enthalten. Der Betreiber und seine eingesetzten Reverse-Proxy-, Hosting-, Protokollierungs-, Speicher- oder Supportanbieter können Anfragedaten erhalten; wer einen geteilten Ergebnislink kennt, erhält diesen Eintrag. openByte selbst sendet keine Daten an Analyse- oder Werbenetzwerke. Nur der Betreiber kann in seinen vollständigen Hinweisen die tatsächlichen Auftragsverarbeiter, Drittlandübermittlungen und Garantien nennen.",
   "privacy.retention.heading": "Speicherdauer",
   "privacy.retention.body":
-    "Übertragene Testdaten werden mit der Anfrage verworfen. IP-Einträge für Anfragelimits bleiben im flüchtigen Arbeitsspeicher; inaktive Einträge können nach 10 Minuten bereinigt werden und verschwinden bei einer späteren Bereinigung oder einem Neustart. Ein stündlicher Vorgang entfernt geteilte Ergebnisse, sobald sie älter als 90 Tage sind; die konfigurierte Höchstzahl kann sie früher entfernen. Betriebsstörungen können die Bereinigung verzögern. Der Betreiber bestimmt die Aufbewahrung von Protokollen und etwaigen Sicherungen und muss diese Fristen in seinen Hinweisen nennen.",
+    "Übertragene Testdaten werden mit der Anfrage verworfen. IP-Einträge für Anfragelimits bleiben im flüchtigen Arbeitsspeicher; inaktive Einträge können nach 10 Minuten bereinigt werden und verschwinden bei einer späteren Bereinigung oder einem Neustart. Geteilte Ergebnisse werden nicht mehr ausgeliefert, sobald die beim Teilen gewählte Gültigkeit endet (standardmäßig 90 Tage, sofern der Betreiber nichts anderes einstellt); ein stündlicher Vorgang entfernt sie anschließend. Die konfigurierte Höchstzahl kann sie früher entfernen. Betriebsstörungen können die Bereinigung verzögern. Der Betreiber bestimmt die Aufbewahrung von Protokollen und etwaigen Sicherungen und muss diese Fristen in seinen Hinweisen nennen.",
   "privacy.rights.heading": "Ihre Wahlmöglichkeiten und Rechte",
   "privacy.rights.body":
-    "Soweit die DSGVO gilt, können Ihnen Rechte auf Auskunft, Berichtigung, Löschung, Einschränkung, Datenübertragbarkeit und Widerspruch sowie ein Beschwerderecht bei einer Datenschutzaufsichtsbehörde zustehen. Richten Sie diese Rechte an den Betreiber aus seinen Hinweisen; weil openByte keine Konten führt, können ein Ergebnislink oder Anfragedetails zur Zuordnung nötig sein. Schalten Sie den Verlauf aus oder löschen Sie Websitedaten, um im Browser gespeicherte Ergebnisse zu entfernen.",
+    "Soweit die DSGVO gilt, können Ihnen Rechte auf Auskunft, Berichtigung, Löschung, Einschränkung, Datenübertragbarkeit und Widerspruch sowie ein Beschwerderecht bei einer Datenschutzaufsichtsbehörde zustehen. Richten Sie diese Rechte an den Betreiber aus seinen Hinweisen; weil openByte keine Konten führt, können ein Ergebnislink oder Anfragedetails zur Zuordnung nötig sein. Ein aus diesem Browser geteiltes Ergebnis können Sie direkt auf seiner Ergebnisseite löschen. Schalten Sie den Verlauf aus oder löschen Sie Websitedaten, um im Browser gespeicherte Ergebnisse zu entfernen.",
   "privacy.required.heading": "Erforderliche Daten und automatisierte Entscheidungen",
   "privacy.required.body":
     "Eine IP-Adresse ist technisch erforderlich, damit der Server antworten kann; ohne Netzwerkanfrage können Seite und Speedtest nicht bereitgestellt werden. Eine Messung durchzuführen und sie zu teilen ist freiwillig, und openByte begründet keine gesetzliche oder vertragliche Pflicht zur Datenbereitstellung. Es erstellt keine Profile und trifft keine Entscheidung im Sinne des Art. 22 DSGVO.",
@@ -141,4 +177,6 @@ export const de = Object.freeze({
   "error.resultInvalidId": "Dieser Ergebnislink ist ungültig.",
   "error.resultTimeout":
     "Das Laden des Ergebnisses dauert zu lange. Bitte erneut versuchen.",
+  "error.resultDelete":
+    "Ergebnis kann nicht gelöscht werden. Bitte erneut versuchen.",
 });
@@ -2,6 +2,7 @@
 
 export const en = Object.freeze({
   "meta.shared.title": "openByte — Shared Result",
+  "meta.compare.title": "openByte — Compare Results",
 
   "common.skipToMain": "Skip to main content",
   "common.error": "Error",
@@ -26,6 +27,21 @@ export const en = Object.freeze({
   "network.publicIp": "Public IP addresses",
   "network.detecting": "Detecting…",
   "network.notDetected": "Not available",
+  "network.connection": "Connection",
+  "network.note.cgnat":
+    "Your provider shares this address between customers (carrier-grade NAT), which can limit speed and make incoming connections impossible.",
+  "network.note.private":
+    "The server sees a private address, so it is on your local network or behind a VPN.",
+  "network.note.local":
+    "The server is running on this device or your local link, so the internet connection is not measured.",
+  "network.note.ipv4Mapped":
+    "The server received an IPv4 connection through an IPv6 socket.",
+  "network.note.nat64":
+    "Your IPv4 connection reaches this server through an IPv6 translator (NAT64).",
+  "network.note.tunnel":
+    "Your IPv6 traffic runs through a Teredo or 6to4 tunnel, which usually adds latency.",
+  "network.note.proxy":
+    "This address was reported by the server's reverse proxy.",
   "test.progressAria": "Network measurement in progress",
   "test.progressText": "Measuring network",
   "test.phasesAria": "Test phases",
@@ -42,6 +58,9 @@ export const en = Object.freeze({
   "result.server": "Server",
   "result.tested": "Tested at",
   "result.loading": "Loading result…",
+  "result.deleteConfirm":
+    "Delete this shared result? The link will stop working for everyone.",
+  "result.deleted": "This result has been deleted.",
   "result.loadedLatencyAdvisory":
     "Latency rises under load, so calls and games may lag while the connection is busy.",
   "metric.idleLatency": "Idle",
@@ -64,15 +83,31 @@ export const en = Object.freeze({
   "history.rememberHint":
     "Stores up to 10 results in this browser. Turning this off deletes them.",
 
+  "compare.heading": "Compare results",
+  "compare.inputLabel":
+    "Result links or IDs, one per line. The first is the baseline.",
+  "compare.submit": "Compare",
+  "compare.loading": "Loading results…",
+  "compare.baseline": "Baseline",
+  "compare.count": "Enter {min} to {max} results to compare.",
+  "compare.invalidId": "One of the entries is not a result link or ID.",
+  "compare.duplicate": "Each result can only be compared once.",
+  "compare.notFound": "One of the results was not found or has expired.",
+
   "action.testAgain": "Test again",
   "action.share": "Share",
   "action.runOwnTest": "Run your own test",
+  "action.deleteResult": "Delete result",
   "action.runSpeedTest": "Run a speed test",
+  "action.compareResult": "Compare with another result",
   "share.preparing": "Creating link…",
   "share.copied": "Link copied",
   "share.unavailable": "Unable to create share link right now",
   "share.nativeTitle": "openByte Speed Test Result",
   "share.copyPrompt": "Copy this link:",
+  "share.lifetimeAria": "Shared link lifetime",
+  "share.lifetimeDay": "Keep {days} day",
+  "share.lifetimeDays": "Keep {days} days",
   "nav.speedTest": "Speed Test",
   "nav.privacy": "Privacy",
   "nav.impressum": "Legal Notice",
@@ -93,10 +128,10 @@ export const en = Object.freeze({
     "Every request necessarily exposes its source IP address to this server and any reverse proxy. openByte uses it to answer the request, display the public IPv4 and IPv6 addresses, enforce transfer and request limits, and protect service capacity. The download and upload tests exchange meaningless random bytes that are discarded. A completed measurement is not written to the results database unless you choose Share.",
   "privacy.share.heading": "Sharing a result",
   "privacy.share.body":
-    "Only activating Share sends a result to the server database. The record contains download, upload, latency, jitter, latency under load, bufferbloat grade, the displayed public IPv4 and IPv6 addresses, server name, and the time it was shared. The random result link has no access control: anyone who knows it can view the record.",
+    "Only activating Share sends a result to the server database. The record contains download, upload, latency, jitter, latency under load, bufferbloat grade, the displayed public IPv4 and IPv6 addresses, server name, and the time it was shared. The operator may configure the server to store the addresses shortened to their network (/24 or /48), as a keyed hash, or not at all, and to remove them from older records. The random result link has no access control: anyone who knows it can view the record. The browser that shared it receives a secret delete token and can remove the record from the result page; the server keeps only a hash of that token.",
   "privacy.local.heading": "Storage on your device",
   "privacy.local.body":
-    "Language and theme are stored in local storage only after you select them. Recent-test history is off by default; enabling it stores the setting and up to 10 results (measurements, time, and grade) in this browser until you turn it off or clear site data. The local copy is not transmitted automatically. Failed optional address probes are remembered only in page memory and are not written to device storage.",
+    "Language and theme are stored in local storage only after you select them. Recent-test history is off by default; enabling it stores the setting and up to 10 results (measurements, time, and grade) in this browser until you turn it off or clear site data. The local copy is not transmitted automatically. Sharing a result stores its delete token for up to 20 shared results so this browser can delete them later; clearing site data discards the tokens. Failed optional address probes are remembered only in page memory and are not written to device storage.",
   "privacy.tracking.heading": "Cookies and tracking",
   "privacy.tracking.body":
     "openByte sets no cookies, performs no analytics or advertising tracking, and embeds no third-party resources. Fonts and other application assets come from this server. Following an external link or an operator-configured redirect sends a request to that destination only after you navigate there.",
@@ -105,10 +140,10 @@ export const en = Object.freeze({
     "API logs may contain method, path, status, duration, and IP address. The operator and its configured reverse-proxy, hosting, logging, storage, or support providers may receive request data; anyone with a shared-result link receives that record. openByte itself sends no data to analytics or ad networks. Only the operator can name its actual processors, international transfers, and safeguards in its complete notice.",
   "privacy.retention.heading": "Retention",
   "privacy.retention.body":
-    "Transfer bytes are discarded with the request. IP rate-limit entries stay in volatile memory; inactive entries become eligible for cleanup after 10 minutes and disappear on later cleanup or a restart. An hourly job removes shared results once they are older than 90 days, and the configured count limit may remove them earlier. Operational failures can delay cleanup. The operator controls log and any backup retention and must disclose those periods in its notice.",
+    "Transfer bytes are discarded with the request. IP rate-limit entries stay in volatile memory; inactive entries become eligible for cleanup after 10 minutes and disappear on later cleanup or a restart. Shared results stop being served when the lifetime chosen while sharing ends (90 days by default, unless the operator configures otherwise), and an hourly job then removes them; the configured count limit may remove them earlier. Operational failures can delay cleanup. The operator controls log and any backup retention and must disclose those periods in its notice.",
   "privacy.rights.heading": "Your choices and rights",
   "privacy.rights.body":
-    "Where GDPR applies, you may have rights to access, rectification, erasure, restriction, data portability, and objection, and to complain to a supervisory authority. Exercise those rights against the operator named in its notice; because openByte has no accounts, a result link or request details may be needed to find data. Turn off recent-test history or clear site data to remove browser-stored results.",
+    "Where GDPR applies, you may have rights to access, rectification, erasure, restriction, data portability, and objection, and to complain to a supervisory authority. Exercise those rights against the operator named in its notice; because openByte has no accounts, a result link or request details may be needed to find data. A result shared from this browser can be deleted directly from its result page. Turn off recent-test history or clear site data to remove browser-stored results.",
   "privacy.required.heading": "Required data and automated decisions",
   "privacy.required.body":
     "An IP address is technically required for the server to answer; without a network request the page and speed test cannot be provided. Running a measurement and sharing it are optional, and openByte creates no statutory or contractual duty to provide data. It performs no profiling and makes no decision covered by Article 22 GDPR.",
@@ -137,4 +172,5 @@ export const en = Object.freeze({
   "error.resultRender": "Failed to render result.",
   "error.resultInvalidId": "This result link is invalid.",
   "error.resultTimeout": "Loading the result timed out. Please try again.",
+  "error.resultDelete": "Unable to delete the result. Please try again.",
 });
@@ -124,6 +124,18 @@ export function updateNetworkDisplay() {
   for (const element of [elements.idleNetworkIPv6, elements.networkIPv6]) {
     if (element) element.textContent = ipv6;
   }
+  if (elements.networkConnection) {
+    elements.networkConnection.textContent =
+      state.networkInfo.connection || "-";
+  }
+  if (elements.addressNote) {
+    const key = state.networkInfo.addressNote;
+    elements.addressNote.hidden = !key;
+    if (key) {
+      elements.addressNote.dataset.i18n = key;
+      elements.addressNote.textContent = t(key);
+    }
+  }
   if (elements.idleNetworkInfo) {
     elements.idleNetworkInfo.setAttribute(
       "aria-busy",
@@ -145,7 +157,12 @@ async function discoverAddress(
       TEST_CONFIG.HEALTH_CHECK_TIMEOUT_MS,
     );
     const data = await parseJSONOrThrow(response);
-    if (includeServerName) setServerName(data?.server_name);
+    if (includeServerName) {
+      setServerName(data?.server_name);
+      rememberTransferToken(data);
+      rememberResultRetention(data);
+      state.networkInfo.addressNote = addressNoteKey(data?.client_address);
+    }
     if (data.client_ip && shouldUpdate()) {
       const family = data.client_ip.includes(":") ? "ipv6" : "ipv4";
       state.networkInfo[family] = data.client_ip;
@@ -158,6 +175,99 @@ async function discoverAddress(
   }
 }
 
+// addressNoteKey picks the explanation for addresses that make results look
+// odd; transition mechanisms take precedence over the address scope.
+function addressNoteKey(address) {
+  const transitions = {
+    ipv4_mapped: "network.note.ipv4Mapped",
+    nat64: "network.note.nat64",
+    teredo: "network.note.tunnel",
+    "6to4": "network.note.tunnel",
+  };
+  const scopes = {
+    cgnat: "network.note.cgnat",
+    private: "network.note.private",
+    loopback: "network.note.local",
+    link_local: "network.note.local",
+  };
+  if (!address || typeof address !== "object") return null;
+  return (
+    transitions[address.transition] ||
+    scopes[address.scope] ||
+    (address.via_proxy ? "network.note.proxy" : null)
+  );
+}
+
+function rememberResultRetention(data) {
+  const defaultDays = Number(data?.result_retention_days);
+  const maxDays = Number(data?.result_max_retention_days);
+  state.resultRetention =
+    defaultDays > 0 && maxDays >= defaultDays ? { defaultDays, maxDays } : null;
+}
+
+function rememberTransferToken(data) {
+  const value =
+    typeof data?.transfer_token === "string" ? data.transfer_token : "";
+  const lifetimeSeconds = Number(data?.transfer_token_expires_in);
+  state.transferToken =
+    value && lifetimeSeconds > 0
+      ? { value, issuedAt: Date.now(), lifetimeMs: lifetimeSeconds * 1000 }
+      : null;
+}
+
+/**
+ * Returns a transfer token with at least half its lifetime left, refreshing
+ * it through the bootstrap ping. Servers that do not require tokens never
+ * issue one, so this resolves to "" without a request for them.
+ */
+export async function freshTransferToken(signal) {
+  const current = state.transferToken;
+  if (!current) return "";
+  if (Date.now() - current.issuedAt < current.lifetimeMs / 2) {
+    return current.value;
+  }
+  try {
+    const response = await fetchWithTimeout(
+      `${getApiBase()}/ping?meta=1`,
+      { cache: "no-store", signal },
+      TEST_CONFIG.HEALTH_CHECK_TIMEOUT_MS,
+    );
+    rememberTransferToken(await parseJSONOrThrow(response));
+  } catch (err) {
+    if (err.name === "AbortError" && signal?.aborted) throw err;
+    console.debug("transfer token refresh failed", err);
+  }
+  return state.transferToken?.value || "";
+}
+
+/** Loads the protocol, TLS, and RTT summary shown with the results. */
+export async function loadConnectionDetails() {
+  try {
+    const response = await fetchWithTimeout(
+      `${getApiBase()}/connection`,
+      { cache: "no-store" },
+      TEST_CONFIG.HEALTH_CHECK_TIMEOUT_MS,
+    );
+    state.networkInfo.connection = summarizeConnection(
+      await parseJSONOrThrow(response),
+    );
+  } catch (err) {
+    console.debug("connection details failed", err);
+    state.networkInfo.connection = null;
+  }
+  updateNetworkDisplay();
+}
+
+// summarizeConnection leaves out the RTT behind a proxy, where it measures
+// the proxy's link rather than the client's.
+function summarizeConnection(data) {
+  const parts = [data?.protocol, data?.tls?.version];
+  const rtt = Number(data?.socket?.rtt_ms);
+  if (!data?.via_proxy && rtt > 0) parts.push(`RTT ${rtt.toFixed(1)} ms`);
+  const summary = parts.filter((part) => typeof part === "string" && part);
+  return summary.length ? summary.join(" · ") : null;
+}
+
 export function getNextHopProtocol() {
   try {
     const entries = performance.getEntriesByType("resource");
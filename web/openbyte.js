@@ -31,6 +31,7 @@ import {
 } from "./network.js";
 import { renderHistory, saveHistoryEntry } from "./history.js";
 import { HISTORY_PREFERENCE_EVENT } from "./preferences.js";
+import { rememberDeleteToken } from "./result-ownership.js";
 
 function testErrorKey(error) {
   const code = error?.code;
@@ -173,6 +174,7 @@ export function resetToIdle() {
     elements.shareBtn.disabled = false;
     elements.shareBtn.textContent = t("action.share");
   }
+  elements.shareLifetime?.classList.add("hidden");
   resetProgress();
   showState("idle");
   hideError();
@@ -185,6 +187,7 @@ export async function saveAndEnableShare() {
   const generation = state.runGeneration;
   const loadedLat = Math.max(state.downloadLatency, state.uploadLatency);
   const bbGrade = computeBufferbloatGrade(state.latencyResult, loadedLat) || "";
+  const retentionDays = Number(elements.shareLifetime?.value) || 0;
 
   const savePromise = (async () => {
     const res = await fetch(`${getApiBase()}/results`, {
@@ -200,6 +203,7 @@ export async function saveAndEnableShare() {
         ipv4: state.networkInfo.ipv4 || "",
         ipv6: state.networkInfo.ipv6 || "",
         server_name: resolveServerName(),
+        ...(retentionDays > 0 && { retention_days: retentionDays }),
       }),
     });
     if (!res.ok) {
@@ -214,6 +218,8 @@ export async function saveAndEnableShare() {
       throw new Error("share save failed: invalid response");
     }
     state.resultId = data.id;
+    rememberDeleteToken(data.id, data.delete_token);
+    elements.shareLifetime?.classList.add("hidden");
     return state.resultId;
   })();
   state.shareSavePromise = savePromise;
@@ -0,0 +1,54 @@
+/** Delete tokens for results shared from this browser. */
+
+const STORAGE_KEY = "openbyte-delete-tokens";
+const MAX_STORED_TOKENS = 20;
+const TOKEN_PREFIX = "obd_";
+
+function isValidEntry(entry) {
+  return (
+    entry !== null &&
+    typeof entry === "object" &&
+    typeof entry.id === "string" &&
+    typeof entry.token === "string" &&
+    entry.token.startsWith(TOKEN_PREFIX)
+  );
+}
+
+function loadEntries() {
+  try {
+    const parsed = JSON.parse(localStorage.getItem(STORAGE_KEY) || "[]");
+    return Array.isArray(parsed) ? parsed.filter(isValidEntry) : [];
+  } catch {
+    return [];
+  }
+}
+
+function storeEntries(entries) {
+  try {
+    if (entries.length === 0) {
+      localStorage.removeItem(STORAGE_KEY);
+    } else {
+      localStorage.setItem(STORAGE_KEY, JSON.stringify(entries));
+    }
+  } catch {
+    // Storage unavailable: the result simply cannot be deleted later.
+  }
+}
+
+/** Keeps the token so the results page can offer deletion. */
+export function rememberDeleteToken(id, token) {
+  const entry = { id, token };
+  if (!isValidEntry(entry)) return;
+  const others = loadEntries().filter((e) => e.id !== id);
+  storeEntries([entry, ...others].slice(0, MAX_STORED_TOKENS));
+}
+
+export function deleteTokenFor(id) {
+  return loadEntries().find((e) => e.id === id)?.token || "";
+}
+
+export function forgetDeleteToken(id) {
+  const entries = loadEntries();
+  const remaining = entries.filter((e) => e.id !== id);
+  if (remaining.length !== entries.length) storeEntries(remaining);
+}
@@ -10,6 +10,7 @@ import {
   formatLoadedLatencyAdvisory,
   formatSpeed,
 } from "./presentation.js";
+import { deleteTokenFor, forgetDeleteToken } from "./result-ownership.js";
 
 const RESULTS_TIMEOUT_MS = 20000;
 const RESULT_ID_REGEX = /^[0-9a-zA-Z]{8}$/;
@@ -181,6 +182,57 @@ function renderResult(d) {
   }
 }
 
+async function deleteResult(resultID, token) {
+  const res = await fetchWithTimeout(
+    "/api/v1/results/" + resultID,
+    { method: "DELETE", headers: { Authorization: "Bearer " + token } },
+    RESULTS_TIMEOUT_MS,
+  );
+  await consumeErrorBody(res);
+  return res.status;
+}
+
+/** Links to the compare page with this result as the baseline. */
+function setupCompareLink(resultID) {
+  const link = document.getElementById("compareResultLink");
+  if (!link) return;
+  link.href = "/compare?ids=" + resultID;
+  link.classList.remove("hidden");
+}
+
+/** Offers deletion only in the browser that shared the result. */
+function setupDeleteButton(resultID) {
+  const button = document.getElementById("deleteResultBtn");
+  const status = document.getElementById("deleteStatus");
+  const token = deleteTokenFor(resultID);
+  if (!button || !token) return;
+  button.classList.remove("hidden");
+  button.addEventListener("click", async () => {
+    if (!globalThis.confirm(t("result.deleteConfirm"))) return;
+    button.disabled = true;
+    let statusCode = 0;
+    try {
+      statusCode = await deleteResult(resultID, token);
+    } catch (err) {
+      console.error("Result delete failed:", err);
+    }
+    if (statusCode === 204 || statusCode === 404) {
+      forgetDeleteToken(resultID);
+      showError("result.deleted");
+      return;
+    }
+    if (statusCode === 403) {
+      forgetDeleteToken(resultID);
+      button.classList.add("hidden");
+    }
+    button.disabled = false;
+    if (status) {
+      status.textContent = t("error.resultDelete");
+      status.classList.remove("hidden");
+    }
+  });
+}
+
 if (!loadingView || !resultView || !errorView) {
   console.error("Results page missing required view elements");
 } else {
@@ -197,6 +249,8 @@ if (!loadingView || !resultView || !errorView) {
       loadingView.classList.add("hidden");
       resultView.classList.remove("hidden");
       renderResult(data);
+      setupCompareLink(id);
+      setupDeleteButton(id);
     } catch (err) {
       console.error("Results fetch failed:", err);
       if (err?.name === "AbortError") {
@@ -15,6 +15,7 @@ import {
 import {
   resolveChunkSize,
   throwIfZeroBytes,
+  withTransferToken,
   applyHttpMeasureTick,
   createWarmUpDetector,
   createEarlyStopDetector,
@@ -89,6 +90,7 @@ async function runDownloadWindow(options) {
     onProgress,
     signal,
     isRamp = false,
+    transferToken,
   } = options;
   const startTime = performance.now();
   const endTimeRef = { value: startTime + duration * 1000 };
@@ -105,7 +107,10 @@ async function runDownloadWindow(options) {
 
   const downloadStream = async (chunk) => {
     const res = await fetchWithTimeout(
-      `${getApiBase()}/download?duration=${duration}&chunk=${chunk}`,
+      withTransferToken(
+        `${getApiBase()}/download?duration=${duration}&chunk=${chunk}`,
+        transferToken,
+      ),
       {
         method: "GET",
         cache: "no-store",
@@ -207,6 +212,7 @@ export async function runDownloadTest(onProgress, signal, options = {}) {
         ...windowOptions,
         onProgress,
         signal,
+        transferToken: options.config?.transferToken,
       }),
   });
 }
@@ -3,6 +3,13 @@
 import { TEST_CONFIG } from "./state.js";
 import { createCodedError } from "./utils.js";
 
+/** Appends the bootstrap-issued transfer token when the server requires one. */
+export function withTransferToken(url, transferToken) {
+  if (!transferToken) return url;
+  const separator = url.includes("?") ? "&" : "?";
+  return `${url}${separator}token=${encodeURIComponent(transferToken)}`;
+}
+
 export function resolveChunkSize() {
   return 1024 * 1024;
 }
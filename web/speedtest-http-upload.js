@@ -15,6 +15,7 @@ import {
 import {
   resolveChunkSize,
   throwIfZeroBytes,
+  withTransferToken,
   applyHttpMeasureIntervalTick,
   createWarmUpDetector,
   createEarlyStopDetector,
@@ -22,9 +23,9 @@ import {
 
 let uploadPayloadCache = null;
 
-async function sendUploadRequest(blob, duration, signal) {
+async function sendUploadRequest(blob, duration, signal, transferToken) {
   return fetchWithTimeout(
-    `${getApiBase()}/upload`,
+    withTransferToken(`${getApiBase()}/upload`, transferToken),
     {
       method: "POST",
       body: blob,
@@ -107,6 +108,7 @@ async function runSingleUploadStream(index, options) {
     metricsState,
     onProgress,
     measureContext,
+    transferToken,
   } = options;
   await sleep(streamDelayForIndex(index));
 
@@ -114,7 +116,12 @@ async function runSingleUploadStream(index, options) {
   while (performance.now() < endTimeRef.value && !signal.aborted) {
     try {
       const requestStart = performance.now();
-      const res = await sendUploadRequest(blob, duration, signal);
+      const res = await sendUploadRequest(
+        blob,
+        duration,
+        signal,
+        transferToken,
+      );
       if (res.ok) {
         const uploadedBytes = await readUploadResponseBytes(res, blob.size);
         metricsState.successfulStreams += 1;
@@ -162,6 +169,7 @@ async function runUploadWindow(options) {
     signal,
     isRamp = false,
     adaptive,
+    transferToken,
   } = options;
   const startTime = performance.now();
   const chunkSize = resolveChunkSize();
@@ -189,6 +197,7 @@ async function runUploadWindow(options) {
     metricsState,
     onProgress,
     measureContext,
+    transferToken,
   };
 
   const streamPromises = [];
@@ -227,6 +236,7 @@ export async function runUploadTest(onProgress, signal, options = {}) {
         ...windowOptions,
         onProgress,
         signal,
+        transferToken: options.config?.transferToken,
       }),
   });
 }
@@ -10,7 +10,11 @@ import {
 } from "./ui.js";
 import { resolveAdaptiveConfig } from "./speedtest-adaptive.js";
 import { createCodedError, fetchWithTimeout } from "./utils.js";
-import { getNextHopProtocol, updateNetworkDisplay } from "./network.js";
+import {
+  freshTransferToken,
+  getNextHopProtocol,
+  updateNetworkDisplay,
+} from "./network.js";
 
 /** Portion of a direction phase's progress allotted to the ramp-up stage. */
 const RAMP_PROGRESS_PORTION = 0.45;
@@ -112,7 +116,13 @@ function workerMessageError(message) {
   return error;
 }
 
-function runWorkerSpeedTest(direction, onProgress, signal, callbacks) {
+function runWorkerSpeedTest(
+  direction,
+  onProgress,
+  signal,
+  callbacks,
+  transferToken,
+) {
   if (typeof Worker === "undefined") {
     const error = createCodedError("worker.unsupported");
     error.name = "TypeError";
@@ -125,6 +135,7 @@ function runWorkerSpeedTest(direction, onProgress, signal, callbacks) {
   const config = {
     ...resolveAdaptiveConfig(),
     nextHopProtocol: getNextHopProtocol(),
+    transferToken,
   };
 
   return new Promise((resolve, reject) => {
@@ -235,15 +246,22 @@ async function runTest(direction, signal) {
   };
   let result;
   try {
-    result = await runWorkerSpeedTest(direction, onProgress, signal, {
-      onPhase: (_stage, _streams, info) => {
-        noteRampWindow(progressModel, info);
-      },
-      onMeasureStart: (streams, duration) => {
-        noteMeasureStart(progressModel, duration);
-        startMeasureLatencyProbe();
+    const transferToken = await freshTransferToken(signal);
+    result = await runWorkerSpeedTest(
+      direction,
+      onProgress,
+      signal,
+      {
+        onPhase: (_stage, _streams, info) => {
+          noteRampWindow(progressModel, info);
+        },
+        onMeasureStart: (streams, duration) => {
+          noteMeasureStart(progressModel, duration);
+          startMeasureLatencyProbe();
+        },
       },
-    });
+      transferToken,
+    );
   } finally {
     clearInterval(progressTick);
     if (latencyProbe) await latencyProbe.stop();
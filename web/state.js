@@ -21,8 +21,15 @@ export const state = {
     ipv4: null,
     ipv6: null,
     complete: false,
+    /** Locale key explaining the bootstrap address, or null. */
+    addressNote: null,
+    /** Protocol, TLS, and RTT summary from /api/v1/connection, or null. */
+    connection: null,
   },
   serverName: "openByte Server",
+  transferToken: null,
+  /** Shared-result lifetimes from the bootstrap ping, or null if unknown. */
+  resultRetention: null,
   resultId: null,
   shareSavePromise: null,
   serverOnline: false,
@@ -53,8 +60,10 @@ export function initElements() {
   elements.idleNetworkInfo = document.getElementById("idleNetworkInfo");
   elements.idleNetworkIPv4 = document.getElementById("idleNetworkIPv4");
   elements.idleNetworkIPv6 = document.getElementById("idleNetworkIPv6");
+  elements.addressNote = document.getElementById("addressNote");
   elements.networkIPv4 = document.getElementById("networkIPv4");
   elements.networkIPv6 = document.getElementById("networkIPv6");
+  elements.networkConnection = document.getElementById("networkConnection");
   elements.restartBtn = document.getElementById("restartBtn");
   elements.cancelBtn = document.getElementById("cancelBtn");
   elements.serverInfo = document.getElementById("serverInfo");
@@ -65,6 +74,7 @@ export function initElements() {
   elements.successToast = document.getElementById("successToast");
   elements.successMessage = document.getElementById("successMessage");
   elements.shareBtn = document.getElementById("shareBtn");
+  elements.shareLifetime = document.getElementById("shareLifetime");
   elements.speedSparkline = document.getElementById("speedSparkline");
   elements.phaseSteps = {
     ping: document.getElementById("phaseStepPing"),
@@ -9,7 +9,7 @@ import {
   formatSpeedText,
 } from "./presentation.js";
 import { computeBufferbloatGrade } from "./utils.js";
-import { updateNetworkDisplay } from "./network.js";
+import { loadConnectionDetails, updateNetworkDisplay } from "./network.js";
 import { renderHistory } from "./history.js";
 
 function bufferbloatBadgeClass(grade) {
@@ -51,11 +51,45 @@ function announceResults(grade) {
   });
 }
 
+const SHARE_LIFETIME_DAYS = [1, 7, 30];
+
+/** Lifetimes the server accepts, always including its default. */
+function shareLifetimeChoices(retention) {
+  const days = SHARE_LIFETIME_DAYS.filter((d) => d <= retention.maxDays);
+  if (!days.includes(retention.defaultDays)) days.push(retention.defaultDays);
+  return days.sort((a, b) => a - b);
+}
+
+function renderShareLifetime() {
+  const select = elements.shareLifetime;
+  if (!select) return;
+  const retention = state.resultRetention;
+  if (!retention || state.resultId) {
+    select.classList.add("hidden");
+    return;
+  }
+  const selected = Number(select.value) || retention.defaultDays;
+  select.replaceChildren(
+    ...shareLifetimeChoices(retention).map((days) => {
+      const option = document.createElement("option");
+      option.value = String(days);
+      option.textContent = t(
+        days === 1 ? "share.lifetimeDay" : "share.lifetimeDays",
+        { days },
+      );
+      option.selected = days === selected;
+      return option;
+    }),
+  );
+  select.classList.remove("hidden");
+}
+
 function renderShareButton() {
   if (!elements.shareBtn) return;
   elements.shareBtn.textContent = t(
     elements.shareBtn.disabled ? "share.preparing" : "action.share",
   );
+  renderShareLifetime();
 }
 
 export function renderResultsContent() {
@@ -93,6 +127,7 @@ export function renderResultsContent() {
   announceResults(grade);
 
   updateNetworkDisplay();
+  loadConnectionDetails();
   renderHistory(elements.historyList, elements.historySection);
   renderShareButton();
 }
@@ -104,5 +139,6 @@ export function enterResults() {
     elements.shareBtn.classList.remove("hidden");
     elements.shareBtn.disabled = false;
   }
+  if (elements.shareLifetime) elements.shareLifetime.value = "";
   renderResultsContent();
 }